@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 
 	logrusr "github.com/bombsimon/logrusr/v4"
@@ -13,14 +14,22 @@ import (
 	"github.com/go-chi/transport"
 	"github.com/hashicorp/go-cleanhttp"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth" // Load all auth plugins
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	ctrmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/apis/versionchecker/v1alpha1"
+	imagecache "github.com/jetstack/version-checker/pkg/cache"
 	"github.com/jetstack/version-checker/pkg/client"
+	"github.com/jetstack/version-checker/pkg/client/util"
 	"github.com/jetstack/version-checker/pkg/controller"
 	"github.com/jetstack/version-checker/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
@@ -38,7 +47,9 @@ func NewCommand(ctx context.Context) *cobra.Command {
 		Short: helpOutput,
 		Long:  helpOutput,
 		RunE: func(_ *cobra.Command, _ []string) error {
-			opts.complete()
+			if err := opts.complete(); err != nil {
+				return err
+			}
 
 			logLevel, err := logrus.ParseLevel(opts.LogLevel)
 			if err != nil {
@@ -61,7 +72,16 @@ func NewCommand(ctx context.Context) *cobra.Command {
 
 			log.Warnf("flag --test-all-containers=%t %s", opts.DefaultTestAll, defaultTestAllInfoMsg)
 
+			scheme := runtime.NewScheme()
+			if err := clientgoscheme.AddToScheme(scheme); err != nil {
+				return err
+			}
+			if err := v1alpha1.AddToScheme(scheme); err != nil {
+				return err
+			}
+
 			mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
+				Scheme:         scheme,
 				LeaderElection: false,
 				Metrics: server.Options{
 					BindAddress:   opts.MetricsServingAddress,
@@ -70,6 +90,11 @@ func NewCommand(ctx context.Context) *cobra.Command {
 				GracefulShutdownTimeout: &opts.GracefulShutdownTimeout,
 				Cache:                   cache.Options{SyncPeriod: &opts.CacheSyncPeriod},
 				PprofBindAddress:        opts.PprofBindAddress,
+				// Secrets and ServiceAccounts are only got for their
+				// imagePullSecrets, so aren't worth caching cluster wide
+				Client: k8sclient.Options{Cache: &k8sclient.CacheOptions{
+					DisableFor: []k8sclient.Object{&corev1.Secret{}, &corev1.ServiceAccount{}},
+				}},
 			})
 			if err != nil {
 				return err
@@ -100,6 +125,29 @@ func NewCommand(ctx context.Context) *cobra.Command {
 
 			metricsServer := metrics.New(log, ctrmetrics.Registry, mgr.GetCache())
 
+			rateLimits, err := util.ParseRegistryRateLimits(opts.RegistryRateLimits)
+			if err != nil {
+				return fmt.Errorf("failed to parse --registry-rate-limit: %s", err)
+			}
+			if len(rateLimits) > 0 || opts.RegistryMaxConcurrency > 0 {
+				opts.Client.RateLimiter = util.NewRateLimiter(rateLimits, opts.RegistryMaxConcurrency, metricsServer)
+			}
+
+			routes, err := client.ParseRoutes(opts.RegistryRoutes)
+			if err != nil {
+				return fmt.Errorf("failed to parse --registry-route: %s", err)
+			}
+			opts.Client.Routes = routes
+			opts.Client.DisableFallback = !opts.RegistryFallback
+
+			opts.Client.Retrier = util.NewRetrier(log, util.RetryOptions{Observer: metricsServer})
+
+			opts.Client.Files.DockerConfig = opts.DockerConfig
+
+			// Tag lists outlive the image cache entries built from them, so
+			// that refreshing an entry can be answered with 304 Not Modified
+			opts.Client.ResponseTimeout = 2 * (opts.CacheTimeout + opts.ImageCacheStaleTimeout)
+
 			opts.Client.Transport = transport.Chain(
 				cleanhttp.DefaultTransport(),
 				metricsServer.RoundTripper,
@@ -110,20 +158,94 @@ func NewCommand(ctx context.Context) *cobra.Command {
 				return fmt.Errorf("failed to setup image registry clients: %s", err)
 			}
 
-			_ = client
-
-			podController := controller.NewPodReconciler(opts.CacheTimeout,
-				metricsServer,
-				client,
-				mgr.GetClient(),
-				log,
-				opts.RequeueDuration,
-				opts.DefaultTestAll,
-			)
-			if err := podController.SetupWithManager(mgr); err != nil {
+			// Registry clients are rebuilt when their credential files change
+			if err := mgr.Add(manager.RunnableFunc(client.Watch)); err != nil {
 				return err
 			}
 
+			// Shows which registry client handles each host
+			if err := mgr.AddMetricsServerExtraHandler("/debug/registry-routes", client.RoutesHandler()); err != nil {
+				return fmt.Errorf("unable to set up registry routes endpoint: %s", err)
+			}
+
+			cacheStore, err := opts.newImageCacheStore(log, mgr.GetConfig(), scheme)
+			if err != nil {
+				return fmt.Errorf("failed to setup image cache store: %s", err)
+			}
+			if closer, ok := cacheStore.(io.Closer); ok {
+				defer func() {
+					if err := closer.Close(); err != nil {
+						log.WithError(err).Error("failed to close image cache store")
+					}
+				}()
+			}
+			if cacheStore != nil {
+				log.WithField("store", opts.ImageCacheStore).Info("Persisting image cache")
+			}
+
+			cacheOpts := imagecache.Options{
+				StaleTimeout: opts.ImageCacheStaleTimeout,
+				RefreshAhead: opts.ImageCacheRefreshAhead,
+				Recorder:     metricsServer,
+				Store:        cacheStore,
+			}
+
+			if opts.WorkloadMode {
+				workloadController := controller.NewWorkloadReconciler(opts.CacheTimeout,
+					cacheOpts,
+					metricsServer,
+					client,
+					mgr.GetClient(),
+					log,
+					opts.RequeueDuration,
+					opts.DefaultTestAll,
+					opts.CheckTemplates,
+					opts.ImageVersionReports,
+					opts.VersionCheckPolicies,
+					opts.ImagePullSecrets,
+					opts.MatchNodePlatform,
+					opts.Mirrors,
+				)
+				if err := workloadController.SetupWithManager(mgr); err != nil {
+					return err
+				}
+				log.Info("Workload mode enabled, reporting image versions per workload")
+			} else {
+				podController := controller.NewPodReconciler(opts.CacheTimeout,
+					cacheOpts,
+					metricsServer,
+					client,
+					mgr.GetClient(),
+					log,
+					opts.RequeueDuration,
+					opts.DefaultTestAll,
+					opts.ImageVersionReports,
+					opts.VersionCheckPolicies,
+					opts.ImagePullSecrets,
+					opts.MatchNodePlatform,
+					opts.Mirrors,
+				)
+				if err := podController.SetupWithManager(mgr); err != nil {
+					return err
+				}
+			}
+
+			if opts.ImageVersionReports {
+				log.Info("Writing results to ImageVersionReport resources")
+			}
+			if opts.VersionCheckPolicies {
+				log.Info("Applying VersionCheckPolicies and ClusterVersionCheckPolicies")
+			}
+			if opts.ImagePullSecrets {
+				log.Info("Authenticating registry lookups with Pod imagePullSecrets")
+			}
+			if opts.MatchNodePlatform {
+				log.Info("Only considering tags built for the platform of each Pod's Node")
+			}
+			if len(opts.Mirrors) > 0 {
+				log.WithField("mirrors", len(opts.Mirrors)).Info("Looking up images of mirrors upstream")
+			}
+
 			kubeController := controller.NewKubeReconciler(
 				log,
 				mgr.GetConfig(),
@@ -140,6 +262,24 @@ func NewCommand(ctx context.Context) *cobra.Command {
 				log.WithField("channel", opts.KubeChannel).Info("Kubernetes version checking enabled")
 			}
 
+			mirrorPairs, err := controller.ParseMirrorPairs(opts.MirrorChecks)
+			if err != nil {
+				return fmt.Errorf("failed to parse --mirror-check: %s", err)
+			}
+			mirrorController := controller.NewMirrorReconciler(
+				log,
+				client,
+				metricsServer,
+				opts.MirrorCheckInterval,
+				mirrorPairs,
+			)
+			if mirrorController != nil {
+				if err := mgr.Add(mirrorController); err != nil {
+					return err
+				}
+				log.WithField("mirrors", len(mirrorPairs)).Info("Mirror checking enabled")
+			}
+
 			// Start the manager and all controllers
 			log.Info("Starting controller manager")
 			if err := mgr.Start(ctx); err != nil {
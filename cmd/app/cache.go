@@ -0,0 +1,68 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagecache "github.com/jetstack/version-checker/pkg/cache"
+)
+
+const (
+	imageCacheStoreMemory    = "memory"
+	imageCacheStoreFile      = "file"
+	imageCacheStoreConfigMap = "configmap"
+	imageCacheStoreSecret    = "secret"
+)
+
+// newImageCacheStore returns the Store to persist cached image tags to, or nil
+// if the cache is only held in memory.
+func (o *Options) newImageCacheStore(log *logrus.Entry, restConfig *rest.Config, scheme *runtime.Scheme) (imagecache.Store, error) {
+	switch o.ImageCacheStore {
+	case "", imageCacheStoreMemory:
+		return nil, nil
+
+	case imageCacheStoreFile:
+		store, err := imagecache.NewBoltStore(o.ImageCacheFile)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+
+	case imageCacheStoreConfigMap, imageCacheStoreSecret:
+		if len(o.ImageCacheNamespace) == 0 || len(o.ImageCacheName) == 0 {
+			return nil, errors.New("--image-cache-namespace and --image-cache-name are required " +
+				"when --image-cache-store=" + o.ImageCacheStore)
+		}
+
+		// Tags fetched with a Pod's pull secrets may be of private
+		// repositories, which mustn't be readable by everyone who can read
+		// ConfigMaps in the namespace.
+		if o.ImageCacheStore == imageCacheStoreConfigMap && o.ImagePullSecrets {
+			return nil, errors.New("--image-cache-store=configmap cannot be used with --image-pull-secrets, " +
+				"as it would expose tags of private repositories, use --image-cache-store=secret instead")
+		}
+
+		// Use a client without an informer cache, so that ConfigMaps and
+		// Secrets aren't watched cluster wide.
+		client, err := k8sclient.New(restConfig, k8sclient.Options{Scheme: scheme})
+		if err != nil {
+			return nil, fmt.Errorf("failed to build kubernetes client: %w", err)
+		}
+
+		if o.ImageCacheStore == imageCacheStoreSecret {
+			return imagecache.NewSecretStore(log, client, o.ImageCacheNamespace, o.ImageCacheName), nil
+		}
+		return imagecache.NewConfigMapStore(log, client, o.ImageCacheNamespace, o.ImageCacheName), nil
+
+	default:
+		return nil, fmt.Errorf("unknown --image-cache-store %q, must be one of %q, %q, %q or %q",
+			o.ImageCacheStore, imageCacheStoreMemory, imageCacheStoreFile, imageCacheStoreConfigMap, imageCacheStoreSecret)
+	}
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+
+	imagecache "github.com/jetstack/version-checker/pkg/cache"
+)
+
+func TestNewImageCacheStore(t *testing.T) {
+	tests := map[string]struct {
+		opts     Options
+		expStore interface{}
+		expErr   string
+	}{
+		"memory has no store": {
+			opts: Options{ImageCacheStore: "memory"},
+		},
+		"file returns a bolt store": {
+			opts:     Options{ImageCacheStore: "file", ImageCacheFile: filepath.Join(t.TempDir(), "cache.db")},
+			expStore: &imagecache.BoltStore{},
+		},
+		"configmap returns a kube store": {
+			opts:     Options{ImageCacheStore: "configmap", ImageCacheNamespace: "default", ImageCacheName: "cache"},
+			expStore: &imagecache.KubeStore{},
+		},
+		"configmap cannot be used with pull secrets": {
+			opts:   Options{ImageCacheStore: "configmap", ImageCacheNamespace: "default", ImageCacheName: "cache", ImagePullSecrets: true},
+			expErr: "--image-cache-store=configmap cannot be used with --image-pull-secrets, as it would expose tags of private repositories, use --image-cache-store=secret instead",
+		},
+		"secret can be used with pull secrets": {
+			opts:     Options{ImageCacheStore: "secret", ImageCacheNamespace: "default", ImageCacheName: "cache", ImagePullSecrets: true},
+			expStore: &imagecache.KubeStore{},
+		},
+		"secret requires a namespace": {
+			opts:   Options{ImageCacheStore: "secret", ImageCacheName: "cache"},
+			expErr: "--image-cache-namespace and --image-cache-name are required when --image-cache-store=secret",
+		},
+		"unknown store": {
+			opts:   Options{ImageCacheStore: "redis"},
+			expErr: `unknown --image-cache-store "redis", must be one of "memory", "file", "configmap" or "secret"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			store, err := test.opts.newImageCacheStore(logrus.NewEntry(logrus.New()), &rest.Config{Host: "https://localhost:6443"}, runtime.NewScheme())
+			if len(test.expErr) > 0 {
+				assert.EqualError(t, err, test.expErr)
+				return
+			}
+			require.NoError(t, err)
+
+			if test.expStore == nil {
+				assert.Nil(t, store)
+				return
+			}
+			assert.IsType(t, test.expStore, store)
+			if bolt, ok := store.(*imagecache.BoltStore); ok {
+				assert.NoError(t, bolt.Close())
+			}
+		})
+	}
+}
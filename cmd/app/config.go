@@ -0,0 +1,611 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"sigs.k8s.io/yaml"
+
+	"github.com/jetstack/version-checker/pkg/client"
+	"github.com/jetstack/version-checker/pkg/client/selfhosted"
+	"github.com/jetstack/version-checker/pkg/client/util"
+	"github.com/jetstack/version-checker/pkg/controller/checker"
+)
+
+// Registry client types of the config file.
+const (
+	registryTypeSelfhosted = "selfhosted"
+	registryTypeOCI        = "oci"
+	registryTypeDocker     = "docker"
+	registryTypeQuay       = "quay"
+	registryTypeGHCR       = "ghcr"
+	registryTypeGCR        = "gcr"
+	registryTypeECR        = "ecr"
+	registryTypeACR        = "acr"
+)
+
+var registryTypes = []string{
+	registryTypeSelfhosted,
+	registryTypeOCI,
+	registryTypeDocker,
+	registryTypeQuay,
+	registryTypeGHCR,
+	registryTypeGCR,
+	registryTypeECR,
+	registryTypeACR,
+}
+
+// supportedAuth are the auth fields supported by each registry client type.
+var supportedAuth = map[string][]string{
+	registryTypeSelfhosted: {"username", "password", "token"},
+	registryTypeOCI:        {"username", "password"},
+	registryTypeDocker:     {"username", "password", "token", "tokenFile"},
+	registryTypeQuay:       {"token", "tokenFile"},
+	registryTypeGHCR:       {"token", "tokenFile"},
+	registryTypeGCR:        {"token", "workloadIdentity"},
+	registryTypeECR:        {"iamRoleARN", "accessKeyID", "secretAccessKey", "sessionToken", "webIdentityTokenFile"},
+	registryTypeACR:        {"username", "password", "refreshToken", "workloadIdentity"},
+}
+
+// Config is the declarative configuration of registries, read from the file
+// given with --config. Flags and environment variables take precedence over
+// its values.
+type Config struct {
+	// DockerConfig is the path of a Docker config file with credentials for
+	// any registry.
+	DockerConfig string `json:"dockerConfig,omitempty"`
+
+	// Registries configure the clients of registry hosts, and route the
+	// hosts to them.
+	Registries []RegistryConfig `json:"registries,omitempty"`
+
+	// Mirrors are mirrors whose images are looked up upstream. The first
+	// matching mirror applies.
+	Mirrors []MirrorConfig `json:"mirrors,omitempty"`
+
+	// MirrorChecks are mirrored images whose tags are compared to their
+	// upstream.
+	MirrorChecks []MirrorCheckConfig `json:"mirrorChecks,omitempty"`
+
+	// ContainerdMirrors is the path of a YAML file with a containerd style
+	// registry mirrors table, such as the registries.yaml of k3s and RKE2.
+	// Its mirrors apply after those of Mirrors.
+	ContainerdMirrors string `json:"containerdMirrors,omitempty"`
+}
+
+// RegistryConfig configures the client of a registry host.
+type RegistryConfig struct {
+	// Name identifies the registry in errors, and is the name of selfhosted
+	// registries.
+	Name string `json:"name,omitempty"`
+
+	// Host is the registry host. Selfhosted and OCI hosts may include an
+	// http[s] scheme. Hosts of other types may be glob patterns, such as
+	// *.dkr.ecr.*.amazonaws.com, to rate limit each matching host.
+	Host string `json:"host"`
+
+	// Type is the client of the registry, one of selfhosted, oci, docker,
+	// quay, ghcr, gcr, ecr or acr.
+	Type string `json:"type"`
+
+	// Auth is the credentials of the registry.
+	Auth *AuthConfig `json:"auth,omitempty"`
+
+	// CAFile, TokenPath and Insecure are only supported by selfhosted
+	// registries.
+	CAFile    string `json:"caFile,omitempty"`
+	TokenPath string `json:"tokenPath,omitempty"`
+	Insecure  bool   `json:"insecure,omitempty"`
+
+	// RateLimit is the request rate limit of the host, of the form
+	// <requests>/<s|m|h>.
+	RateLimit string `json:"rateLimit,omitempty"`
+}
+
+// AuthConfig is the credentials of a registry. The fields supported depend
+// on the registry's type.
+type AuthConfig struct {
+	Username     string `json:"username,omitempty"`
+	Password     string `json:"password,omitempty"`
+	Token        string `json:"token,omitempty"`
+	TokenFile    string `json:"tokenFile,omitempty"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+
+	IAMRoleARN           string `json:"iamRoleARN,omitempty"`
+	AccessKeyID          string `json:"accessKeyID,omitempty"`
+	SecretAccessKey      string `json:"secretAccessKey,omitempty"`
+	SessionToken         string `json:"sessionToken,omitempty"`
+	WebIdentityTokenFile string `json:"webIdentityTokenFile,omitempty"`
+
+	WorkloadIdentity bool `json:"workloadIdentity,omitempty"`
+}
+
+// MirrorConfig maps the images of a mirror to their upstream. Either Prefix
+// or Regex matches the images of the mirror.
+type MirrorConfig struct {
+	// Prefix is the image prefix of the mirror, such as
+	// harbor.corp/dockerhub-proxy.
+	Prefix string `json:"prefix,omitempty"`
+
+	// Regex matches the images of the mirror, such as
+	// ^harbor\.corp/(quay|ghcr)-proxy.
+	Regex string `json:"regex,omitempty"`
+
+	// Upstream replaces the prefix, or the match of the regex, such as
+	// docker.io. Upstreams of regexes may refer to their submatches, such as
+	// ${1}.io.
+	Upstream string `json:"upstream"`
+
+	// Transparent mirrors are configured in the container runtime, so their
+	// images are named after the upstream, and looked up as named. Images of
+	// the upstream are reported as pulled through the prefix. Transparent
+	// mirrors cannot use a regex.
+	Transparent bool `json:"transparent,omitempty"`
+}
+
+// MirrorCheckConfig is a mirrored image, and the upstream image it is
+// mirrored from, such as harbor.corp/library/nginx and
+// docker.io/library/nginx.
+type MirrorCheckConfig struct {
+	Mirror   string `json:"mirror"`
+	Upstream string `json:"upstream"`
+}
+
+// containerdRegistries is the containerd style registry config of
+// ContainerdMirrors. Only the endpoints of mirrors are read.
+type containerdRegistries struct {
+	Mirrors map[string]struct {
+		Endpoint []string `json:"endpoint"`
+	} `json:"mirrors"`
+}
+
+// loadConfig reads and validates the YAML or JSON config file.
+func loadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	cfg := new(Config)
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %q: %s", path, err)
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %q:\n%w", path, err)
+	}
+
+	if len(cfg.ContainerdMirrors) > 0 {
+		mirrors, err := loadContainerdMirrors(cfg.ContainerdMirrors)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Mirrors = append(cfg.Mirrors, mirrors...)
+	}
+
+	return cfg, nil
+}
+
+// loadContainerdMirrors reads the mirrors of a containerd style registry
+// config file. containerd pulls the images of a registry, which are named
+// after the registry, through its first endpoint, so each registry is a
+// transparent mirror of its first endpoint, with the endpoint's path as the
+// prefix of the mirrored images. The "*" registry, and registries whose
+// first endpoint is the registry itself, are skipped.
+func loadContainerdMirrors(path string) ([]MirrorConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read containerd mirrors file: %w", err)
+	}
+
+	// Registry configs and rewrites are allowed, but ignored.
+	var registries containerdRegistries
+	if err := yaml.Unmarshal(data, &registries); err != nil {
+		return nil, fmt.Errorf("failed to parse containerd mirrors file %q: %s", path, err)
+	}
+
+	var mirrors []MirrorConfig
+	for _, upstream := range slices.Sorted(maps.Keys(registries.Mirrors)) {
+		if upstream == "*" {
+			continue
+		}
+
+		// Later endpoints are only pulled through when the first fails
+		endpoints := registries.Mirrors[upstream].Endpoint
+		if len(endpoints) == 0 {
+			continue
+		}
+		u, err := url.Parse(endpoints[0])
+		if err != nil || len(u.Host) == 0 {
+			return nil, fmt.Errorf("invalid endpoint %q of containerd mirror %q in %q", endpoints[0], upstream, path)
+		}
+		if u.Host == upstream {
+			continue
+		}
+
+		// Endpoints may include the /v2 API path of the registry
+		prefix := strings.TrimSuffix(u.Path, "/")
+		if prefix == "/v2" || strings.HasPrefix(prefix, "/v2/") {
+			prefix = strings.TrimPrefix(prefix, "/v2")
+		}
+		mirrors = append(mirrors, MirrorConfig{
+			Prefix:      u.Host + prefix,
+			Upstream:    upstream,
+			Transparent: true,
+		})
+	}
+
+	return mirrors, nil
+}
+
+// validate returns the errors of every invalid registry and mirror.
+func (c *Config) validate() error {
+	var errs []error
+
+	// authBy is the registry configuring the credentials of each client
+	// type which has a single set of credentials.
+	authBy := make(map[string]string)
+	selfhostedHosts := make(map[string]string)
+	var ghcrHost string
+
+	for i, reg := range c.Registries {
+		field := fmt.Sprintf("registries[%d]", i)
+		if len(reg.Name) > 0 {
+			field = fmt.Sprintf("%s (%s)", field, reg.Name)
+		}
+		errorf := func(format string, args ...any) {
+			errs = append(errs, fmt.Errorf(field+": "+format, args...))
+		}
+
+		if !slices.Contains(registryTypes, reg.Type) {
+			errorf("type must be one of %s, got %q", strings.Join(registryTypes, ", "), reg.Type)
+			continue
+		}
+
+		host, err := reg.registryHost()
+		if err != nil {
+			errorf("%s", err)
+			continue
+		}
+
+		if strings.ContainsAny(host, "*?[") {
+			switch reg.Type {
+			case registryTypeSelfhosted, registryTypeOCI, registryTypeGHCR:
+				errorf("host must not be a glob pattern for %s registries", reg.Type)
+			default:
+				if reg.Auth != nil {
+					errorf("auth is not supported for glob pattern hosts")
+				}
+			}
+		}
+
+		if reg.Type != registryTypeSelfhosted {
+			for _, opt := range []struct {
+				name string
+				set  bool
+			}{
+				{"caFile", len(reg.CAFile) > 0},
+				{"tokenPath", len(reg.TokenPath) > 0},
+				{"insecure", reg.Insecure},
+			} {
+				if opt.set {
+					errorf("%s is only supported by selfhosted registries", opt.name)
+				}
+			}
+		}
+
+		if reg.Auth != nil {
+			set := reg.Auth.setFields()
+			for _, name := range set {
+				if !slices.Contains(supportedAuth[reg.Type], name) {
+					errorf("auth.%s is not supported by %s registries", name, reg.Type)
+				}
+			}
+			switch {
+			case slices.Contains(set, "token") && (slices.Contains(set, "username") || slices.Contains(set, "password")):
+				errorf("auth.token cannot be used with auth.username or auth.password")
+			case slices.Contains(set, "token") && slices.Contains(set, "tokenFile"):
+				errorf("auth.token cannot be used with auth.tokenFile")
+			}
+
+			if reg.Type != registryTypeSelfhosted && reg.Type != registryTypeOCI && len(set) > 0 {
+				if other, ok := authBy[reg.Type]; ok {
+					errorf("auth of %s registries is already configured by %s", reg.Type, other)
+				} else {
+					authBy[reg.Type] = field
+				}
+			}
+		}
+
+		switch reg.Type {
+		case registryTypeSelfhosted:
+			if other, ok := selfhostedHosts[host]; ok {
+				errorf("host %q is already configured by %s", host, other)
+			} else {
+				selfhostedHosts[host] = field
+			}
+
+		case registryTypeGHCR:
+			if host != "ghcr.io" {
+				if len(ghcrHost) > 0 && ghcrHost != host {
+					errorf("only one GitHub Enterprise host is supported, already configured %q", ghcrHost)
+				}
+				ghcrHost = host
+			}
+		}
+
+		if len(reg.RateLimit) > 0 {
+			if _, err := util.ParseRegistryRateLimits([]string{host + "=" + reg.RateLimit}); err != nil {
+				errorf("rateLimit must be of the form <requests>/<s|m|h>: %s", err)
+			}
+		}
+	}
+
+	for i, mirror := range c.Mirrors {
+		field := fmt.Sprintf("mirrors[%d]", i)
+		switch {
+		case len(mirror.Prefix) == 0 && len(mirror.Regex) == 0:
+			errs = append(errs, fmt.Errorf("%s: prefix or regex is required", field))
+		case len(mirror.Prefix) > 0 && len(mirror.Regex) > 0:
+			errs = append(errs, fmt.Errorf("%s: prefix cannot be used with regex", field))
+		case mirror.Transparent && len(mirror.Regex) > 0:
+			errs = append(errs, fmt.Errorf("%s: transparent cannot be used with regex", field))
+		case strings.Contains(mirror.Prefix, "://"):
+			errs = append(errs, fmt.Errorf("%s: prefix must not include a scheme", field))
+		case len(mirror.Regex) > 0:
+			if _, err := regexp.Compile(mirror.Regex); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid regex: %s", field, err))
+			}
+		}
+		switch {
+		case len(mirror.Upstream) == 0:
+			errs = append(errs, fmt.Errorf("%s: upstream is required", field))
+		case strings.Contains(mirror.Upstream, "://"):
+			errs = append(errs, fmt.Errorf("%s: upstream must not include a scheme", field))
+		}
+	}
+
+	for i, check := range c.MirrorChecks {
+		field := fmt.Sprintf("mirrorChecks[%d]", i)
+		for _, image := range []struct{ name, url string }{
+			{"mirror", check.Mirror},
+			{"upstream", check.Upstream},
+		} {
+			switch {
+			case len(image.url) == 0:
+				errs = append(errs, fmt.Errorf("%s: %s is required", field, image.name))
+			case strings.Contains(image.url, "://"):
+				errs = append(errs, fmt.Errorf("%s: %s must not include a scheme", field, image.name))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// registryHost returns the host of the registry, without its scheme.
+func (r *RegistryConfig) registryHost() (string, error) {
+	if len(r.Host) == 0 {
+		return "", errors.New("host is required")
+	}
+
+	if !strings.Contains(r.Host, "://") {
+		return r.Host, nil
+	}
+
+	if r.Type != registryTypeSelfhosted && r.Type != registryTypeOCI {
+		return "", fmt.Errorf("host must not include a scheme for %s registries", r.Type)
+	}
+	u, err := url.Parse(r.Host)
+	if err != nil {
+		return "", fmt.Errorf("invalid host: %s", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("host scheme must be http or https, got %q", u.Scheme)
+	}
+	if len(u.Host) == 0 || strings.Trim(u.Path, "/") != "" {
+		return "", fmt.Errorf("host must be of the form http[s]://<host>[:<port>], got %q", r.Host)
+	}
+
+	return u.Host, nil
+}
+
+// setFields returns the names of the auth fields which are set.
+func (a *AuthConfig) setFields() []string {
+	var set []string
+	for _, field := range []struct {
+		name string
+		set  bool
+	}{
+		{"username", len(a.Username) > 0},
+		{"password", len(a.Password) > 0},
+		{"token", len(a.Token) > 0},
+		{"tokenFile", len(a.TokenFile) > 0},
+		{"refreshToken", len(a.RefreshToken) > 0},
+		{"iamRoleARN", len(a.IAMRoleARN) > 0},
+		{"accessKeyID", len(a.AccessKeyID) > 0},
+		{"secretAccessKey", len(a.SecretAccessKey) > 0},
+		{"sessionToken", len(a.SessionToken) > 0},
+		{"webIdentityTokenFile", len(a.WebIdentityTokenFile) > 0},
+		{"workloadIdentity", a.WorkloadIdentity},
+	} {
+		if field.set {
+			set = append(set, field.name)
+		}
+	}
+	return set
+}
+
+// applyConfig sets the options of the validated config which are not
+// already set by flags or environment variables. The auth of a registry is
+// ignored if they set any credentials of its client.
+func (o *Options) applyConfig(cfg *Config) {
+	fill := func(assign *string, value string) {
+		if len(*assign) == 0 {
+			*assign = value
+		}
+	}
+	fillBool := func(assign *bool, value bool) {
+		*assign = *assign || value
+	}
+
+	fill(&o.DockerConfig, cfg.DockerConfig)
+
+	// The auth of a registry is ignored entirely if flags or environment
+	// variables set any credentials of its client, so that credentials of
+	// both are never mixed.
+	anySet := func(values ...string) bool {
+		return slices.ContainsFunc(values, func(value string) bool { return len(value) > 0 })
+	}
+	flagAuth := map[string]bool{
+		registryTypeDocker: anySet(o.Client.Docker.Username, o.Client.Docker.Password,
+			o.Client.Docker.Token, o.Client.Files.DockerToken),
+		registryTypeQuay: anySet(o.Client.Quay.Token, o.Client.Files.QuayToken),
+		registryTypeGHCR: anySet(o.Client.GHCR.Token, o.Client.Files.GHCRToken),
+		registryTypeGCR:  anySet(o.Client.GCR.Token) || o.Client.GCR.WorkloadIdentity,
+		registryTypeECR: anySet(o.Client.ECR.IamRoleArn, o.Client.ECR.AccessKeyID, o.Client.ECR.SecretAccessKey,
+			o.Client.ECR.SessionToken, o.Client.ECR.WebIdentityTokenFile),
+		registryTypeACR: anySet(o.Client.ACR.Username, o.Client.ACR.Password,
+			o.Client.ACR.RefreshToken) || o.Client.ACR.WorkloadIdentity,
+	}
+
+	keychain := make(util.HostKeychain)
+
+	for _, reg := range cfg.Registries {
+		host, _ := reg.registryHost()
+		auth := reg.Auth
+		if auth == nil || flagAuth[reg.Type] {
+			auth = new(AuthConfig)
+		}
+
+		switch reg.Type {
+		case registryTypeSelfhosted:
+			o.applySelfhostedConfig(reg, auth)
+
+		case registryTypeOCI:
+			if len(auth.Username) > 0 || len(auth.Password) > 0 {
+				keychain[host] = authn.AuthConfig{Username: auth.Username, Password: auth.Password}
+			}
+
+		case registryTypeDocker:
+			fill(&o.Client.Docker.Username, auth.Username)
+			fill(&o.Client.Docker.Password, auth.Password)
+			fill(&o.Client.Docker.Token, auth.Token)
+			fill(&o.Client.Files.DockerToken, auth.TokenFile)
+
+		case registryTypeQuay:
+			fill(&o.Client.Quay.Token, auth.Token)
+			fill(&o.Client.Files.QuayToken, auth.TokenFile)
+
+		case registryTypeGHCR:
+			fill(&o.Client.GHCR.Token, auth.Token)
+			fill(&o.Client.Files.GHCRToken, auth.TokenFile)
+			if host != "ghcr.io" {
+				fill(&o.Client.GHCR.Hostname, host)
+			}
+
+		case registryTypeGCR:
+			fill(&o.Client.GCR.Token, auth.Token)
+			fillBool(&o.Client.GCR.WorkloadIdentity, auth.WorkloadIdentity)
+
+		case registryTypeECR:
+			fill(&o.Client.ECR.IamRoleArn, auth.IAMRoleARN)
+			fill(&o.Client.ECR.AccessKeyID, auth.AccessKeyID)
+			fill(&o.Client.ECR.SecretAccessKey, auth.SecretAccessKey)
+			fill(&o.Client.ECR.SessionToken, auth.SessionToken)
+			fill(&o.Client.ECR.WebIdentityTokenFile, auth.WebIdentityTokenFile)
+
+		case registryTypeACR:
+			fill(&o.Client.ACR.Username, auth.Username)
+			fill(&o.Client.ACR.Password, auth.Password)
+			fill(&o.Client.ACR.RefreshToken, auth.RefreshToken)
+			fillBool(&o.Client.ACR.WorkloadIdentity, auth.WorkloadIdentity)
+		}
+
+		// The first matching route and rate limit applies, so those of
+		// flags win
+		o.RegistryRoutes = append(o.RegistryRoutes, host+"="+reg.Type)
+		if len(reg.RateLimit) > 0 {
+			o.RegistryRateLimits = append(o.RegistryRateLimits, host+"="+reg.RateLimit)
+		}
+	}
+
+	if len(keychain) > 0 {
+		o.Client.Keychain = keychain
+	}
+
+	for _, mirror := range cfg.Mirrors {
+		m := checker.Mirror{
+			Prefix:      mirror.Prefix,
+			Upstream:    mirror.Upstream,
+			Transparent: mirror.Transparent,
+		}
+		if len(mirror.Regex) > 0 {
+			m.Regex = regexp.MustCompile(mirror.Regex)
+		}
+		o.Mirrors = append(o.Mirrors, m)
+	}
+
+	for _, check := range cfg.MirrorChecks {
+		o.MirrorChecks = append(o.MirrorChecks, check.Mirror+"="+check.Upstream)
+	}
+}
+
+// applySelfhostedConfig merges the selfhosted registry into that of the
+// flags or environment variables with the same name or host, or adds it. Its
+// auth is ignored if they set any credentials.
+func (o *Options) applySelfhostedConfig(reg RegistryConfig, auth *AuthConfig) {
+	host := reg.Host
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+
+	name := reg.Name
+	if len(name) == 0 {
+		name = host
+	}
+
+	var opts *selfhosted.Options
+	for key, existing := range o.Client.Selfhosted {
+		// Names of environment variables are upper case
+		if strings.EqualFold(key, name) || existing.Host == host {
+			opts = existing
+
+			// Credentials of flags or environment variables replace those
+			// of the config, rather than being mixed with them
+			if len(existing.Username) > 0 || len(existing.Password) > 0 || len(existing.Bearer) > 0 ||
+				o.Client.Files.Selfhosted[key] != (client.SelfhostedFiles{}) {
+				auth = new(AuthConfig)
+			}
+			break
+		}
+	}
+	if opts == nil {
+		opts = new(selfhosted.Options)
+		o.Client.Selfhosted[name] = opts
+	}
+
+	for _, opt := range []struct {
+		assign *string
+		value  string
+	}{
+		{&opts.Host, host},
+		{&opts.Username, auth.Username},
+		{&opts.Password, auth.Password},
+		{&opts.Bearer, auth.Token},
+		{&opts.TokenPath, reg.TokenPath},
+		{&opts.CAPath, reg.CAFile},
+	} {
+		if len(*opt.assign) == 0 {
+			*opt.assign = opt.value
+		}
+	}
+	opts.Insecure = opts.Insecure || reg.Insecure
+}
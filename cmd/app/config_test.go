@@ -0,0 +1,394 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/version-checker/pkg/client"
+	"github.com/jetstack/version-checker/pkg/client/acr"
+	"github.com/jetstack/version-checker/pkg/client/docker"
+	"github.com/jetstack/version-checker/pkg/client/ecr"
+	"github.com/jetstack/version-checker/pkg/client/ghcr"
+	"github.com/jetstack/version-checker/pkg/client/quay"
+	"github.com/jetstack/version-checker/pkg/client/selfhosted"
+	"github.com/jetstack/version-checker/pkg/client/util"
+	"github.com/jetstack/version-checker/pkg/controller/checker"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		expErr string
+	}{
+		"valid yaml": {
+			config: `
+registries:
+- name: harbor
+  host: https://harbor.corp
+  type: selfhosted
+  auth:
+    username: user
+    password: pass
+  caFile: /etc/ssl/harbor.crt
+  rateLimit: 20/s
+- host: "*.dkr.ecr.*.amazonaws.com"
+  type: ecr
+  rateLimit: 100/m
+mirrors:
+- prefix: harbor.corp/dockerhub-proxy
+  upstream: docker.io
+- regex: ^harbor\.corp/(quay|ghcr)-proxy
+  upstream: ${1}.io
+mirrorChecks:
+- mirror: harbor.corp/library/nginx
+  upstream: docker.io/library/nginx
+`,
+		},
+		"valid json": {
+			config: `{"registries":[{"host":"quay.io","type":"quay","auth":{"tokenFile":"/secrets/quay"}}]}`,
+		},
+		"unknown fields are an error": {
+			config: `
+registries:
+- host: quay.io
+  type: quay
+  tokn: abc
+`,
+			expErr: `unknown field "tokn"`,
+		},
+		"unknown type": {
+			config: `
+registries:
+- host: registry.example.com
+  type: harbor
+`,
+			expErr: `registries[0]: type must be one of selfhosted, oci, docker, quay, ghcr, gcr, ecr, acr, got "harbor"`,
+		},
+		"missing host": {
+			config: `
+registries:
+- name: quay
+  type: quay
+`,
+			expErr: "registries[0] (quay): host is required",
+		},
+		"unsupported auth": {
+			config: `
+registries:
+- name: harbor
+  host: harbor.corp
+  type: selfhosted
+  auth:
+    refreshToken: abc
+`,
+			expErr: "registries[0] (harbor): auth.refreshToken is not supported by selfhosted registries",
+		},
+		"token with username": {
+			config: `
+registries:
+- host: docker.io
+  type: docker
+  auth:
+    username: user
+    token: abc
+`,
+			expErr: "registries[0]: auth.token cannot be used with auth.username or auth.password",
+		},
+		"selfhosted options of other types": {
+			config: `
+registries:
+- host: quay.io
+  type: quay
+  caFile: /etc/ssl/quay.crt
+`,
+			expErr: "registries[0]: caFile is only supported by selfhosted registries",
+		},
+		"scheme of other types": {
+			config: `
+registries:
+- host: https://quay.io
+  type: quay
+`,
+			expErr: "registries[0]: host must not include a scheme for quay registries",
+		},
+		"glob selfhosted host": {
+			config: `
+registries:
+- host: "*.corp"
+  type: selfhosted
+`,
+			expErr: "registries[0]: host must not be a glob pattern for selfhosted registries",
+		},
+		"invalid rate limit": {
+			config: `
+registries:
+- host: quay.io
+  type: quay
+  rateLimit: 100/d
+`,
+			expErr: "registries[0]: rateLimit must be of the form <requests>/<s|m|h>",
+		},
+		"auth configured twice": {
+			config: `
+registries:
+- host: quay.io
+  type: quay
+  auth:
+    token: abc
+- name: second
+  host: quay.io
+  type: quay
+  auth:
+    token: def
+`,
+			expErr: "registries[1] (second): auth of quay registries is already configured by registries[0]",
+		},
+		"duplicate selfhosted host": {
+			config: `
+registries:
+- host: harbor.corp
+  type: selfhosted
+- host: harbor.corp
+  type: selfhosted
+`,
+			expErr: `registries[1]: host "harbor.corp" is already configured by registries[0]`,
+		},
+		"mirror without upstream": {
+			config: `
+mirrors:
+- prefix: harbor.corp/dockerhub-proxy
+`,
+			expErr: "mirrors[0]: upstream is required",
+		},
+		"mirror with prefix and regex": {
+			config: `
+mirrors:
+- prefix: harbor.corp/dockerhub-proxy
+  regex: ^harbor\.corp/dockerhub-proxy
+  upstream: docker.io
+`,
+			expErr: "mirrors[0]: prefix cannot be used with regex",
+		},
+		"transparent mirror with regex": {
+			config: `
+mirrors:
+- regex: ^harbor\.corp/(quay|ghcr)-proxy
+  upstream: ${1}.io
+  transparent: true
+`,
+			expErr: "mirrors[0]: transparent cannot be used with regex",
+		},
+		"invalid mirror regex": {
+			config: `
+mirrors:
+- regex: ^harbor\.corp/(quay|ghcr-proxy
+  upstream: ${1}.io
+`,
+			expErr: "mirrors[0]: invalid regex: error parsing regexp: missing closing )",
+		},
+		"mirror check without mirror": {
+			config: `
+mirrorChecks:
+- upstream: docker.io/library/nginx
+`,
+			expErr: "mirrorChecks[0]: mirror is required",
+		},
+		"every error is returned": {
+			config: `
+registries:
+- type: quay
+- host: harbor.corp
+  type: harbor
+`,
+			expErr: "registries[0]: host is required\nregistries[1]: type must be one of",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(test.config), 0o600))
+
+			_, err := loadConfig(path)
+			if len(test.expErr) > 0 {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.expErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLoadContainerdMirrors(t *testing.T) {
+	tests := map[string]struct {
+		mirrors    string
+		expMirrors []MirrorConfig
+		expErr     string
+	}{
+		"registries are transparent mirrors of their first endpoint": {
+			mirrors: `
+mirrors:
+  docker.io:
+    endpoint:
+    - https://harbor.corp/v2/dockerhub-proxy
+    - https://mirror.gcr.io
+    - https://registry-1.docker.io
+    rewrite:
+      "^rancher/(.*)": "mirrorproject/rancher-images/$1"
+  quay.io:
+    endpoint:
+    - http://harbor.corp:8080/quay-proxy/
+  "*":
+    endpoint:
+    - https://harbor.corp
+configs:
+  harbor.corp:
+    auth:
+      username: user
+`,
+			expMirrors: []MirrorConfig{
+				{Prefix: "harbor.corp/dockerhub-proxy", Upstream: "docker.io", Transparent: true},
+				{Prefix: "harbor.corp:8080/quay-proxy", Upstream: "quay.io", Transparent: true},
+			},
+		},
+		"registries pulled from themselves first are skipped": {
+			mirrors: `
+mirrors:
+  harbor.corp:
+    endpoint:
+    - https://harbor.corp
+  ghcr.io:
+    endpoint:
+    - https://ghcr.io
+    - https://harbor.corp/ghcr-proxy
+`,
+		},
+		"invalid endpoint": {
+			mirrors: `
+mirrors:
+  docker.io:
+    endpoint:
+    - harbor.corp
+`,
+			expErr: `invalid endpoint "harbor.corp" of containerd mirror "docker.io"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			mirrorsPath := filepath.Join(dir, "registries.yaml")
+			require.NoError(t, os.WriteFile(mirrorsPath, []byte(test.mirrors), 0o600))
+
+			configPath := filepath.Join(dir, "config.yaml")
+			config := "mirrors:\n- prefix: artifactory.corp/docker-remote\n  upstream: docker.io\ncontainerdMirrors: " + mirrorsPath
+			require.NoError(t, os.WriteFile(configPath, []byte(config), 0o600))
+
+			cfg, err := loadConfig(configPath)
+			if len(test.expErr) > 0 {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.expErr)
+				return
+			}
+			require.NoError(t, err)
+
+			expMirrors := append([]MirrorConfig{{Prefix: "artifactory.corp/docker-remote", Upstream: "docker.io"}}, test.expMirrors...)
+			assert.Equal(t, expMirrors, cfg.Mirrors)
+		})
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	cfg := &Config{
+		DockerConfig: "/config/docker.json",
+		Registries: []RegistryConfig{
+			{Name: "harbor", Host: "harbor.corp", Type: registryTypeSelfhosted,
+				Auth: &AuthConfig{Username: "config-user", Password: "config-pass"}, CAFile: "/etc/ssl/harbor.crt"},
+			{Host: "https://nexus.corp", Type: registryTypeSelfhosted, Auth: &AuthConfig{Token: "nexus-token"}, Insecure: true},
+			{Host: "registry.example.com", Type: registryTypeOCI, Auth: &AuthConfig{Username: "oci-user", Password: "oci-pass"}},
+			{Host: "docker.io", Type: registryTypeDocker, Auth: &AuthConfig{Username: "config-user", Password: "config-pass"}, RateLimit: "100/h"},
+			{Host: "quay.io", Type: registryTypeQuay, Auth: &AuthConfig{TokenFile: "/secrets/quay"}},
+			{Host: "ghcr.corp", Type: registryTypeGHCR},
+			{Host: "*.dkr.ecr.*.amazonaws.com", Type: registryTypeECR, RateLimit: "20/s"},
+			{Host: "123.dkr.ecr.eu-west-1.amazonaws.com", Type: registryTypeECR,
+				Auth: &AuthConfig{AccessKeyID: "config-key", SecretAccessKey: "config-secret"}},
+			{Host: "myregistry.azurecr.io", Type: registryTypeACR, Auth: &AuthConfig{Username: "acr-user", Password: "acr-pass"}},
+		},
+		Mirrors: []MirrorConfig{
+			{Prefix: "harbor.corp/dockerhub-proxy", Upstream: "docker.io"},
+			{Regex: `^harbor\.corp/(quay|ghcr)-proxy`, Upstream: "${1}.io"},
+			{Prefix: "mirror.corp/gcr", Upstream: "gcr.io", Transparent: true},
+		},
+		MirrorChecks: []MirrorCheckConfig{
+			{Mirror: "harbor.corp/library/nginx", Upstream: "docker.io/library/nginx"},
+		},
+	}
+	require.NoError(t, cfg.validate())
+
+	// Flags and environment variables already set
+	o := &Options{
+		RegistryRateLimits: []string{"docker.io=10/m"},
+		MirrorChecks:       []string{"harbor.corp/library/redis=docker.io/library/redis"},
+		Client: client.Options{
+			Docker: docker.Options{Token: "flag-token"},
+			ECR:    ecr.Options{IamRoleArn: "arn:aws:iam::123:role/flag"},
+			Selfhosted: map[string]*selfhosted.Options{
+				"HARBOR": {Host: "https://harbor.corp", Password: "env-pass"},
+			},
+		},
+	}
+	o.applyConfig(cfg)
+
+	assert.Equal(t, "/config/docker.json", o.DockerConfig)
+	assert.Equal(t, []string{"docker.io=10/m", "docker.io=100/h", "*.dkr.ecr.*.amazonaws.com=20/s"}, o.RegistryRateLimits)
+	assert.Equal(t, []string{
+		"harbor.corp=selfhosted",
+		"nexus.corp=selfhosted",
+		"registry.example.com=oci",
+		"docker.io=docker",
+		"quay.io=quay",
+		"ghcr.corp=ghcr",
+		"*.dkr.ecr.*.amazonaws.com=ecr",
+		"123.dkr.ecr.eu-west-1.amazonaws.com=ecr",
+		"myregistry.azurecr.io=acr",
+	}, o.RegistryRoutes)
+	assert.Equal(t, []checker.Mirror{
+		{Prefix: "harbor.corp/dockerhub-proxy", Upstream: "docker.io"},
+		{Regex: regexp.MustCompile(`^harbor\.corp/(quay|ghcr)-proxy`), Upstream: "${1}.io"},
+		{Prefix: "mirror.corp/gcr", Upstream: "gcr.io", Transparent: true},
+	}, o.Mirrors)
+
+	assert.Equal(t, []string{"harbor.corp/library/redis=docker.io/library/redis", "harbor.corp/library/nginx=docker.io/library/nginx"}, o.MirrorChecks)
+
+	assert.Equal(t, map[string]*selfhosted.Options{
+		"HARBOR": {
+			Host:     "https://harbor.corp",
+			Password: "env-pass",
+			CAPath:   "/etc/ssl/harbor.crt",
+		},
+		"https://nexus.corp": {
+			Host:     "https://nexus.corp",
+			Bearer:   "nexus-token",
+			Insecure: true,
+		},
+	}, o.Client.Selfhosted)
+
+	assert.Equal(t, util.HostKeychain{
+		"registry.example.com": authn.AuthConfig{Username: "oci-user", Password: "oci-pass"},
+	}, o.Client.Keychain)
+
+	// Credentials of flags replace those of the config, rather than mixing
+	assert.Equal(t, docker.Options{Token: "flag-token"}, o.Client.Docker)
+	assert.Equal(t, quay.Options{}, o.Client.Quay)
+	assert.Equal(t, "/secrets/quay", o.Client.Files.QuayToken)
+	assert.Equal(t, ghcr.Options{Hostname: "ghcr.corp"}, o.Client.GHCR)
+	assert.Equal(t, ecr.Options{IamRoleArn: "arn:aws:iam::123:role/flag"}, o.Client.ECR)
+	assert.Equal(t, acr.Options{Username: "acr-user", Password: "acr-pass"}, o.Client.ACR)
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/jetstack/version-checker/pkg/api"
 	"github.com/jetstack/version-checker/pkg/client"
 	"github.com/jetstack/version-checker/pkg/client/selfhosted"
+	"github.com/jetstack/version-checker/pkg/controller/checker"
 )
 
 const (
@@ -26,21 +27,34 @@ const (
 	envACRRefreshToken = "ACR_REFRESH_TOKEN" // #nosec G101
 	envACRJWKSURI      = "ACR_JWKS_URI"
 
+	envACRWorkloadIdentity = "ACR_WORKLOAD_IDENTITY"
+
 	envDockerUsername = "DOCKER_USERNAME"
 	envDockerPassword = "DOCKER_PASSWORD" // #nosec G101
 	envDockerToken    = "DOCKER_TOKEN"    // #nosec G101
 
+	envDockerTokenFile = "DOCKER_TOKEN_FILE" // #nosec G101
+
 	envECRIamRoleArn      = "ECR_IAM_ROLE_ARN"
 	envECRAccessKeyID     = "ECR_ACCESS_KEY_ID"     // #nosec G101
 	envECRSecretAccessKey = "ECR_SECRET_ACCESS_KEY" // #nosec G101
 	envECRSessionToken    = "ECR_SESSION_TOKEN"     // #nosec G101
 
-	envGCRAccessToken = "GCR_TOKEN" // #nosec G101
+	envECRWebIdentityTokenFile = "ECR_WEB_IDENTITY_TOKEN_FILE" // #nosec G101
+
+	envGCRAccessToken      = "GCR_TOKEN" // #nosec G101
+	envGCRWorkloadIdentity = "GCR_WORKLOAD_IDENTITY"
 
 	envGHCRAccessToken = "GHCR_TOKEN" // #nosec G101
 	envGHCRHostname    = "GHCR_HOSTNAME"
+	envGHCRTokenFile   = "GHCR_TOKEN_FILE" // #nosec G101
+
+	envQuayToken     = "QUAY_TOKEN"      // #nosec G101
+	envQuayTokenFile = "QUAY_TOKEN_FILE" // #nosec G101
+
+	envDockerConfig = "DOCKER_CONFIG_FILE"
 
-	envQuayToken = "QUAY_TOKEN" // #nosec G101
+	envConfig = "CONFIG"
 
 	envSelfhostedPrefix    = "SELFHOSTED"
 	envSelfhostedUsername  = "USERNAME"
@@ -50,9 +64,17 @@ const (
 	envSelfhostedTokenPath = "TOKEN_PATH"
 	envSelfhostedInsecure  = "INSECURE"
 	envSelfhostedCAPath    = "CA_PATH"
+
+	envSelfhostedUsernameFile = "USERNAME_FILE"
+	envSelfhostedPasswordFile = "PASSWORD_FILE" // #nosec G101
+	envSelfhostedBearerFile   = "TOKEN_FILE"    // #nosec G101
 )
 
 var (
+	selfhostedUsernameFileReg = regexp.MustCompile("^VERSION_CHECKER_SELFHOSTED_USERNAME_FILE_(.*)")
+	selfhostedPasswordFileReg = regexp.MustCompile("^VERSION_CHECKER_SELFHOSTED_PASSWORD_FILE_(.*)")
+	selfhostedTokenFileReg    = regexp.MustCompile("^VERSION_CHECKER_SELFHOSTED_TOKEN_FILE_(.*)")
+
 	selfhostedHostReg     = regexp.MustCompile("^VERSION_CHECKER_SELFHOSTED_HOST_(.*)")
 	selfhostedUsernameReg = regexp.MustCompile("^VERSION_CHECKER_SELFHOSTED_USERNAME_(.*)")
 	selfhostedPasswordReg = regexp.MustCompile("^VERSION_CHECKER_SELFHOSTED_PASSWORD_(.*)")
@@ -67,22 +89,52 @@ type Options struct {
 	MetricsServingAddress string
 	PprofBindAddress      string
 
-	DefaultTestAll bool
-	LogLevel       string
+	DefaultTestAll       bool
+	WorkloadMode         bool
+	CheckTemplates       bool
+	ImageVersionReports  bool
+	VersionCheckPolicies bool
+	ImagePullSecrets     bool
+	MatchNodePlatform    bool
+	LogLevel             string
 
 	CacheTimeout            time.Duration
+	ImageCacheStaleTimeout  time.Duration
+	ImageCacheRefreshAhead  time.Duration
+	ImageCacheStore         string
+	ImageCacheFile          string
+	ImageCacheNamespace     string
+	ImageCacheName          string
 	GracefulShutdownTimeout time.Duration
 	CacheSyncPeriod         time.Duration
 	RequeueDuration         time.Duration
 
+	RegistryRateLimits     []string
+	RegistryMaxConcurrency int
+	RegistryRoutes         []string
+	RegistryFallback       bool
+
+	DockerConfig string
+
+	// ConfigFile is the path of the registry config file, and Mirrors are
+	// the mirrors it configures.
+	ConfigFile string
+	Mirrors    []checker.Mirror
+
+	// MirrorChecks are the mirrored images compared to their upstream, of
+	// the form <mirror>=<upstream>.
+	MirrorChecks        []string
+	MirrorCheckInterval time.Duration
+
 	KubeChannel  string
 	KubeInterval time.Duration
 
 	// kubeConfigFlags holds the flags for the kubernetes client
 	kubeConfigFlags *genericclioptions.ConfigFlags
 
-	selfhosted selfhosted.Options
-	Client     client.Options
+	selfhosted      selfhosted.Options
+	selfhostedFiles client.SelfhostedFiles
+	Client          client.Options
 }
 
 type envMatcher struct {
@@ -117,6 +169,14 @@ func (o *Options) addFlags(cmd *cobra.Command) {
 }
 
 func (o *Options) addAppFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.ConfigFile,
+		"config", "",
+		fmt.Sprintf(
+			"Path to a YAML or JSON file configuring registry hosts, their client type, auth, CA, "+
+				"rate limits and mirrors. Flags and environment variables take precedence over it (%s_%s).",
+			envPrefix, envConfig,
+		))
+
 	fs.StringVarP(&o.MetricsServingAddress,
 		"metrics-serving-address", "m", "0.0.0.0:8080",
 		"Address to serve metrics on at the /metrics path.")
@@ -130,6 +190,38 @@ func (o *Options) addAppFlags(fs *pflag.FlagSet) {
 		"If enabled, all containers will be tested, unless they have the "+
 			fmt.Sprintf(`annotation "%s/${my-container}=false".`, api.EnableAnnotationKey))
 
+	fs.BoolVarP(&o.WorkloadMode,
+		"workload-mode", "", false,
+		"If enabled, image versions are reported per workload (Deployment, StatefulSet, "+
+			"DaemonSet, Job, CronJob) rather than per pod.")
+
+	fs.BoolVarP(&o.CheckTemplates,
+		"check-templates", "", false,
+		"If enabled with --workload-mode, images in workload pod templates are checked "+
+			"when no pod is running them, resolving the current digest from the registry.")
+
+	fs.BoolVarP(&o.ImageVersionReports,
+		"image-version-reports", "", false,
+		"If enabled, results are also written to an ImageVersionReport resource per "+
+			"workload. Requires the ImageVersionReport CRD to be installed.")
+
+	fs.BoolVarP(&o.VersionCheckPolicies,
+		"version-check-policies", "", false,
+		"If enabled, VersionCheckPolicies and ClusterVersionCheckPolicies set the default "+
+			"search options of matching containers. Annotations take precedence. Requires "+
+			"the policy CRDs to be installed.")
+
+	fs.BoolVarP(&o.ImagePullSecrets,
+		"image-pull-secrets", "", false,
+		"If enabled, registry lookups of a container are authenticated with the "+
+			"imagePullSecrets of its Pod and ServiceAccount, preferring them over the "+
+			"configured credentials. Requires RBAC to get Secrets and ServiceAccounts.")
+
+	fs.BoolVarP(&o.MatchNodePlatform,
+		"match-node-platform", "", false,
+		"If enabled, only tags built for the platform (kubernetes.io/os and kubernetes.io/arch) "+
+			"of the Node a Pod runs on are considered. Requires RBAC to get, list and watch Nodes.")
+
 	fs.StringVarP(&o.LogLevel,
 		"log-level", "v", "info",
 		"Log level (debug, info, warn, error, fatal, panic).")
@@ -139,6 +231,69 @@ func (o *Options) addAppFlags(fs *pflag.FlagSet) {
 		"The time for an image version in the cache to be considered fresh. Images "+
 			"will be rechecked after this interval.")
 
+	fs.DurationVarP(&o.ImageCacheStaleTimeout,
+		"image-cache-stale-timeout", "", time.Minute*30,
+		"How long after --image-cache-timeout an image's tags are still served from "+
+			"the cache, while they are refreshed in the background. Set to 0 to fetch "+
+			"expired images before checking them.")
+
+	fs.DurationVarP(&o.ImageCacheRefreshAhead,
+		"image-cache-refresh-ahead", "", time.Minute*5,
+		"How long before expiry an image that is checked has its tags refreshed in "+
+			"the background, so that frequently checked images don't expire. Set to 0 "+
+			"to disable.")
+
+	fs.StringVarP(&o.ImageCacheStore,
+		"image-cache-store", "", imageCacheStoreMemory,
+		fmt.Sprintf("Where to persist cached image tags, so that the cache survives restarts. "+
+			"One of %q, %q (--image-cache-file), %q or %q (--image-cache-namespace and --image-cache-name).",
+			imageCacheStoreMemory, imageCacheStoreFile, imageCacheStoreConfigMap, imageCacheStoreSecret))
+
+	fs.StringVarP(&o.ImageCacheFile,
+		"image-cache-file", "", "/var/cache/version-checker/image-cache.db",
+		"The BoltDB file to persist cached image tags to, when --image-cache-store=file.")
+
+	fs.StringVarP(&o.ImageCacheNamespace,
+		"image-cache-namespace", "", "",
+		"The namespace of the ConfigMap or Secret to persist cached image tags to.")
+
+	fs.StringVarP(&o.ImageCacheName,
+		"image-cache-name", "", "version-checker-image-cache",
+		"The name of the ConfigMap or Secret to persist cached image tags to.")
+
+	fs.StringSliceVar(&o.RegistryRateLimits,
+		"registry-rate-limit", nil,
+		"Request rate limits for registry hosts, of the form <host>=<requests>/<s|m|h>, "+
+			"e.g. quay.io=100/m,*.azurecr.io=20/s. Hosts may be glob patterns, and the first "+
+			"matching limit applies. Each matching host has its own budget.")
+
+	fs.IntVar(&o.RegistryMaxConcurrency,
+		"registry-max-concurrency", 0,
+		"The maximum number of concurrent requests to each registry host. 0 is unlimited.")
+
+	fs.StringSliceVar(&o.RegistryRoutes,
+		"registry-route", nil,
+		fmt.Sprintf("Registry clients of registry hosts, of the form <host>=<client>, e.g. "+
+			"harbor.corp=oci. Hosts may be glob patterns, and the first matching route applies, "+
+			"before the hosts each client recognises. The client is one of %s.",
+			strings.Join(client.ClientNames, ", ")))
+
+	fs.BoolVar(&o.RegistryFallback,
+		"registry-fallback", true,
+		"If enabled, hosts which no route or client matches are looked up by trying the "+
+			"selfhosted, docker and oci clients in turn. If disabled, their lookups fail.")
+
+	fs.StringSliceVar(&o.MirrorChecks,
+		"mirror-check", nil,
+		"Mirrored images whose tags are compared to their upstream, of the form "+
+			"<mirror>=<upstream>, e.g. harbor.corp/library/nginx=docker.io/library/nginx. "+
+			"Upstream tags missing from the mirror, or with a different digest in it, are "+
+			"exported as version_checker_mirror_missing_tags.")
+
+	fs.DurationVar(&o.MirrorCheckInterval,
+		"mirror-check-interval", time.Hour,
+		"The time in which mirrors of --mirror-check are compared to their upstream.")
+
 	fs.DurationVarP(&o.RequeueDuration,
 		"requeue-duration", "r", time.Hour,
 		"The time a pod will be re-checked for new versions/tags")
@@ -187,6 +342,14 @@ func (o *Options) addAuthFlags(fs *pflag.FlagSet) {
 			"JWKS URI to verify the JWT access token received. If left blank, JWT token will not be verified. (%s_%s)",
 			envPrefix, envACRJWKSURI,
 		))
+	fs.BoolVarP(&o.Client.ACR.WorkloadIdentity,
+		"acr-workload-identity", "", false,
+		fmt.Sprintf(
+			"Exchange the federated token of Azure Workload Identity for access to azure container "+
+				"registries, when no other credentials are given. The client ID, tenant ID and token "+
+				"file are read from the environment variables injected by its webhook (%s_%s).",
+			envPrefix, envACRWorkloadIdentity,
+		))
 	///
 
 	// Docker
@@ -209,6 +372,13 @@ func (o *Options) addAuthFlags(fs *pflag.FlagSet) {
 				"username/password (%s_%s).",
 			envPrefix, envDockerToken,
 		))
+	fs.StringVar(&o.Client.Files.DockerToken,
+		"docker-token-file", "",
+		fmt.Sprintf(
+			"Path to a file containing the token to authenticate with docker registry, "+
+				"which is reloaded when it changes (%s_%s).",
+			envPrefix, envDockerTokenFile,
+		))
 	///
 
 	/// ECR
@@ -236,6 +406,15 @@ func (o *Options) addAuthFlags(fs *pflag.FlagSet) {
 			"ECR session token for read access to private registries (%s_%s).",
 			envPrefix, envECRSessionToken,
 		))
+	fs.StringVar(&o.Client.ECR.WebIdentityTokenFile,
+		"ecr-web-identity-token-file", "",
+		fmt.Sprintf(
+			"Path to a web identity token, such as a projected ServiceAccount token, which is exchanged "+
+				"for credentials of the IAM role ARN. Defaults to that of IRSA when the IAM role ARN is given. "+
+				"Without static credentials, the default AWS credential chain is used, including IRSA and "+
+				"EKS Pod Identity (%s_%s).",
+			envPrefix, envECRWebIdentityTokenFile,
+		))
 	///
 
 	/// GCR
@@ -245,6 +424,13 @@ func (o *Options) addAuthFlags(fs *pflag.FlagSet) {
 			"Access token for read access to private GCR registries (%s_%s).",
 			envPrefix, envGCRAccessToken,
 		))
+	fs.BoolVarP(&o.Client.GCR.WorkloadIdentity,
+		"gcr-workload-identity", "", false,
+		fmt.Sprintf(
+			"Use the access token of the metadata server, such as that of GKE Workload Identity, "+
+				"when no other credentials are given (%s_%s).",
+			envPrefix, envGCRWorkloadIdentity,
+		))
 	///
 
 	/// GHCR
@@ -260,6 +446,13 @@ func (o *Options) addAuthFlags(fs *pflag.FlagSet) {
 			"Override hostname for Github Enterprise instances (%s_%s).",
 			envPrefix, envGHCRHostname,
 		))
+	fs.StringVar(&o.Client.Files.GHCRToken,
+		"ghcr-token-file", "",
+		fmt.Sprintf(
+			"Path to a file containing the Personal Access token for GHCR releases, "+
+				"which is reloaded when it changes (%s_%s).",
+			envPrefix, envGHCRTokenFile,
+		))
 	///
 
 	/// Quay
@@ -269,6 +462,24 @@ func (o *Options) addAuthFlags(fs *pflag.FlagSet) {
 			"Access token for read access to private Quay registries (%s_%s).",
 			envPrefix, envQuayToken,
 		))
+	fs.StringVar(&o.Client.Files.QuayToken,
+		"quay-token-file", "",
+		fmt.Sprintf(
+			"Path to a file containing the access token for private Quay registries, "+
+				"which is reloaded when it changes (%s_%s).",
+			envPrefix, envQuayTokenFile,
+		))
+	///
+
+	/// Docker config
+	fs.StringVar(&o.DockerConfig,
+		"docker-config", "",
+		fmt.Sprintf(
+			"Path to a Docker config.json, or the .dockerconfigjson of a kubernetes.io/dockerconfigjson "+
+				"Secret, with credentials for any registry. Its auths, credHelpers and credsStore are used "+
+				"by clients which have no other credentials configured, and it is reloaded when it changes (%s_%s).",
+			envPrefix, envDockerConfig,
+		))
 	///
 
 	/// Selfhosted
@@ -291,6 +502,27 @@ func (o *Options) addAuthFlags(fs *pflag.FlagSet) {
 				"username/password (%s_%s_%s).",
 			envPrefix, envSelfhostedPrefix, envSelfhostedBearer,
 		))
+	fs.StringVar(&o.selfhostedFiles.Username,
+		"selfhosted-username-file", "",
+		fmt.Sprintf(
+			"Path to a file containing the username to authenticate with a selfhosted registry, "+
+				"which is reloaded when it changes (%s_%s_%s).",
+			envPrefix, envSelfhostedPrefix, envSelfhostedUsernameFile,
+		))
+	fs.StringVar(&o.selfhostedFiles.Password,
+		"selfhosted-password-file", "",
+		fmt.Sprintf(
+			"Path to a file containing the password to authenticate with a selfhosted registry, "+
+				"which is reloaded when it changes (%s_%s_%s).",
+			envPrefix, envSelfhostedPrefix, envSelfhostedPasswordFile,
+		))
+	fs.StringVar(&o.selfhostedFiles.Bearer,
+		"selfhosted-token-file", "",
+		fmt.Sprintf(
+			"Path to a file containing the token to authenticate with a selfhosted registry, "+
+				"which is reloaded when it changes (%s_%s_%s).",
+			envPrefix, envSelfhostedPrefix, envSelfhostedBearerFile,
+		))
 	fs.StringVar(&o.selfhosted.TokenPath,
 		"selfhosted-token-path", "",
 		fmt.Sprintf(
@@ -322,7 +554,7 @@ func (o *Options) addAuthFlags(fs *pflag.FlagSet) {
 	// }
 }
 
-func (o *Options) complete() {
+func (o *Options) complete() error {
 	o.Client.Selfhosted = make(map[string]*selfhosted.Options)
 
 	envs := os.Environ()
@@ -338,18 +570,26 @@ func (o *Options) complete() {
 		{envDockerUsername, &o.Client.Docker.Username},
 		{envDockerPassword, &o.Client.Docker.Password},
 		{envDockerToken, &o.Client.Docker.Token},
+		{envDockerTokenFile, &o.Client.Files.DockerToken},
 
 		{envECRIamRoleArn, &o.Client.ECR.IamRoleArn},
 		{envECRAccessKeyID, &o.Client.ECR.AccessKeyID},
 		{envECRSessionToken, &o.Client.ECR.SessionToken},
 		{envECRSecretAccessKey, &o.Client.ECR.SecretAccessKey},
+		{envECRWebIdentityTokenFile, &o.Client.ECR.WebIdentityTokenFile},
 
 		{envGCRAccessToken, &o.Client.GCR.Token},
 
 		{envGHCRAccessToken, &o.Client.GHCR.Token},
 		{envGHCRHostname, &o.Client.GHCR.Hostname},
+		{envGHCRTokenFile, &o.Client.Files.GHCRToken},
 
 		{envQuayToken, &o.Client.Quay.Token},
+		{envQuayTokenFile, &o.Client.Files.QuayToken},
+
+		{envDockerConfig, &o.DockerConfig},
+
+		{envConfig, &o.ConfigFile},
 	} {
 		for _, env := range envs {
 			if o.assignEnv(env, opt.key, opt.assign) {
@@ -358,7 +598,31 @@ func (o *Options) complete() {
 		}
 	}
 
+	for _, opt := range []struct {
+		key    string
+		assign *bool
+	}{
+		{envACRWorkloadIdentity, &o.Client.ACR.WorkloadIdentity},
+		{envGCRWorkloadIdentity, &o.Client.GCR.WorkloadIdentity},
+	} {
+		if value, ok := os.LookupEnv(envPrefix + "_" + opt.key); ok && !*opt.assign {
+			if b, err := strconv.ParseBool(value); err == nil {
+				*opt.assign = b
+			}
+		}
+	}
+
 	o.assignSelfhosted(envs)
+
+	if len(o.ConfigFile) > 0 {
+		cfg, err := loadConfig(o.ConfigFile)
+		if err != nil {
+			return err
+		}
+		o.applyConfig(cfg)
+	}
+
+	return nil
 }
 
 func (o *Options) assignEnv(env, key string, assign *string) bool {
@@ -385,9 +649,37 @@ func (o *Options) assignSelfhosted(envs []string) {
 			o.Client.Selfhosted[name] = new(selfhosted.Options)
 		}
 	}
+	setFile := func(name string, set func(files *client.SelfhostedFiles)) {
+		initOptions(name)
+		if o.Client.Files.Selfhosted == nil {
+			o.Client.Files.Selfhosted = make(map[string]client.SelfhostedFiles)
+		}
+		files := o.Client.Files.Selfhosted[name]
+		set(&files)
+		o.Client.Files.Selfhosted[name] = files
+	}
 
-	// Go maps iterate in random order - Using a slice to consistency
+	// Go maps iterate in random order - Using a slice to consistency. Files
+	// are matched first, as their keys share the prefixes of the values.
 	regexActions := []envMatcher{
+		{
+			re: selfhostedUsernameFileReg,
+			action: func(matches []string, value string) {
+				setFile(matches[1], func(files *client.SelfhostedFiles) { files.Username = value })
+			},
+		},
+		{
+			re: selfhostedPasswordFileReg,
+			action: func(matches []string, value string) {
+				setFile(matches[1], func(files *client.SelfhostedFiles) { files.Password = value })
+			},
+		},
+		{
+			re: selfhostedTokenFileReg,
+			action: func(matches []string, value string) {
+				setFile(matches[1], func(files *client.SelfhostedFiles) { files.Bearer = value })
+			},
+		},
 		{
 			re: selfhostedTokenPath,
 			action: func(matches []string, value string) {
@@ -461,6 +753,9 @@ func (o *Options) assignSelfhosted(envs []string) {
 	// If we have some selfhosted flags, lets set them here...
 	if len(o.selfhosted.Host) > 0 {
 		o.Client.Selfhosted[o.selfhosted.Host] = &o.selfhosted
+		if o.selfhostedFiles != (client.SelfhostedFiles{}) {
+			setFile(o.selfhosted.Host, func(files *client.SelfhostedFiles) { *files = o.selfhostedFiles })
+		}
 	}
 
 	if !validSelfHostedOpts(o) {
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/jetstack/version-checker/pkg/client"
 	"github.com/jetstack/version-checker/pkg/client/acr"
@@ -40,9 +41,15 @@ func TestComplete(t *testing.T) {
 				{"VERSION_CHECKER_ECR_ACCESS_KEY_ID", "ecr-access-token"},
 				{"VERSION_CHECKER_ECR_SECRET_ACCESS_KEY", "ecr-secret-access-token"},
 				{"VERSION_CHECKER_ECR_SESSION_TOKEN", "ecr-session-token"},
+				{"VERSION_CHECKER_ECR_WEB_IDENTITY_TOKEN_FILE", "/var/run/secrets/eks.amazonaws.com/serviceaccount/token"},
+				{"VERSION_CHECKER_ACR_WORKLOAD_IDENTITY", "true"},
 				{"VERSION_CHECKER_GCR_TOKEN", "gcr-token"},
+				{"VERSION_CHECKER_GCR_WORKLOAD_IDENTITY", "true"},
 				{"VERSION_CHECKER_GHCR_TOKEN", "ghcr-token"},
 				{"VERSION_CHECKER_QUAY_TOKEN", "quay-token"},
+				{"VERSION_CHECKER_DOCKER_TOKEN_FILE", "/vault/secrets/docker"},
+				{"VERSION_CHECKER_GHCR_TOKEN_FILE", "/vault/secrets/ghcr"},
+				{"VERSION_CHECKER_QUAY_TOKEN_FILE", "/vault/secrets/quay"},
 				{"VERSION_CHECKER_SELFHOSTED_HOST_FOO", "docker.joshvanl.com"},
 				{"VERSION_CHECKER_SELFHOSTED_USERNAME_FOO", "joshvanl"},
 				{"VERSION_CHECKER_SELFHOSTED_PASSWORD_FOO", "password"},
@@ -50,10 +57,11 @@ func TestComplete(t *testing.T) {
 			},
 			expOptions: client.Options{
 				ACR: acr.Options{
-					Username:     "acr-username",
-					Password:     "acr-password",
-					RefreshToken: "acr-token",
-					JWKSURI:      "acr-jwks-uri",
+					Username:         "acr-username",
+					Password:         "acr-password",
+					RefreshToken:     "acr-token",
+					JWKSURI:          "acr-jwks-uri",
+					WorkloadIdentity: true,
 				},
 				Docker: docker.Options{
 					Username: "docker-username",
@@ -61,13 +69,15 @@ func TestComplete(t *testing.T) {
 					Token:    "docker-token",
 				},
 				ECR: ecr.Options{
-					IamRoleArn:      "iam-role-arn",
-					AccessKeyID:     "ecr-access-token",
-					SecretAccessKey: "ecr-secret-access-token",
-					SessionToken:    "ecr-session-token",
+					IamRoleArn:           "iam-role-arn",
+					AccessKeyID:          "ecr-access-token",
+					SecretAccessKey:      "ecr-secret-access-token",
+					SessionToken:         "ecr-session-token",
+					WebIdentityTokenFile: "/var/run/secrets/eks.amazonaws.com/serviceaccount/token",
 				},
 				GCR: gcr.Options{
-					Token: "gcr-token",
+					Token:            "gcr-token",
+					WorkloadIdentity: true,
 				},
 				GHCR: ghcr.Options{
 					Token: "ghcr-token",
@@ -75,6 +85,11 @@ func TestComplete(t *testing.T) {
 				Quay: quay.Options{
 					Token: "quay-token",
 				},
+				Files: client.CredentialFiles{
+					DockerToken: "/vault/secrets/docker",
+					GHCRToken:   "/vault/secrets/ghcr",
+					QuayToken:   "/vault/secrets/quay",
+				},
 				Selfhosted: map[string]*selfhosted.Options{
 					"FOO": {
 						Host:     "docker.joshvanl.com",
@@ -180,7 +195,7 @@ func TestComplete(t *testing.T) {
 				t.Setenv(env[0], env[1])
 			}
 			o := new(Options)
-			o.complete()
+			require.NoError(t, o.complete())
 
 			assert.Exactly(t, test.expOptions, o.Client)
 		})
@@ -318,6 +333,28 @@ func TestAssignSelfhosted(t *testing.T) {
 				},
 			},
 		},
+		"credential files are read by the client": {
+			envs: []string{
+				"VERSION_CHECKER_SELFHOSTED_HOST_FOO=docker.joshvanl.com",
+				"VERSION_CHECKER_SELFHOSTED_USERNAME_FILE_FOO=/secrets/username",
+				"VERSION_CHECKER_SELFHOSTED_PASSWORD_FILE_FOO=/secrets/password",
+				"VERSION_CHECKER_SELFHOSTED_HOST_BAR=hello.world.com",
+				"VERSION_CHECKER_SELFHOSTED_TOKEN_FILE_BAR=/secrets/token",
+				"VERSION_CHECKER_SELFHOSTED_TOKEN_PATH_BAR=/artifactory/api/security/token",
+			},
+			expOptions: client.Options{
+				Selfhosted: map[string]*selfhosted.Options{
+					"FOO": {Host: "docker.joshvanl.com"},
+					"BAR": {Host: "hello.world.com", TokenPath: "/artifactory/api/security/token"},
+				},
+				Files: client.CredentialFiles{
+					Selfhosted: map[string]client.SelfhostedFiles{
+						"FOO": {Username: "/secrets/username", Password: "/secrets/password"},
+						"BAR": {Bearer: "/secrets/token"},
+					},
+				},
+			},
+		},
 		"ignore keys with no values": {
 			envs: []string{
 				"VERSION_CHECKER_SELFHOSTED_HOST_FOO=docker.joshvanl.com",
@@ -356,6 +393,7 @@ func TestAssignSelfhosted(t *testing.T) {
 			o.assignSelfhosted(test.envs)
 
 			assert.Exactly(t, test.expOptions.Selfhosted, o.Client.Selfhosted)
+			assert.Exactly(t, test.expOptions.Files, o.Client.Files)
 		})
 	}
 }
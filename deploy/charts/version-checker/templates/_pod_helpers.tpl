@@ -1,8 +1,62 @@
 {{- define "version-checker.pod.args" -}}
 - "--image-cache-timeout={{.Values.versionChecker.imageCacheTimeout}}"
+- "--image-cache-stale-timeout={{.Values.versionChecker.imageCacheStaleTimeout}}"
+- "--image-cache-refresh-ahead={{.Values.versionChecker.imageCacheRefreshAhead}}"
+{{- if ne .Values.versionChecker.imageCacheStore "memory" }}
+- "--image-cache-store={{ .Values.versionChecker.imageCacheStore }}"
+{{- end }}
+{{- if eq .Values.versionChecker.imageCacheStore "file" }}
+- "--image-cache-file={{ .Values.versionChecker.imageCacheFile }}"
+{{- end }}
+{{- if has .Values.versionChecker.imageCacheStore (list "configmap" "secret") }}
+- "--image-cache-namespace={{ .Release.Namespace }}"
+- "--image-cache-name={{ include "version-checker.name" . }}-image-cache"
+{{- end }}
 - "--log-level={{.Values.versionChecker.logLevel}}"
 - "--metrics-serving-address={{.Values.versionChecker.metricsServingAddress}}"
 - "--test-all-containers={{.Values.versionChecker.testAllContainers}}"
+{{- if .Values.dockerConfig.existingSecret }}
+- "--docker-config=/etc/version-checker/docker-config/.dockerconfigjson"
+{{- end }}
+{{- if or .Values.registryConfig.config .Values.registryConfig.existingSecret }}
+- "--config=/etc/version-checker/config/config.yaml"
+{{- end }}
+{{- with .Values.versionChecker.registryRateLimits }}
+- "--registry-rate-limit={{ join "," . }}"
+{{- end }}
+{{- if .Values.versionChecker.registryMaxConcurrency }}
+- "--registry-max-concurrency={{ .Values.versionChecker.registryMaxConcurrency }}"
+{{- end }}
+{{- with .Values.versionChecker.registryRoutes }}
+- "--registry-route={{ join "," . }}"
+{{- end }}
+{{- if not .Values.versionChecker.registryFallback }}
+- "--registry-fallback=false"
+{{- end }}
+{{- with .Values.versionChecker.mirrorChecks }}
+- "--mirror-check={{ join "," . }}"
+{{- end }}
+{{- with .Values.versionChecker.mirrorCheckInterval }}
+- "--mirror-check-interval={{ . }}"
+{{- end }}
+{{- if .Values.versionChecker.workloadMode }}
+- "--workload-mode=true"
+{{- if .Values.versionChecker.checkTemplates }}
+- "--check-templates=true"
+{{- end }}
+{{- end }}
+{{- if .Values.versionChecker.imageVersionReports }}
+- "--image-version-reports=true"
+{{- end }}
+{{- if .Values.versionChecker.versionCheckPolicies }}
+- "--version-check-policies=true"
+{{- end }}
+{{- if .Values.versionChecker.podImagePullSecrets }}
+- "--image-pull-secrets=true"
+{{- end }}
+{{- if .Values.versionChecker.matchNodePlatform }}
+- "--match-node-platform=true"
+{{- end }}
 {{- end -}}
 
 {{- define "version-checker.pod.envs.selfhosted" -}}
@@ -102,6 +156,10 @@
         name: {{ $chartname }}
         key: acr.password
   {{- end }}
+  {{- if .Values.acr.workloadIdentity }}
+  - name: VERSION_CHECKER_ACR_WORKLOAD_IDENTITY
+    value: "true"
+  {{- end }}
 {{- end -}}
 
 {{- define "version-checker.pod.envs.ecr" -}}
@@ -131,6 +189,10 @@
         name: {{ $chartname }}
         key: ecr.sessionToken
   {{- end }}
+  {{- if .Values.ecr.webIdentityTokenFile }}
+  - name: VERSION_CHECKER_ECR_WEB_IDENTITY_TOKEN_FILE
+    value: {{ .Values.ecr.webIdentityTokenFile }}
+  {{- end }}
 {{- end -}}
 
 {{- define "version-checker.pod.envs.quay" -}}
@@ -166,6 +228,10 @@
         name: {{ $chartname }}
         key: gcr.token
   {{- end -}}
+  {{- if .Values.gcr.workloadIdentity }}
+  - name: VERSION_CHECKER_GCR_WORKLOAD_IDENTITY
+    value: "true"
+  {{- end }}
 {{- end -}}
 
 
@@ -179,6 +245,20 @@
   secret:
     secretName: {{ include "version-checker.name" . }}
 {{- end }}
+{{- if eq .Values.versionChecker.imageCacheStore "file" }}
+- name: image-cache
+{{ toYaml .Values.versionChecker.imageCacheVolume | indent 2 -}}
+{{- end }}
+{{- if .Values.dockerConfig.existingSecret }}
+- name: docker-config
+  secret:
+    secretName: {{ .Values.dockerConfig.existingSecret }}
+{{- end }}
+{{- if or .Values.registryConfig.config .Values.registryConfig.existingSecret }}
+- name: registry-config
+  secret:
+    secretName: {{ .Values.registryConfig.existingSecret | default (printf "%s-config" (include "version-checker.name" .)) }}
+{{- end }}
 {{- if and .Values.extraVolumes (gt (len .Values.extraVolumes) 0) }}
 {{ toYaml .Values.extraVolumes -}}
 {{- end -}}
@@ -9,6 +9,14 @@ const (
 	// mirroring images.
 	OverrideURLAnnotationKey = "override-url.version-checker.io"
 
+	// RegistryClientAnnotationKey sets the registry client used to look up
+	// the image, e.g. oci, overriding the route of its host.
+	RegistryClientAnnotationKey = "registry-client.version-checker.io"
+
+	// PlatformAnnotationKey sets the platform, of the form <os>/<arch>, whose
+	// images tags must be built for, overriding that of the Pod's Node.
+	PlatformAnnotationKey = "platform.version-checker.io"
+
 	// UseSHAAnnotationKey is used to comparing the SHA digests of images. This
 	// is silently set to true if the container image using using the SHA digest
 	// as its tag.
@@ -27,6 +35,19 @@ const (
 	// e.g. v1.0.1-gke.3 v1.0.1-alpha.0, v1.2.3.4...
 	UseMetaDataAnnotationKey = "use-metadata.version-checker.io"
 
+	// StrictSemVerAnnotationKey will compare tags using SemVer 2.0 precedence.
+	// Pre-release identifiers are separated from '+build' metadata, which is
+	// ignored, and 'v' prefixed tags are equal to unprefixed tags.
+	StrictSemVerAnnotationKey = "strict-semver.version-checker.io"
+
+	// VersionSchemeAnnotationKey sets the scheme used to order tags. One of
+	// semver (default), calver, numeric-build, date or lexical.
+	VersionSchemeAnnotationKey = "version-scheme.version-checker.io"
+
+	// ConstraintAnnotationKey will only check versions matching the constraint
+	// expression, e.g. ">=1.20 <2.0", "~1.4", "^3" or "!=1.5.2".
+	ConstraintAnnotationKey = "constraint.version-checker.io"
+
 	// PinMajorAnnotationKey will pin the major version to check.
 	PinMajorAnnotationKey = "pin-major.version-checker.io"
 
@@ -1,18 +1,33 @@
 package api
 
-import "regexp"
+import (
+	"regexp"
+
+	"github.com/jetstack/version-checker/pkg/version/constraint"
+)
 
 // Options is used to describe what restrictions should be used for determining
 // the latest image.
 type Options struct {
 	OverrideURL *string `json:"override-url,omitempty"`
 
+	// RegistryClient is the name of the registry client which looks up the
+	// image, regardless of the route of its host, e.g. oci.
+	RegistryClient string `json:"registry-client,omitempty"`
+
+	// Platform is the platform which tags must be built for, if known.
+	Platform *Platform `json:"platform,omitempty"`
+
 	MatchRegex *string `json:"match-regex,omitempty"`
 
 	PinMajor *int64 `json:"pin-major,omitempty"`
 	PinMinor *int64 `json:"pin-minor,omitempty"`
 	PinPatch *int64 `json:"pin-patch,omitempty"`
 
+	Constraint *string `json:"constraint,omitempty"`
+
+	ConstraintMatcher *constraint.Constraint `json:"-"`
+
 	RegexMatcher *regexp.Regexp `json:"-"`
 
 	// UseSHA cannot be used with any other options
@@ -23,4 +38,13 @@ type Options struct {
 	// UseMetaData defines whether tags with '-alpha', '-debian.0' etc. is
 	// permissible.
 	UseMetaData bool `json:"use-metadata,omitempty"`
+
+	// StrictSemVer defines whether tags are compared using SemVer 2.0
+	// precedence, where '+build' metadata is ignored and 'v' prefixed tags are
+	// equal to unprefixed tags.
+	StrictSemVer bool `json:"strict-semver,omitempty"`
+
+	// VersionScheme is the name of the scheme used to order tags, e.g.
+	// calver. Defaults to semver.
+	VersionScheme string `json:"version-scheme,omitempty"`
 }
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"strings"
 	"time"
 )
 
@@ -29,11 +30,59 @@ func (i *ImageTag) MatchesSHA(sha string) bool {
 	return false
 }
 
+// VersionDistance describes how far an image version is behind the latest
+// version.
+type VersionDistance struct {
+	// Major, Minor and Patch are the semver deltas to the latest version.
+	// Only the most significant differing component is set, e.g. v1.2.3 to
+	// v2.0.1 is 1 major version behind.
+	Major int64
+	Minor int64
+	Patch int64
+
+	// Releases is the number of releases, matching the search options, that
+	// are newer than the current version up to and including the latest.
+	Releases int
+
+	// Age is how much older the current version is than the latest, based on
+	// the image timestamps. Zero if either timestamp is unknown.
+	Age time.Duration
+}
+
+// TagDrift describes whether the digest a mutable tag, such as stable or
+// 1.25, points to in the registry is still the digest a container runs.
+type TagDrift struct {
+	// Tag is the tag the container runs.
+	Tag string
+
+	// Drifted is true when the tag points to a different digest than the
+	// running one, such as after the tag was re-pushed, so the container
+	// needs to be restarted to run the current image of the tag.
+	Drifted bool
+}
+
+// Platform is the OS and architecture an image is built for, such as
+// linux/arm64.
 type Platform struct {
 	OS           OS
 	Architecture Architecture
 }
 
+// String returns the platform of the form <os>/<arch>.
+func (p Platform) String() string {
+	return string(p.OS) + "/" + string(p.Architecture)
+}
+
+// Matches returns whether the image tag is built for the platform. The OS is
+// only compared when both are known.
+func (p Platform) Matches(tag *ImageTag) bool {
+	if !strings.EqualFold(string(p.Architecture), string(tag.Architecture)) {
+		return false
+	}
+	return len(p.OS) == 0 || len(tag.OS) == 0 ||
+		strings.EqualFold(string(p.OS), string(tag.OS))
+}
+
 type OS string
 type Architecture string
 
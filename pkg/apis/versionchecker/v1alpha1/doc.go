@@ -0,0 +1,5 @@
+// Package v1alpha1 contains the version-checker.io v1alpha1 API group, used to
+// expose image version check results through the Kubernetes API.
+// +kubebuilder:object:generate=true
+// +groupName=version-checker.io
+package v1alpha1
@@ -0,0 +1,105 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WorkloadReference identifies the workload that a report belongs to.
+type WorkloadReference struct {
+	// APIVersion of the workload.
+	APIVersion string `json:"apiVersion"`
+	// Kind of the workload, e.g. Deployment, or Pod for Pods without a
+	// controller.
+	Kind string `json:"kind"`
+	// Name of the workload.
+	Name string `json:"name"`
+}
+
+// ImageVersionReportSpec defines the workload that is being reported on.
+type ImageVersionReportSpec struct {
+	// Workload is the top level controller of the checked Pods.
+	Workload WorkloadReference `json:"workload"`
+}
+
+// ContainerVersionStatus is the result of the latest version check of a
+// single container image.
+type ContainerVersionStatus struct {
+	// Name of the container.
+	Name string `json:"name"`
+	// Type of the container, either "container" or "init".
+	Type string `json:"type"`
+	// Image of the container.
+	Image string `json:"image"`
+	// CurrentVersion is the version of the image that is running.
+	// +optional
+	CurrentVersion string `json:"currentVersion,omitempty"`
+	// LatestVersion is the latest version of the image which matches the
+	// container's search options.
+	// +optional
+	LatestVersion string `json:"latestVersion,omitempty"`
+	// IsLatest is true when the container is running the latest version.
+	IsLatest bool `json:"isLatest"`
+	// Platform is the platform of the multi-platform image whose digest the
+	// running digest was compared to, such as linux/arm64, if known.
+	// +optional
+	Platform string `json:"platform,omitempty"`
+	// Lag is how long the latest version has been available while the
+	// container is not running it, if the registry reports a timestamp.
+	// +optional
+	Lag *metav1.Duration `json:"lag,omitempty"`
+	// LastChecked is the time the image was last checked.
+	LastChecked metav1.Time `json:"lastChecked"`
+	// LastError is the error of the last check, if it failed. The last
+	// successful versions are kept.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
+
+// ImageVersionReportStatus holds the version check results of the workload's
+// containers.
+type ImageVersionReportStatus struct {
+	// Containers holds a result for each checked container.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +listMapKey=type
+	Containers []ContainerVersionStatus `json:"containers,omitempty"`
+	// OutdatedContainers is the number of containers not running the latest
+	// version.
+	OutdatedContainers int32 `json:"outdatedContainers"`
+	// LastChecked is the most recent time any container was checked.
+	// +optional
+	LastChecked *metav1.Time `json:"lastChecked,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=ivr,categories=version-checker
+// +kubebuilder:printcolumn:name="Kind",type=string,JSONPath=`.spec.workload.kind`
+// +kubebuilder:printcolumn:name="Workload",type=string,JSONPath=`.spec.workload.name`
+// +kubebuilder:printcolumn:name="Outdated",type=integer,JSONPath=`.status.outdatedContainers`
+// +kubebuilder:printcolumn:name="Last Checked",type=date,JSONPath=`.status.lastChecked`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ImageVersionReport exposes the image version check results of a single
+// workload.
+type ImageVersionReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ImageVersionReportSpec   `json:"spec,omitempty"`
+	Status ImageVersionReportStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ImageVersionReportList contains a list of ImageVersionReports.
+type ImageVersionReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ImageVersionReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ImageVersionReport{}, &ImageVersionReportList{})
+}
@@ -0,0 +1,121 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VersionCheckOptions are the search options applied to matching containers.
+// They are the same as the options set by the *.version-checker.io
+// annotations, which take precedence when set on a Pod.
+type VersionCheckOptions struct {
+	// MatchRegex only considers tags matching this regex.
+	// +optional
+	MatchRegex *string `json:"matchRegex,omitempty"`
+	// PinMajor pins the major version to check.
+	// +optional
+	PinMajor *int64 `json:"pinMajor,omitempty"`
+	// PinMinor pins the minor version to check. Requires pinMajor.
+	// +optional
+	PinMinor *int64 `json:"pinMinor,omitempty"`
+	// PinPatch pins the patch version to check. Requires pinMajor and
+	// pinMinor.
+	// +optional
+	PinPatch *int64 `json:"pinPatch,omitempty"`
+	// Constraint only considers versions matching the constraint expression,
+	// e.g. ">=1.20 <2.0", "~1.4", "^3" or "!=1.5.2".
+	// +optional
+	Constraint *string `json:"constraint,omitempty"`
+	// UseMetadata allows tags with metadata, e.g. -alpha, -debian.0.
+	// +optional
+	UseMetadata bool `json:"useMetadata,omitempty"`
+	// StrictSemVer compares tags using SemVer 2.0 precedence.
+	// +optional
+	StrictSemVer bool `json:"strictSemVer,omitempty"`
+	// VersionScheme is the scheme used to order tags. One of semver,
+	// calver, numeric-build, date or lexical. Defaults to semver.
+	// +optional
+	VersionScheme string `json:"versionScheme,omitempty"`
+	// UseSHA compares image digests rather than tags. Cannot be used with
+	// any other semver options.
+	// +optional
+	UseSHA bool `json:"useSHA,omitempty"`
+	// ResolveSHAToTags resolves the digest of images pinned by digest to a
+	// tag.
+	// +optional
+	ResolveSHAToTags bool `json:"resolveSHAToTags,omitempty"`
+	// OverrideURL overrides the URL used to look up the image.
+	// +optional
+	OverrideURL *string `json:"overrideURL,omitempty"`
+}
+
+// VersionCheckPolicySpec selects containers and the search options to use
+// for them.
+type VersionCheckPolicySpec struct {
+	// Images is a list of glob patterns matched against the image registry
+	// and repository, without tag or digest, e.g. "quay.io/jetstack/*" or
+	// "*.azurecr.io/*". A "*" matches any characters, including "/". Docker
+	// Hub images are matched with their full name, e.g.
+	// "docker.io/library/nginx". All images match if empty.
+	// +optional
+	Images []string `json:"images,omitempty"`
+	// NamespaceSelector restricts a ClusterVersionCheckPolicy to Pods in
+	// matching namespaces. It is ignored by a VersionCheckPolicy, which only
+	// applies to its own namespace.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// Selector restricts the policy to Pods with matching labels.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// Options are the search options applied to matching containers.
+	Options VersionCheckOptions `json:"options"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=vcp,categories=version-checker
+
+// VersionCheckPolicy sets the default search options of matching containers
+// in its namespace.
+type VersionCheckPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VersionCheckPolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VersionCheckPolicyList contains a list of VersionCheckPolicies.
+type VersionCheckPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VersionCheckPolicy `json:"items"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=cvcp,categories=version-checker
+
+// ClusterVersionCheckPolicy sets the default search options of matching
+// containers in all namespaces.
+type ClusterVersionCheckPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VersionCheckPolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterVersionCheckPolicyList contains a list of
+// ClusterVersionCheckPolicies.
+type ClusterVersionCheckPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterVersionCheckPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(
+		&VersionCheckPolicy{}, &VersionCheckPolicyList{},
+		&ClusterVersionCheckPolicy{}, &ClusterVersionCheckPolicyList{},
+	)
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var _ Store = (*BoltStore)(nil)
+
+// boltBucket is the bucket that entries are stored in, keyed by cache index.
+var boltBucket = []byte("image-tags")
+
+// BoltStore persists cache entries to a local BoltDB file.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens, or creates, the BoltDB file at the given path.
+func NewBoltStore(path string) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache file %q: %w", path, err)
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	}); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to create cache bucket: %w", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// Load returns all unexpired entries, removing expired entries from the file.
+func (b *BoltStore) Load(_ context.Context) (map[string]Entry, error) {
+	entries := make(map[string]Entry)
+	now := time.Now()
+
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+
+		var expired [][]byte
+		if err := bucket.ForEach(func(k, v []byte) error {
+			var entry Entry
+			if err := json.Unmarshal(v, &entry); err != nil || entry.expired(now) {
+				expired = append(expired, k)
+				return nil
+			}
+			entries[string(k)] = entry
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Save writes the entry to the file.
+func (b *BoltStore) Save(_ context.Context, index string, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte(index), data)
+	})
+}
+
+// Delete removes the entry from the file.
+func (b *BoltStore) Delete(_ context.Context, index string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete([]byte(index))
+	})
+}
+
+// Close closes the file.
+func (b *BoltStore) Close() error {
+	return b.db.Close()
+}
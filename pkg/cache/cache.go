@@ -2,9 +2,11 @@ package cache
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
 
 	"github.com/jetstack/version-checker/pkg/api"
 	"github.com/patrickmn/go-cache"
@@ -14,8 +16,20 @@ import (
 type Cache struct {
 	log     *logrus.Entry
 	handler Handler
+	timeout time.Duration
 
 	store *cache.Cache
+
+	// fetches coalesces concurrent fetches of the same index.
+	fetches singleflight.Group
+
+	name         string
+	staleTimeout time.Duration
+	refreshAhead time.Duration
+	recorder     Recorder
+
+	// persist is the optional Store that image tag items are persisted to.
+	persist Store
 }
 
 // Handler is an interface for implementations of the cache fetch.
@@ -24,20 +38,154 @@ type Handler interface {
 	Fetch(ctx context.Context, index string, opts *api.Options) (interface{}, error)
 }
 
+// Recorder records cache events, e.g. as metrics. Events are recorded with
+// the name of the cache.
+type Recorder interface {
+	// CacheHit records an item served before expiry.
+	CacheHit(cache string)
+	// CacheMiss records an item fetched because it was not in the cache.
+	CacheMiss(cache string)
+	// CacheStale records an expired item served while it is refreshed.
+	CacheStale(cache string)
+	// CacheRefreshFailure records a failed background refresh.
+	CacheRefreshFailure(cache string)
+	// CacheCoalesced records a fetch that waited on the result of a
+	// concurrent fetch of the same item, rather than fetching it again.
+	CacheCoalesced(cache string)
+}
+
+// Options configure a Cache.
+type Options struct {
+	// Name is the name the cache events are recorded with.
+	Name string
+
+	// StaleTimeout is how long after expiry an item is still served, while
+	// a single background refresh fetches it again. Zero disables serving
+	// stale items, so that expired items are fetched on Get.
+	StaleTimeout time.Duration
+
+	// RefreshAhead is how long before expiry an item is refreshed in the
+	// background, when it is got. Zero disables refreshing ahead.
+	RefreshAhead time.Duration
+
+	// Recorder is the optional Recorder of cache events.
+	Recorder Recorder
+
+	// Store is the optional Store that image tag items are persisted to.
+	// Unexpired entries in the Store are loaded into the cache, so that it
+	// is warm after a restart.
+	Store Store
+}
+
+// entry is an item in the cache, along with what is needed to refresh it.
+type entry struct {
+	item       interface{}
+	expires    time.Time
+	fetchIndex string
+	opts       *api.Options
+
+	refreshing atomic.Bool
+}
+
 // New returns a new generic Cache.
 func New(log *logrus.Entry, timeout time.Duration, handler Handler) *Cache {
+	return NewWithOptions(context.Background(), log, timeout, handler, Options{})
+}
+
+// NewWithOptions returns a new generic Cache, configured with the given
+// Options. Failing to load the persisted Store is logged, and the cache
+// starts empty.
+func NewWithOptions(ctx context.Context, log *logrus.Entry, timeout time.Duration, handler Handler, opts Options) *Cache {
 	c := &Cache{
-		log:     log.WithField("cache", "handler"),
-		handler: handler,
-		store:   cache.New(timeout, timeout*2),
+		log:          log.WithField("cache", "handler"),
+		handler:      handler,
+		timeout:      timeout,
+		store:        cache.New(timeout+opts.StaleTimeout, (timeout+opts.StaleTimeout)*2),
+		name:         opts.Name,
+		staleTimeout: opts.StaleTimeout,
+		refreshAhead: opts.RefreshAhead,
+		recorder:     opts.Recorder,
+		persist:      opts.Store,
+	}
+	if c.recorder == nil {
+		c.recorder = nopRecorder{}
 	}
 	// Set our Cleanup hook
 	c.store.OnEvicted(c.cleanup)
+
+	if c.persist != nil {
+		c.load(ctx)
+	}
+
 	return c
 }
 
+// load warms the cache with the unexpired entries of the persisted Store.
+func (c *Cache) load(ctx context.Context) {
+	entries, err := c.persist.Load(ctx)
+	if err != nil {
+		c.log.WithError(err).Error("failed to load persisted cache, starting empty")
+		return
+	}
+
+	now := time.Now()
+	for index, e := range entries {
+		if e.expired(now) {
+			continue
+		}
+		fetchIndex := index
+		if len(e.FetchIndex) > 0 {
+			fetchIndex = e.FetchIndex
+		}
+		c.store.Set(index, &entry{item: e.Tags, expires: e.Expires, fetchIndex: fetchIndex},
+			e.Expires.Sub(now)+c.staleTimeout)
+	}
+	c.log.Infof("loaded %d items from persisted cache", c.store.ItemCount())
+}
+
 func (c *Cache) cleanup(key string, obj interface{}) {
 	c.log.Debugf("removing item from cache: %q", key)
+
+	if c.persist != nil {
+		if err := c.persist.Delete(context.Background(), key); err != nil {
+			c.log.WithError(err).Errorf("failed to delete persisted item: %q", key)
+		}
+	}
+}
+
+// set commits the item to the cache, and persists it.
+func (c *Cache) set(ctx context.Context, index, fetchIndex string, opts *api.Options, item interface{}) {
+	c.log.Debugf("committing item: %q", index)
+
+	e := &entry{
+		item:       item,
+		expires:    time.Now().Add(c.timeout),
+		fetchIndex: fetchIndex,
+		opts:       opts,
+	}
+	c.store.Set(index, e, cache.DefaultExpiration)
+	c.save(ctx, index, e)
+}
+
+// save persists the entry, if the cache has a Store and the item is a list
+// of image tags.
+func (c *Cache) save(ctx context.Context, index string, e *entry) {
+	if c.persist == nil {
+		return
+	}
+
+	tags, ok := e.item.([]api.ImageTag)
+	if !ok {
+		return
+	}
+
+	persisted := Entry{Tags: tags, Expires: e.expires}
+	if e.fetchIndex != index {
+		persisted.FetchIndex = e.fetchIndex
+	}
+	if err := c.persist.Save(ctx, index, persisted); err != nil {
+		c.log.WithError(err).Errorf("failed to persist item: %q", index)
+	}
 }
 
 func (c *Cache) Shutdown() {
@@ -45,30 +193,103 @@ func (c *Cache) Shutdown() {
 }
 
 // Get returns the cache item from the store given the index. Will populate
-// the cache if the index does not currently exist.
+// the cache if the index does not currently exist. Expired items within the
+// stale timeout are returned while they are refreshed in the background, as
+// are items got within the refresh ahead of their expiry.
 func (c *Cache) Get(ctx context.Context, index string, fetchIndex string, opts *api.Options) (item interface{}, err error) {
-	item, found := c.store.Get(index)
+	obj, found := c.store.Get(index)
+	if found {
+		e := obj.(*entry)
+		now := time.Now()
+
+		switch {
+		case !now.Before(e.expires):
+			c.log.Debugf("found stale: %q", index)
+			c.recorder.CacheStale(c.name)
+			c.refresh(ctx, index, e)
+
+		case c.refreshAhead > 0 && !now.Before(e.expires.Add(-c.refreshAhead)):
+			c.log.Debugf("found, refreshing ahead: %q", index)
+			c.recorder.CacheHit(c.name)
+			c.refresh(ctx, index, e)
+
+		default:
+			c.log.Debugf("found: %q", index)
+			c.recorder.CacheHit(c.name)
+		}
+
+		return e.item, nil
+	}
 
 	// If the item doesn't yet exist, Lets look it up
-	if !found {
-		// Fetch a new item to commit
-		item, err = c.handler.Fetch(ctx, fetchIndex, opts)
+	c.recorder.CacheMiss(c.name)
+	return c.fetch(ctx, index, fetchIndex, opts)
+}
+
+// fetch fetches the item and commits it to the cache. Concurrent fetches of
+// the same index wait on a single call to the handler, which is not
+// cancelled if the caller that started it is.
+func (c *Cache) fetch(ctx context.Context, index, fetchIndex string, opts *api.Options) (interface{}, error) {
+	fetchCtx := context.WithoutCancel(ctx)
+
+	// Only the caller that fetches runs the function
+	fetched := false
+	ch := c.fetches.DoChan(index, func() (interface{}, error) {
+		fetched = true
+		item, err := c.handler.Fetch(fetchCtx, fetchIndex, opts)
 		if err != nil {
 			return nil, err
 		}
 
 		// Commit to the cache
-		c.log.Debugf("committing item: %q", index)
-		c.store.Set(index, item, cache.DefaultExpiration)
+		c.set(fetchCtx, index, fetchIndex, opts, item)
+
+		return item, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-ch:
+		if result.Shared && !fetched {
+			c.recorder.CacheCoalesced(c.name)
+		}
+		return result.Val, result.Err
 	}
-	c.log.Debugf("found: %q", index)
+}
+
+// refresh fetches the entry again in the background, unless it is already
+// being refreshed. On failure, the entry is kept, and is refreshed again the
+// next time it is got.
+func (c *Cache) refresh(ctx context.Context, index string, e *entry) {
+	if !e.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+
+	// The refresh outlives the caller
+	ctx = context.WithoutCancel(ctx)
 
-	return item, err
+	go func() {
+		if _, err := c.fetch(ctx, index, e.fetchIndex, e.opts); err != nil {
+			c.log.WithError(err).Errorf("failed to refresh item: %q", index)
+			e.refreshing.Store(false)
+			c.recorder.CacheRefreshFailure(c.name)
+		}
+	}()
 }
 
 func (c *Cache) Update(index string, item interface{}) {
-	c.store.SetDefault(index, item)
+	c.set(context.Background(), index, index, nil, item)
 }
 func (c *Cache) Delete(index string) {
 	c.store.Delete(index)
 }
+
+// nopRecorder is the Recorder of caches without one.
+type nopRecorder struct{}
+
+func (nopRecorder) CacheHit(string)            {}
+func (nopRecorder) CacheMiss(string)           {}
+func (nopRecorder) CacheStale(string)          {}
+func (nopRecorder) CacheRefreshFailure(string) {}
+func (nopRecorder) CacheCoalesced(string)      {}
@@ -0,0 +1,227 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+// countingHandler returns the number of times it has been called as the item.
+type countingHandler struct {
+	mu    sync.Mutex
+	calls int
+	err   error
+
+	// release, if set, blocks Fetch until it is closed.
+	release chan struct{}
+}
+
+func (h *countingHandler) Fetch(_ context.Context, _ string, _ *api.Options) (interface{}, error) {
+	if h.release != nil {
+		<-h.release
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.calls++
+	if h.err != nil {
+		return nil, h.err
+	}
+	return h.calls, nil
+}
+
+func (h *countingHandler) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.calls
+}
+
+type fakeRecorder struct {
+	mu     sync.Mutex
+	events map[string]int
+}
+
+func (r *fakeRecorder) record(event, cache string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.events == nil {
+		r.events = make(map[string]int)
+	}
+	r.events[cache+"/"+event]++
+}
+
+func (r *fakeRecorder) get(event string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.events["test/"+event]
+}
+
+func (r *fakeRecorder) CacheHit(cache string)            { r.record("hit", cache) }
+func (r *fakeRecorder) CacheMiss(cache string)           { r.record("miss", cache) }
+func (r *fakeRecorder) CacheStale(cache string)          { r.record("stale", cache) }
+func (r *fakeRecorder) CacheRefreshFailure(cache string) { r.record("refresh_failure", cache) }
+func (r *fakeRecorder) CacheCoalesced(cache string)      { r.record("coalesced", cache) }
+
+func TestGet(t *testing.T) {
+	ctx := context.Background()
+	log := logrus.NewEntry(logrus.New())
+
+	t.Run("miss is fetched, then hit", func(t *testing.T) {
+		handler, recorder := new(countingHandler), new(fakeRecorder)
+		c := NewWithOptions(ctx, log, time.Hour, handler, Options{Name: "test", Recorder: recorder})
+
+		for i := 0; i < 3; i++ {
+			item, err := c.Get(ctx, "index", "index", nil)
+			require.NoError(t, err)
+			assert.Equal(t, 1, item)
+		}
+
+		assert.Equal(t, 1, handler.count())
+		assert.Equal(t, 1, recorder.get("miss"))
+		assert.Equal(t, 2, recorder.get("hit"))
+	})
+
+	t.Run("expired item is fetched without stale timeout", func(t *testing.T) {
+		handler, recorder := new(countingHandler), new(fakeRecorder)
+		c := NewWithOptions(ctx, log, 10*time.Millisecond, handler, Options{Name: "test", Recorder: recorder})
+
+		_, err := c.Get(ctx, "index", "index", nil)
+		require.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+
+		item, err := c.Get(ctx, "index", "index", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 2, item)
+		assert.Equal(t, 2, recorder.get("miss"))
+		assert.Equal(t, 0, recorder.get("stale"))
+	})
+
+	t.Run("stale item is served while a single refresh runs", func(t *testing.T) {
+		handler, recorder := new(countingHandler), new(fakeRecorder)
+		c := NewWithOptions(ctx, log, 10*time.Millisecond, handler, Options{
+			Name: "test", Recorder: recorder, StaleTimeout: time.Hour,
+		})
+
+		_, err := c.Get(ctx, "index", "index", nil)
+		require.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+
+		handler.release = make(chan struct{})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				item, err := c.Get(ctx, "index", "index", nil)
+				assert.NoError(t, err)
+				assert.Equal(t, 1, item)
+			}()
+		}
+		wg.Wait()
+		close(handler.release)
+
+		require.Eventually(t, func() bool { return handler.count() == 2 }, time.Second, time.Millisecond)
+		require.Eventually(t, func() bool {
+			item, _ := c.store.Get("index")
+			return item.(*entry).item == 2
+		}, time.Second, time.Millisecond)
+
+		assert.Equal(t, 10, recorder.get("stale"))
+		assert.Equal(t, 1, recorder.get("miss"))
+	})
+
+	t.Run("item is refreshed ahead of expiry", func(t *testing.T) {
+		handler, recorder := new(countingHandler), new(fakeRecorder)
+		c := NewWithOptions(ctx, log, time.Hour, handler, Options{
+			Name: "test", Recorder: recorder, RefreshAhead: 2 * time.Hour,
+		})
+
+		_, err := c.Get(ctx, "index", "index", nil)
+		require.NoError(t, err)
+
+		item, err := c.Get(ctx, "index", "index", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, item)
+
+		require.Eventually(t, func() bool { return handler.count() == 2 }, time.Second, time.Millisecond)
+		assert.Equal(t, 1, recorder.get("hit"))
+	})
+
+	t.Run("failed refresh keeps the stale item", func(t *testing.T) {
+		handler, recorder := new(countingHandler), new(fakeRecorder)
+		c := NewWithOptions(ctx, log, 10*time.Millisecond, handler, Options{
+			Name: "test", Recorder: recorder, StaleTimeout: time.Hour,
+		})
+
+		_, err := c.Get(ctx, "index", "index", nil)
+		require.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+
+		handler.mu.Lock()
+		handler.err = errors.New("registry unavailable")
+		handler.mu.Unlock()
+
+		item, err := c.Get(ctx, "index", "index", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, item)
+		require.Eventually(t, func() bool { return recorder.get("refresh_failure") == 1 }, time.Second, time.Millisecond)
+
+		// The next get retries the refresh
+		item, err = c.Get(ctx, "index", "index", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, item)
+		require.Eventually(t, func() bool { return recorder.get("refresh_failure") == 2 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("concurrent misses are coalesced into a single fetch", func(t *testing.T) {
+		handler, recorder := &countingHandler{release: make(chan struct{})}, new(fakeRecorder)
+		c := NewWithOptions(ctx, log, time.Hour, handler, Options{Name: "test", Recorder: recorder})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				item, err := c.Get(ctx, "index", "index", nil)
+				assert.NoError(t, err)
+				assert.Equal(t, 1, item)
+			}()
+		}
+
+		// Give the last caller time to wait on the fetch
+		require.Eventually(t, func() bool { return recorder.get("miss") == 10 }, time.Second, time.Millisecond)
+		time.Sleep(10 * time.Millisecond)
+		close(handler.release)
+		wg.Wait()
+
+		assert.Equal(t, 1, handler.count())
+		assert.Equal(t, 9, recorder.get("coalesced"))
+	})
+
+	t.Run("cancelled caller doesn't cancel a coalesced fetch", func(t *testing.T) {
+		handler := &countingHandler{release: make(chan struct{})}
+		c := NewWithOptions(ctx, log, time.Hour, handler, Options{})
+
+		cancelCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := c.Get(cancelCtx, "index", "index", nil)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		close(handler.release)
+		require.Eventually(t, func() bool { return handler.count() == 1 }, time.Second, time.Millisecond)
+
+		item, err := c.Get(ctx, "index", "index", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, item)
+	})
+}
@@ -0,0 +1,321 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ Store = (*KubeStore)(nil)
+
+// kubeFlushInterval is how long writes are batched for before being written
+// to the object.
+const kubeFlushInterval = 10 * time.Second
+
+// ErrObjectTooLarge is returned when the entries no longer fit in the
+// ConfigMap or Secret.
+var ErrObjectTooLarge = errors.New("image cache exceeds the kubernetes object size limit")
+
+// KubeStore persists cache entries to a single ConfigMap or Secret. Each entry
+// is stored gzipped under a key derived from its cache index, so the total
+// size of all entries must fit in the 1MiB object size limit. Writes are
+// batched, so that the object isn't updated on every cache write.
+type KubeStore struct {
+	log    *logrus.Entry
+	client k8sclient.Client
+	key    types.NamespacedName
+	secret bool
+
+	interval time.Duration
+
+	mu sync.Mutex
+	// pending holds the entries waiting to be written, where a nil entry is
+	// a deletion.
+	pending map[string]*Entry
+	timer   *time.Timer
+}
+
+// kubeEntry is an Entry stored with its cache index, since the index may not
+// be a valid ConfigMap or Secret key.
+type kubeEntry struct {
+	Index string `json:"index"`
+	Entry
+}
+
+// NewConfigMapStore returns a Store which persists entries to the given
+// ConfigMap, creating it if it doesn't exist.
+func NewConfigMapStore(log *logrus.Entry, client k8sclient.Client, namespace, name string) *KubeStore {
+	return newKubeStore(log, client, namespace, name, false)
+}
+
+// NewSecretStore returns a Store which persists entries to the given Secret,
+// creating it if it doesn't exist.
+func NewSecretStore(log *logrus.Entry, client k8sclient.Client, namespace, name string) *KubeStore {
+	return newKubeStore(log, client, namespace, name, true)
+}
+
+func newKubeStore(log *logrus.Entry, client k8sclient.Client, namespace, name string, secret bool) *KubeStore {
+	return &KubeStore{
+		log:      log.WithField("module", "image_cache_store"),
+		client:   client,
+		key:      types.NamespacedName{Namespace: namespace, Name: name},
+		secret:   secret,
+		interval: kubeFlushInterval,
+		pending:  make(map[string]*Entry),
+	}
+}
+
+// Load returns all unexpired entries, including any not yet written.
+func (k *KubeStore) Load(ctx context.Context) (map[string]Entry, error) {
+	obj, data := k.newObject()
+	if err := k.client.Get(ctx, k.key, obj); err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	entries := make(map[string]Entry, len(*data))
+	now := time.Now()
+	for _, value := range *data {
+		entry, err := decodeKubeEntry(value)
+		if err != nil || entry.expired(now) {
+			continue
+		}
+		entries[entry.Index] = entry.Entry
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for index, entry := range k.pending {
+		if entry == nil || entry.expired(now) {
+			delete(entries, index)
+			continue
+		}
+		entries[index] = *entry
+	}
+
+	return entries, nil
+}
+
+// Save queues the entry to be written to the object.
+func (k *KubeStore) Save(_ context.Context, index string, entry Entry) error {
+	k.queue(index, &entry)
+	return nil
+}
+
+// Delete queues the entry to be removed from the object.
+func (k *KubeStore) Delete(_ context.Context, index string) error {
+	k.queue(index, nil)
+	return nil
+}
+
+// Close writes any queued entries to the object.
+func (k *KubeStore) Close() error {
+	return k.Flush(context.Background())
+}
+
+// queue adds the entry to the pending writes, and schedules a flush if one
+// isn't already.
+func (k *KubeStore) queue(index string, entry *Entry) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.pending[index] = entry
+	k.schedule()
+}
+
+// requeue returns entries which failed to be written to the pending writes,
+// unless newer writes of the same index were queued since, and schedules
+// another flush.
+func (k *KubeStore) requeue(entries map[string]*Entry) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for index, entry := range entries {
+		if _, ok := k.pending[index]; !ok {
+			k.pending[index] = entry
+		}
+	}
+	k.schedule()
+}
+
+// schedule schedules a flush if one isn't already. It must be called with
+// the lock held.
+func (k *KubeStore) schedule() {
+	if k.timer == nil {
+		k.timer = time.AfterFunc(k.interval, func() {
+			if err := k.Flush(context.Background()); err != nil {
+				k.log.WithError(err).Error("failed to persist image cache")
+			}
+		})
+	}
+}
+
+// Flush writes all queued entries to the object in a single update. If the
+// update fails, the entries are queued again.
+func (k *KubeStore) Flush(ctx context.Context) error {
+	k.mu.Lock()
+	pending := k.pending
+	k.pending = make(map[string]*Entry)
+	if k.timer != nil {
+		k.timer.Stop()
+		k.timer = nil
+	}
+	k.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if err := k.write(ctx, pending); err != nil {
+		k.requeue(pending)
+		return err
+	}
+
+	return nil
+}
+
+// write writes the entries to the object in a single update.
+func (k *KubeStore) write(ctx context.Context, pending map[string]*Entry) error {
+	values := make(map[string][]byte, len(pending))
+	for index, entry := range pending {
+		if entry == nil {
+			values[kubeKey(index)] = nil
+			continue
+		}
+
+		value, err := encodeKubeEntry(kubeEntry{Index: index, Entry: *entry})
+		if err != nil {
+			return err
+		}
+		values[kubeKey(index)] = value
+	}
+
+	return k.update(ctx, func(data map[string][]byte) bool {
+		// Drop expired entries while writing, since they are only otherwise
+		// removed when evicted from memory.
+		var changed bool
+		now := time.Now()
+		for key, value := range data {
+			if entry, err := decodeKubeEntry(value); err != nil || entry.expired(now) {
+				delete(data, key)
+				changed = true
+			}
+		}
+
+		for key, value := range values {
+			if value != nil {
+				data[key] = value
+				changed = true
+			} else if _, ok := data[key]; ok {
+				delete(data, key)
+				changed = true
+			}
+		}
+
+		return changed
+	})
+}
+
+// update applies the mutation to the object data, creating the object if it
+// doesn't exist. The mutation returns false if nothing changed. Returns
+// ErrObjectTooLarge if the mutated data doesn't fit in the object.
+func (k *KubeStore) update(ctx context.Context, mutate func(data map[string][]byte) bool) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, data := k.newObject()
+		err := k.client.Get(ctx, k.key, obj)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		exists := err == nil
+
+		if *data == nil {
+			*data = make(map[string][]byte)
+		}
+		if !mutate(*data) {
+			return nil
+		}
+
+		var size int
+		for key, value := range *data {
+			size += len(key) + len(value)
+		}
+		if size > corev1.MaxSecretSize {
+			return fmt.Errorf("%w: %s would be %d bytes, over the limit of %d bytes, use --image-cache-store=file instead",
+				ErrObjectTooLarge, k.key, size, corev1.MaxSecretSize)
+		}
+
+		if !exists {
+			obj.SetNamespace(k.key.Namespace)
+			obj.SetName(k.key.Name)
+			return k.client.Create(ctx, obj)
+		}
+
+		return k.client.Update(ctx, obj)
+	})
+}
+
+// newObject returns an empty ConfigMap or Secret, and a pointer to its data.
+func (k *KubeStore) newObject() (k8sclient.Object, *map[string][]byte) {
+	if k.secret {
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: kubeStoreLabels()}}
+		return secret, &secret.Data
+	}
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Labels: kubeStoreLabels()}}
+	return cm, &cm.BinaryData
+}
+
+func kubeStoreLabels() map[string]string {
+	return map[string]string{"app.kubernetes.io/component": "image-cache"}
+}
+
+// kubeKey returns a valid ConfigMap and Secret key for the cache index.
+func kubeKey(index string) string {
+	sum := sha256.Sum256([]byte(index))
+	return hex.EncodeToString(sum[:])
+}
+
+func encodeKubeEntry(entry kubeEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(gz).Encode(entry); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeKubeEntry(value []byte) (kubeEntry, error) {
+	var entry kubeEntry
+
+	gz, err := gzip.NewReader(bytes.NewReader(value))
+	if err != nil {
+		return entry, err
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		return entry, err
+	}
+
+	err = json.Unmarshal(data, &entry)
+	return entry, err
+}
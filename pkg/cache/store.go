@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+// Store persists image tag cache entries, so that the cache survives
+// restarts.
+type Store interface {
+	// Load returns all persisted entries which have not yet expired, keyed
+	// by cache index.
+	Load(ctx context.Context) (map[string]Entry, error)
+
+	// Save persists the entry at the given index, replacing any existing
+	// entry.
+	Save(ctx context.Context, index string, entry Entry) error
+
+	// Delete removes the entry at the given index, if it exists.
+	Delete(ctx context.Context, index string) error
+}
+
+// Entry is a persisted cache entry.
+type Entry struct {
+	Tags    []api.ImageTag `json:"tags"`
+	Expires time.Time      `json:"expires"`
+
+	// FetchIndex is the index the tags are fetched with, if not the same as
+	// the cache index.
+	FetchIndex string `json:"fetchIndex,omitempty"`
+}
+
+// expired returns whether the entry has expired at the given time.
+func (e Entry) expired(now time.Time) bool {
+	return !now.Before(e.Expires)
+}
@@ -0,0 +1,279 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+func TestStores(t *testing.T) {
+	newBoltStore := func(t *testing.T) Store {
+		store, err := NewBoltStore(filepath.Join(t.TempDir(), "cache", "image-cache.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = store.Close() })
+		return store
+	}
+	log := logrus.NewEntry(logrus.New())
+	newFakeClient := func() *fake.ClientBuilder {
+		return fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme)
+	}
+
+	stores := map[string]func(t *testing.T) Store{
+		"bolt": newBoltStore,
+		"configmap": func(*testing.T) Store {
+			return NewConfigMapStore(log, newFakeClient().Build(), "version-checker", "image-cache")
+		},
+		"secret": func(*testing.T) Store {
+			return NewSecretStore(log, newFakeClient().Build(), "version-checker", "image-cache")
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t)
+
+			entries, err := store.Load(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, entries)
+
+			fresh := Entry{
+				Tags:    []api.ImageTag{{Tag: "v1.0.0", SHA: "sha:123", Timestamp: time.Unix(100, 0).UTC()}},
+				Expires: time.Now().Add(time.Hour).Truncate(time.Second).UTC(),
+			}
+			expired := Entry{
+				Tags:    []api.ImageTag{{Tag: "v0.1.0"}},
+				Expires: time.Now().Add(-time.Hour),
+			}
+
+			require.NoError(t, store.Save(ctx, "quay.io/jetstack/app", fresh))
+			require.NoError(t, store.Save(ctx, "docker.io/library/nginx", fresh))
+			require.NoError(t, store.Save(ctx, "ghcr.io/old/app", expired))
+
+			entries, err = store.Load(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]Entry{
+				"quay.io/jetstack/app":    fresh,
+				"docker.io/library/nginx": fresh,
+			}, entries)
+
+			require.NoError(t, store.Delete(ctx, "docker.io/library/nginx"))
+			require.NoError(t, store.Delete(ctx, "missing"))
+
+			entries, err = store.Load(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]Entry{"quay.io/jetstack/app": fresh}, entries)
+		})
+	}
+}
+
+func TestKubeStoreObject(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+
+	store := NewConfigMapStore(logrus.NewEntry(logrus.New()), client, "version-checker", "image-cache")
+	require.NoError(t, store.Save(ctx, "quay.io/jetstack/app", Entry{Expires: time.Now().Add(time.Hour)}))
+	require.NoError(t, store.Flush(ctx))
+
+	var cm corev1.ConfigMap
+	require.NoError(t, client.Get(ctx, types.NamespacedName{Namespace: "version-checker", Name: "image-cache"}, &cm))
+	assert.Equal(t, "image-cache", cm.Labels["app.kubernetes.io/component"])
+	assert.Contains(t, cm.BinaryData, kubeKey("quay.io/jetstack/app"))
+	assert.Empty(t, cm.Data)
+}
+
+func TestKubeStoreBatchesWrites(t *testing.T) {
+	ctx := context.Background()
+
+	var writes int
+	client := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, client k8sclient.WithWatch, obj k8sclient.Object, opts ...k8sclient.CreateOption) error {
+				writes++
+				return client.Create(ctx, obj, opts...)
+			},
+			Update: func(ctx context.Context, client k8sclient.WithWatch, obj k8sclient.Object, opts ...k8sclient.UpdateOption) error {
+				writes++
+				return client.Update(ctx, obj, opts...)
+			},
+		}).Build()
+
+	store := NewSecretStore(logrus.NewEntry(logrus.New()), client, "version-checker", "image-cache")
+	entry := Entry{Expires: time.Now().Add(time.Hour)}
+	require.NoError(t, store.Save(ctx, "quay.io/jetstack/app", entry))
+	require.NoError(t, store.Save(ctx, "docker.io/library/nginx", entry))
+	require.NoError(t, store.Delete(ctx, "docker.io/library/nginx"))
+	assert.Equal(t, 0, writes, "writes should be queued")
+
+	require.NoError(t, store.Close())
+	assert.Equal(t, 1, writes, "queued writes should be a single write")
+
+	// Deleting a missing entry doesn't write the object.
+	require.NoError(t, store.Delete(ctx, "missing"))
+	require.NoError(t, store.Flush(ctx))
+	assert.Equal(t, 1, writes)
+
+	entries, err := store.Load(ctx)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Contains(t, entries, "quay.io/jetstack/app")
+}
+
+func TestKubeStoreObjectSizeLimit(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+	store := NewConfigMapStore(logrus.NewEntry(logrus.New()), client, "version-checker", "image-cache")
+
+	// Random digests don't compress, so this entry is larger than 1MiB
+	// when gzipped.
+	tags := make([]api.ImageTag, 40000)
+	for i := range tags {
+		sha := make([]byte, 32)
+		_, err := rand.Read(sha)
+		require.NoError(t, err)
+		tags[i] = api.ImageTag{Tag: fmt.Sprintf("v%d", i), SHA: "sha256:" + hex.EncodeToString(sha)}
+	}
+
+	require.NoError(t, store.Save(ctx, "quay.io/jetstack/small", Entry{Expires: time.Now().Add(time.Hour)}))
+	require.NoError(t, store.Flush(ctx))
+
+	require.NoError(t, store.Save(ctx, "quay.io/jetstack/large", Entry{Tags: tags, Expires: time.Now().Add(time.Hour)}))
+	err := store.Flush(ctx)
+	require.ErrorIs(t, err, ErrObjectTooLarge)
+	assert.Contains(t, err.Error(), "version-checker/image-cache")
+
+	// The object is left as it was, and the entry is queued again.
+	var cm corev1.ConfigMap
+	require.NoError(t, client.Get(ctx, types.NamespacedName{Namespace: "version-checker", Name: "image-cache"}, &cm))
+	assert.Len(t, cm.BinaryData, 1)
+
+	entries, err := store.Load(ctx)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Contains(t, entries, "quay.io/jetstack/small")
+	assert.Contains(t, entries, "quay.io/jetstack/large")
+
+	// Replacing the entry with a smaller one is written.
+	require.NoError(t, store.Save(ctx, "quay.io/jetstack/large", Entry{Tags: tags[:1], Expires: time.Now().Add(time.Hour)}))
+	require.NoError(t, store.Flush(ctx))
+	require.NoError(t, client.Get(ctx, types.NamespacedName{Namespace: "version-checker", Name: "image-cache"}, &cm))
+	assert.Len(t, cm.BinaryData, 2)
+}
+
+func TestKubeStoreFailedWrite(t *testing.T) {
+	ctx := context.Background()
+
+	fail := true
+	client := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, client k8sclient.WithWatch, obj k8sclient.Object, opts ...k8sclient.CreateOption) error {
+				if fail {
+					fail = false
+					return errors.New("unavailable")
+				}
+				return client.Create(ctx, obj, opts...)
+			},
+		}).Build()
+
+	store := NewSecretStore(logrus.NewEntry(logrus.New()), client, "version-checker", "image-cache")
+	store.interval = time.Hour
+
+	first := Entry{Tags: []api.ImageTag{{Tag: "v1"}}, Expires: time.Now().Add(time.Hour)}
+	require.NoError(t, store.Save(ctx, "quay.io/jetstack/app", first))
+	require.NoError(t, store.Save(ctx, "docker.io/library/nginx", first))
+	require.EqualError(t, store.Flush(ctx), "unavailable")
+
+	// The failed entries are queued again, with the flush re-armed
+	store.mu.Lock()
+	assert.Len(t, store.pending, 2)
+	assert.NotNil(t, store.timer)
+	store.mu.Unlock()
+
+	// Entries queued since the failed write win over the failed ones
+	second := Entry{Tags: []api.ImageTag{{Tag: "v2"}}, Expires: time.Now().Add(time.Hour)}
+	require.NoError(t, store.Save(ctx, "quay.io/jetstack/app", second))
+	require.NoError(t, store.Flush(ctx))
+
+	var secret corev1.Secret
+	require.NoError(t, client.Get(ctx, types.NamespacedName{Namespace: "version-checker", Name: "image-cache"}, &secret))
+	assert.Len(t, secret.Data, 2)
+
+	entries, err := store.Load(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "v2", entries["quay.io/jetstack/app"].Tags[0].Tag)
+	assert.Equal(t, "v1", entries["docker.io/library/nginx"].Tags[0].Tag)
+}
+
+type fakeHandler struct {
+	calls int
+}
+
+func (f *fakeHandler) Fetch(_ context.Context, index string, _ *api.Options) (interface{}, error) {
+	f.calls++
+	return []api.ImageTag{{Tag: "fetched-" + index}}, nil
+}
+
+func TestNewWithOptionsStore(t *testing.T) {
+	ctx := context.Background()
+	log := logrus.NewEntry(logrus.New())
+
+	store, err := NewBoltStore(filepath.Join(t.TempDir(), "image-cache.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	// Populate the store through a first cache.
+	handler := new(fakeHandler)
+	c := NewWithOptions(ctx, log, time.Hour, handler, Options{Store: store})
+	_, err = c.Get(ctx, "quay.io/jetstack/app", "quay.io/jetstack/app", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, handler.calls)
+
+	// A restarted cache is warmed from the store.
+	handler = new(fakeHandler)
+	c = NewWithOptions(ctx, log, time.Hour, handler, Options{Store: store})
+	item, err := c.Get(ctx, "quay.io/jetstack/app", "quay.io/jetstack/app", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, handler.calls)
+	assert.Equal(t, []api.ImageTag{{Tag: "fetched-quay.io/jetstack/app"}}, item)
+
+	// Items fetched with another index are refreshed with it once restored.
+	_, err = c.Get(ctx, "quay.io/jetstack/app#default/pull-secret", "quay.io/jetstack/app", nil)
+	require.NoError(t, err)
+	c = NewWithOptions(ctx, log, time.Hour, handler, Options{Store: store})
+	obj, ok := c.store.Get("quay.io/jetstack/app#default/pull-secret")
+	require.True(t, ok)
+	assert.Equal(t, "quay.io/jetstack/app", obj.(*entry).fetchIndex)
+
+	// Deleting removes the persisted entry.
+	c.Delete("quay.io/jetstack/app")
+	c.Delete("quay.io/jetstack/app#default/pull-secret")
+	entries, err := store.Load(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// Items which aren't image tags are not persisted.
+	c.Update("search", &api.ImageTag{Tag: "v1"})
+	entries, err = store.Load(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
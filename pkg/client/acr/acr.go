@@ -10,6 +10,7 @@ import (
 	"sync"
 
 	"github.com/Azure/go-autorest/autorest"
+	"github.com/google/go-containerregistry/pkg/authn"
 
 	"github.com/jetstack/version-checker/pkg/api"
 	"github.com/jetstack/version-checker/pkg/client/util"
@@ -31,10 +32,24 @@ type Client struct {
 }
 
 type Options struct {
+	Transporter  http.RoundTripper
 	Username     string
 	Password     string
 	RefreshToken string
 	JWKSURI      string
+
+	// Keychain, if set, provides the credentials of each registry when no
+	// username, password or refresh token is given.
+	Keychain authn.Keychain
+
+	// WorkloadIdentity exchanges the federated token of Azure Workload
+	// Identity for refresh tokens when no other credentials are found. Its
+	// options default to the environment variables injected by its webhook.
+	WorkloadIdentity   bool
+	ClientID           string
+	TenantID           string
+	FederatedTokenFile string
+	AuthorityHost      string
 }
 
 func New(opts Options) (*Client, error) {
@@ -42,6 +57,11 @@ func New(opts Options) (*Client, error) {
 		(len(opts.Username) > 0 || len(opts.Password) > 0) {
 		return nil, errors.New("cannot specify refresh token as well as username/password")
 	}
+	if opts.WorkloadIdentity {
+		if err := opts.completeWorkloadIdentity(); err != nil {
+			return nil, err
+		}
+	}
 
 	return &Client{
 		Options:         opts,
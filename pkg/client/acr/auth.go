@@ -1,8 +1,8 @@
 package acr
 
 // The intention here is to provide a client for Azure Container Registry (ACR)
-// that can authenticate using either basic authentication (username/password)
-// or a refresh token. The client will cache the access token and its expiration
+// that can authenticate using either basic authentication (username/password),
+// a refresh token or Azure Workload Identity. The client will cache the access token and its expiration
 // time to avoid unnecessary requests to the ACR server.
 
 import (
@@ -16,26 +16,32 @@ import (
 	"github.com/Azure/go-autorest/autorest/adal"
 	"github.com/MicahParks/keyfunc/v3"
 	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/jetstack/version-checker/pkg/client/util"
 )
 
 func (c *Client) getACRClient(ctx context.Context, host string) (*acrClient, error) {
 	c.cacheMu.Lock()
 	defer c.cacheMu.Unlock()
 
-	if client, ok := c.cachedACRClient[host]; ok && time.Now().After(client.tokenExpiry) {
+	if client, ok := c.cachedACRClient[host]; ok && time.Now().Before(client.tokenExpiry) {
 		return client, nil
 	}
 
+	username, password, refreshToken, err := c.credentials(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
 	var (
 		client            *acrClient
 		accessTokenClient *autorest.Client
 		accessTokenReq    *http.Request
-		err               error
 	)
-	if len(c.RefreshToken) > 0 {
-		accessTokenClient, accessTokenReq, err = c.getAccessTokenRequesterForRefreshToken(ctx, host)
+	if len(refreshToken) > 0 {
+		accessTokenClient, accessTokenReq, err = c.getAccessTokenRequesterForRefreshToken(ctx, host, refreshToken)
 	} else {
-		accessTokenClient, accessTokenReq, err = c.getAccessTokenRequesterForBasicAuth(ctx, host)
+		accessTokenClient, accessTokenReq, err = c.getAccessTokenRequesterForBasicAuth(ctx, host, username, password)
 	}
 	if err != nil {
 		return nil, err
@@ -49,9 +55,44 @@ func (c *Client) getACRClient(ctx context.Context, host string) (*acrClient, err
 	return client, nil
 }
 
-func (c *Client) getAccessTokenRequesterForBasicAuth(ctx context.Context, host string) (*autorest.Client, *http.Request, error) {
+// credentials returns the username and password, or refresh token, to
+// authenticate to the host with. When none are configured, they are taken
+// from the Keychain, where `az acr login` stores the refresh token as the
+// identity token, or else exchanged for with Workload Identity.
+func (c *Client) credentials(ctx context.Context, host string) (string, string, string, error) {
+	if len(c.Username) > 0 || len(c.Password) > 0 || len(c.RefreshToken) > 0 {
+		return c.Username, c.Password, c.RefreshToken, nil
+	}
+
+	auth, err := util.Authorization(c.Keychain, host)
+	if err != nil {
+		return "", "", "", fmt.Errorf("%s: failed to get credentials from keychain: %s", host, err)
+	}
+	if auth == nil {
+		if !c.WorkloadIdentity {
+			return "", "", "", nil
+		}
+		refreshToken, err := c.workloadIdentityRefreshToken(ctx, host)
+		return "", "", refreshToken, err
+	}
+	if len(auth.IdentityToken) > 0 {
+		return "", "", auth.IdentityToken, nil
+	}
+	return auth.Username, auth.Password, "", nil
+}
+
+// newAutorestClient returns a client using the Transporter, if set.
+func (c *Client) newAutorestClient() autorest.Client {
 	client := autorest.NewClientWithUserAgent(userAgent)
-	client.Authorizer = autorest.NewBasicAuthorizer(c.Username, c.Password)
+	if c.Transporter != nil {
+		client.Sender = &http.Client{Transport: c.Transporter}
+	}
+	return client
+}
+
+func (c *Client) getAccessTokenRequesterForBasicAuth(ctx context.Context, host, username, password string) (*autorest.Client, *http.Request, error) {
+	client := c.newAutorestClient()
+	client.Authorizer = autorest.NewBasicAuthorizer(username, password)
 	urlParameters := map[string]interface{}{
 		"url": "https://" + host,
 	}
@@ -72,15 +113,15 @@ func (c *Client) getAccessTokenRequesterForBasicAuth(ctx context.Context, host s
 	return &client, req, nil
 }
 
-func (c *Client) getAccessTokenRequesterForRefreshToken(ctx context.Context, host string) (*autorest.Client, *http.Request, error) {
-	client := autorest.NewClientWithUserAgent(userAgent)
+func (c *Client) getAccessTokenRequesterForRefreshToken(ctx context.Context, host, refreshToken string) (*autorest.Client, *http.Request, error) {
+	client := c.newAutorestClient()
 	urlParameters := map[string]interface{}{
 		"url": "https://" + host,
 	}
 
 	formDataParameters := map[string]interface{}{
 		"grant_type":    "refresh_token",
-		"refresh_token": c.RefreshToken,
+		"refresh_token": refreshToken,
 		"scope":         requiredScope,
 		"service":       host,
 	}
@@ -0,0 +1,140 @@
+package acr
+
+// Azure Workload Identity projects a federated ServiceAccount token into the
+// Pod, which is exchanged for an Azure AD access token of the identity's
+// client, and then for an ACR refresh token. The refresh token is used in
+// the same way as one that is configured, and is exchanged again whenever
+// the cached client's access token expires, re-reading the rotated token.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest"
+)
+
+// Environment variables which the Azure Workload Identity webhook injects
+// into Pods using it.
+const (
+	envAzureClientID           = "AZURE_CLIENT_ID"
+	envAzureTenantID           = "AZURE_TENANT_ID"
+	envAzureFederatedTokenFile = "AZURE_FEDERATED_TOKEN_FILE" // #nosec G101
+	envAzureAuthorityHost      = "AZURE_AUTHORITY_HOST"
+)
+
+const (
+	defaultAuthorityHost   = "https://login.microsoftonline.com/"
+	containerRegistryScope = "https://containerregistry.azure.net/.default"
+	jwtBearerAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+)
+
+type aadTokenResponse struct {
+	AccessToken string `json:"access_token"`
+}
+
+type exchangeResponse struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// completeWorkloadIdentity defaults the unset Workload Identity options to
+// those injected by its webhook.
+func (o *Options) completeWorkloadIdentity() error {
+	for _, opt := range []struct {
+		env    string
+		assign *string
+	}{
+		{envAzureClientID, &o.ClientID},
+		{envAzureTenantID, &o.TenantID},
+		{envAzureFederatedTokenFile, &o.FederatedTokenFile},
+		{envAzureAuthorityHost, &o.AuthorityHost},
+	} {
+		if len(*opt.assign) == 0 {
+			*opt.assign = os.Getenv(opt.env)
+		}
+	}
+
+	if len(o.AuthorityHost) == 0 {
+		o.AuthorityHost = defaultAuthorityHost
+	}
+
+	switch {
+	case len(o.ClientID) == 0:
+		return fmt.Errorf("workload identity requires a client ID (%s)", envAzureClientID)
+	case len(o.TenantID) == 0:
+		return fmt.Errorf("workload identity requires a tenant ID (%s)", envAzureTenantID)
+	case len(o.FederatedTokenFile) == 0:
+		return fmt.Errorf("workload identity requires a federated token file (%s)", envAzureFederatedTokenFile)
+	}
+
+	return nil
+}
+
+// workloadIdentityRefreshToken exchanges the federated token for an ACR
+// refresh token of the host.
+func (c *Client) workloadIdentityRefreshToken(ctx context.Context, host string) (string, error) {
+	assertion, err := os.ReadFile(c.FederatedTokenFile)
+	if err != nil {
+		return "", fmt.Errorf("%s: failed to read federated token: %s", host, err)
+	}
+
+	var aadToken aadTokenResponse
+	if err := c.postForm(ctx, strings.TrimSuffix(c.AuthorityHost, "/")+"/"+c.TenantID, "/oauth2/v2.0/token",
+		map[string]interface{}{
+			"client_assertion_type": jwtBearerAssertionType,
+			"client_assertion":      strings.TrimSpace(string(assertion)),
+			"client_id":             c.ClientID,
+			"grant_type":            "client_credentials",
+			"scope":                 containerRegistryScope,
+		}, &aadToken); err != nil {
+		return "", fmt.Errorf("%s: failed to request azure ad access token: %s", host, err)
+	}
+
+	var exchange exchangeResponse
+	if err := c.postForm(ctx, "https://"+host, "/oauth2/exchange",
+		map[string]interface{}{
+			"grant_type":   "access_token",
+			"service":      host,
+			"tenant":       c.TenantID,
+			"access_token": aadToken.AccessToken,
+		}, &exchange); err != nil {
+		return "", fmt.Errorf("%s: failed to exchange azure ad access token: %s", host, err)
+	}
+	if len(exchange.RefreshToken) == 0 {
+		return "", fmt.Errorf("%s: no refresh token in exchange response", host)
+	}
+
+	return exchange.RefreshToken, nil
+}
+
+// postForm posts the form data to the path of the base URL, decoding the
+// JSON response into v.
+func (c *Client) postForm(ctx context.Context, baseURL, path string, form map[string]interface{}, v interface{}) error {
+	client := c.newAutorestClient()
+
+	preparer := autorest.CreatePreparer(
+		autorest.AsPost(),
+		autorest.WithBaseURL(baseURL),
+		autorest.WithPath(path),
+		autorest.WithFormData(autorest.MapToValues(form)))
+	req, err := preparer.Prepare((&http.Request{}).WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	resp, err := autorest.SendWithSender(client, req,
+		autorest.DoRetryForStatusCodes(client.RetryAttempts, client.RetryDuration, autorest.StatusCodesForRetry...))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
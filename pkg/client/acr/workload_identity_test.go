@@ -0,0 +1,150 @@
+package acr
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAzure is a fake of Azure AD and an ACR registry.
+type fakeAzure struct {
+	*httptest.Server
+
+	// expiresIn is the lifetime of the ACR access tokens issued
+	expiresIn time.Duration
+
+	aadTokens atomic.Int32
+	exchanges atomic.Int32
+}
+
+func newFakeAzure(t *testing.T, assertion string) *fakeAzure {
+	f := &fakeAzure{expiresIn: time.Hour}
+	f.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+
+		switch r.URL.Path {
+		case "/tenant-id/oauth2/v2.0/token":
+			f.aadTokens.Add(1)
+			assert.Equal(t, jwtBearerAssertionType, r.PostForm.Get("client_assertion_type"))
+			assert.Equal(t, assertion, r.PostForm.Get("client_assertion"))
+			assert.Equal(t, "client-id", r.PostForm.Get("client_id"))
+			assert.Equal(t, containerRegistryScope, r.PostForm.Get("scope"))
+			_, _ = w.Write([]byte(`{"access_token":"aad-token","expires_in":3600}`))
+
+		case "/oauth2/exchange":
+			f.exchanges.Add(1)
+			assert.Equal(t, "access_token", r.PostForm.Get("grant_type"))
+			assert.Equal(t, "aad-token", r.PostForm.Get("access_token"))
+			assert.Equal(t, "tenant-id", r.PostForm.Get("tenant"))
+			_, _ = w.Write([]byte(`{"refresh_token":"acr-refresh-token"}`))
+
+		case "/oauth2/token":
+			assert.Equal(t, "refresh_token", r.PostForm.Get("grant_type"))
+			assert.Equal(t, "acr-refresh-token", r.PostForm.Get("refresh_token"))
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+				"exp": time.Now().Add(f.expiresIn).Unix(),
+			}).SignedString([]byte("secret"))
+			require.NoError(t, err)
+			_, _ = w.Write([]byte(`{"access_token":"` + token + `"}`))
+
+		case "/acr/v1/app/_manifests":
+			assert.Contains(t, r.Header.Get("Authorization"), "Bearer ")
+			_, _ = w.Write([]byte(`{"manifests":[{"digest":"sha256:abc","tags":["v1.0.0"]}]}`))
+
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+func TestWorkloadIdentity(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "azure-identity-token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("federated-token\n"), 0o600))
+
+	t.Run("options default to the webhook's environment variables", func(t *testing.T) {
+		t.Setenv(envAzureClientID, "client-id")
+		t.Setenv(envAzureTenantID, "tenant-id")
+		t.Setenv(envAzureFederatedTokenFile, tokenFile)
+		t.Setenv(envAzureAuthorityHost, "")
+
+		client, err := New(Options{WorkloadIdentity: true, ClientID: "explicit-client-id"})
+		require.NoError(t, err)
+		assert.Equal(t, "explicit-client-id", client.ClientID)
+		assert.Equal(t, "tenant-id", client.TenantID)
+		assert.Equal(t, tokenFile, client.FederatedTokenFile)
+		assert.Equal(t, defaultAuthorityHost, client.AuthorityHost)
+	})
+
+	t.Run("missing options are an error", func(t *testing.T) {
+		t.Setenv(envAzureClientID, "")
+		t.Setenv(envAzureTenantID, "tenant-id")
+		t.Setenv(envAzureFederatedTokenFile, tokenFile)
+
+		_, err := New(Options{WorkloadIdentity: true})
+		assert.EqualError(t, err, "workload identity requires a client ID (AZURE_CLIENT_ID)")
+	})
+
+	newClient := func(t *testing.T, f *fakeAzure) *Client {
+		client, err := New(Options{
+			Transporter:        f.Client().Transport,
+			WorkloadIdentity:   true,
+			ClientID:           "client-id",
+			TenantID:           "tenant-id",
+			FederatedTokenFile: tokenFile,
+			AuthorityHost:      f.URL + "/",
+		})
+		require.NoError(t, err)
+		return client
+	}
+
+	t.Run("federated token is exchanged once until the access token expires", func(t *testing.T) {
+		f := newFakeAzure(t, "federated-token")
+		client := newClient(t, f)
+		host := f.Listener.Addr().String()
+
+		for i := 0; i < 3; i++ {
+			tags, err := client.Tags(context.Background(), host, "", "app")
+			require.NoError(t, err)
+			require.Len(t, tags, 1)
+			assert.Equal(t, "v1.0.0", tags[0].Tag)
+		}
+		assert.Equal(t, int32(1), f.aadTokens.Load())
+		assert.Equal(t, int32(1), f.exchanges.Load())
+	})
+
+	t.Run("federated token is exchanged again once the access token expires", func(t *testing.T) {
+		f := newFakeAzure(t, "federated-token")
+		f.expiresIn = -time.Minute
+		client := newClient(t, f)
+		host := f.Listener.Addr().String()
+
+		for i := 0; i < 2; i++ {
+			_, err := client.Tags(context.Background(), host, "", "app")
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(2), f.exchanges.Load())
+	})
+
+	t.Run("configured credentials are preferred", func(t *testing.T) {
+		f := newFakeAzure(t, "federated-token")
+		client := newClient(t, f)
+		client.RefreshToken = "acr-refresh-token"
+
+		_, err := client.Tags(context.Background(), f.Listener.Addr().String(), "", "app")
+		require.NoError(t, err)
+		assert.Zero(t, f.exchanges.Load())
+	})
+}
@@ -4,13 +4,20 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"os"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/sirupsen/logrus"
 
 	"github.com/jetstack/version-checker/pkg/api"
 	"github.com/jetstack/version-checker/pkg/client/acr"
 	"github.com/jetstack/version-checker/pkg/client/docker"
+	"github.com/jetstack/version-checker/pkg/client/dockerconfig"
 	"github.com/jetstack/version-checker/pkg/client/ecr"
 	"github.com/jetstack/version-checker/pkg/client/fallback"
 	"github.com/jetstack/version-checker/pkg/client/gcr"
@@ -18,6 +25,7 @@ import (
 	"github.com/jetstack/version-checker/pkg/client/oci"
 	"github.com/jetstack/version-checker/pkg/client/quay"
 	"github.com/jetstack/version-checker/pkg/client/selfhosted"
+	"github.com/jetstack/version-checker/pkg/client/util"
 )
 
 // Used for testing/mocking purposes
@@ -26,12 +34,34 @@ type ClientHandler interface {
 }
 
 // Client is a container image registry client to list tags of given image
-// URLs.
+// URLs. Its registry clients are rebuilt by Reload, such as when their
+// credential files change.
 type Client struct {
+	log  *logrus.Entry
+	opts Options
+
+	// registries is the current set of registry clients, which is swapped
+	// atomically on Reload.
+	registries atomic.Pointer[registries]
+
+	// watchDebounce is how long Watch waits for changes to files to settle
+	// before reloading.
+	watchDebounce time.Duration
+
+	// hosts are the routes of the hosts looked up, shown by RoutesHandler.
+	hostsMu sync.Mutex
+	hosts   map[hostRouteKey]HostRoute
+}
+
+// registries is a set of registry clients, built from the Options.
+type registries struct {
 	fallbackClient api.ImageClient
+	clients        []api.ImageClient
 
-	log     *logrus.Entry
-	clients []api.ImageClient
+	// byName are the clients which routes may name, and selfhosted are the
+	// configured selfhosted clients.
+	byName     map[string]api.ImageClient
+	selfhosted []api.ImageClient
 }
 
 // Options used to configure client authentication.
@@ -46,16 +76,133 @@ type Options struct {
 	Selfhosted map[string]*selfhosted.Options
 
 	Transport http.RoundTripper
+
+	// RateLimiter, if set, limits the requests of every client to each
+	// registry host.
+	RateLimiter *util.RateLimiter
+
+	// Retrier retries the failed requests of every client. Defaults to a
+	// Retrier with default options.
+	Retrier *util.Retrier
+
+	// Keychain, if set, provides the credentials of clients which have
+	// none configured, such as those of a Docker config file.
+	Keychain authn.Keychain
+
+	// Files are files holding credentials, which are read whenever the
+	// registry clients are built.
+	Files CredentialFiles
+
+	// Routes route the hosts matching them to a registry client, in order,
+	// taking precedence over the hosts which clients recognise.
+	Routes []Route
+
+	// ResponseTimeout is how long the tag list responses of registries are
+	// kept to be revalidated, answering unchanged lists from memory.
+	ResponseTimeout time.Duration
+
+	// DisableFallback fails the lookups of hosts which no route or client
+	// matches, rather than trying each fallback client in turn.
+	DisableFallback bool
+}
+
+// CredentialFiles are paths of files holding the credentials of clients,
+// such as Secrets mounted as files or those rendered by a Vault agent.
+type CredentialFiles struct {
+	// DockerConfig is a Docker config file whose credentials are added to
+	// the Keychain, after those of the Keychain of the Options.
+	DockerConfig string
+
+	DockerToken string
+	GHCRToken   string
+	QuayToken   string
+
+	// Selfhosted are the credential files of the selfhosted registries, by
+	// the same name as their Options.
+	Selfhosted map[string]SelfhostedFiles
+}
+
+// SelfhostedFiles are paths of files holding the credentials of a selfhosted
+// registry, which take precedence over those given directly.
+type SelfhostedFiles struct {
+	Username string
+	Password string
+	Bearer   string
 }
 
 func New(ctx context.Context, log *logrus.Entry, opts Options) (*Client, error) {
 	log = log.WithField("component", "client")
-	// Setup Transporters for all remaining clients (if one is set)
-	if opts.Transport != nil {
-		opts.Quay.Transporter = opts.Transport
-		opts.ECR.Transporter = opts.Transport
-		opts.GHCR.Transporter = opts.Transport
-		opts.GCR.Transporter = opts.Transport
+	if opts.RateLimiter != nil {
+		opts.Transport = opts.RateLimiter.RoundTripper(opts.Transport)
+	}
+	if opts.Retrier == nil {
+		opts.Retrier = util.NewRetrier(log, util.RetryOptions{})
+	}
+	// Each retry waits for the rate limiter
+	opts.Transport = opts.Retrier.RoundTripper(opts.Transport)
+
+	c := &Client{
+		log:           log,
+		opts:          opts,
+		watchDebounce: defaultWatchDebounce,
+	}
+
+	regs, err := c.build(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.registries.Store(regs)
+
+	for _, client := range append(regs.clients, regs.fallbackClient) {
+		log.WithField("client", client.Name()).Debugf("registered client")
+	}
+
+	return c, nil
+}
+
+// Reload rebuilds the registry clients, re-reading their credential and CA
+// files, and swaps them in atomically. The current clients are kept if they
+// fail to build.
+func (c *Client) Reload(ctx context.Context) error {
+	regs, err := c.build(ctx)
+	if err != nil {
+		return err
+	}
+	c.registries.Store(regs)
+	return nil
+}
+
+// build builds the registry clients from a copy of the Options, so that
+// they can be built again.
+func (c *Client) build(ctx context.Context) (*registries, error) {
+	log := c.log
+	opts := c.opts
+
+	// Clients write back the credentials they resolve
+	opts.Selfhosted = make(map[string]*selfhosted.Options, len(c.opts.Selfhosted))
+	for name, sOpts := range c.opts.Selfhosted {
+		sOpts := *sOpts
+		opts.Selfhosted[name] = &sOpts
+	}
+
+	if err := readCredentialFiles(&opts); err != nil {
+		return nil, err
+	}
+
+	// Setup Transporters for all remaining clients
+	opts.Quay.Transporter = opts.Transport
+	opts.ECR.Transporter = opts.Transport
+	opts.GHCR.Transporter = opts.Transport
+	opts.GCR.Transporter = opts.Transport
+	opts.ACR.Transporter = opts.Transport
+	opts.Docker.Transporter = opts.Transport
+	opts.OCI.Transporter = opts.Transport
+	opts.OCI.ResponseTimeout = opts.ResponseTimeout
+
+	if opts.Keychain != nil {
+		if err := setKeychain(&opts); err != nil {
+			return nil, err
+		}
 	}
 
 	acrClient, err := acr.New(opts.ACR)
@@ -69,6 +216,10 @@ func New(ctx context.Context, log *logrus.Entry, opts Options) (*Client, error)
 
 	var selfhostedClients []api.ImageClient
 	for _, sOpts := range opts.Selfhosted {
+		sOpts.RateLimiter = opts.RateLimiter
+		sOpts.Retrier = opts.Retrier
+		sOpts.Keychain = opts.Keychain
+		sOpts.ResponseTimeout = opts.ResponseTimeout
 		sClient, err := selfhosted.New(ctx, log, sOpts)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create selfhosted client %q: %w",
@@ -83,7 +234,11 @@ func New(ctx context.Context, log *logrus.Entry, opts Options) (*Client, error)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create OCI client: %w", err)
 	}
-	anonSelfHosted, err := selfhosted.New(ctx, log, &selfhosted.Options{Transporter: opts.Transport})
+	anonSelfHosted, err := selfhosted.New(ctx, log, &selfhosted.Options{
+		Transporter:     opts.Transport,
+		Keychain:        opts.Keychain,
+		ResponseTimeout: opts.ResponseTimeout,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create anonymous Selfhosted client: %w", err)
 	}
@@ -100,41 +255,149 @@ func New(ctx context.Context, log *logrus.Entry, opts Options) (*Client, error)
 		return nil, fmt.Errorf("failed to create fallback client: %w", err)
 	}
 
-	c := &Client{
+	ecrClient := ecr.New(opts.ECR)
+	gcrClient := gcr.New(opts.GCR)
+	ghcrClient := ghcr.New(opts.GHCR)
+	quayClient := quay.New(opts.Quay, log)
+
+	return &registries{
 		// Append all the clients in order of which we want to check against
 		clients: append(
-			selfhostedClients,
+			slices.Clone(selfhostedClients),
 			acrClient,
-			ecr.New(opts.ECR),
+			ecrClient,
 			dockerClient,
-			gcr.New(opts.GCR),
-			ghcr.New(opts.GHCR),
-			quay.New(opts.Quay, log),
+			gcrClient,
+			ghcrClient,
+			quayClient,
 		),
 		fallbackClient: fallbackClient,
-		log:            log,
+		byName: map[string]api.ImageClient{
+			ClientACR:        acrClient,
+			ClientDocker:     dockerClient,
+			ClientECR:        ecrClient,
+			ClientFallback:   fallbackClient,
+			ClientGCR:        gcrClient,
+			ClientGHCR:       ghcrClient,
+			ClientOCI:        ociclient,
+			ClientQuay:       quayClient,
+			ClientSelfhosted: anonSelfHosted,
+		},
+		selfhosted: selfhostedClients,
+	}, nil
+}
+
+// readCredentialFiles sets the credentials of the Options from its files.
+func readCredentialFiles(opts *Options) error {
+	if len(opts.Files.DockerConfig) > 0 {
+		keychain, err := dockerconfig.Load(opts.Files.DockerConfig)
+		if err != nil {
+			return err
+		}
+		if opts.Keychain != nil {
+			opts.Keychain = authn.NewMultiKeychain(opts.Keychain, keychain)
+		} else {
+			opts.Keychain = keychain
+		}
 	}
 
-	for _, client := range append(c.clients, fallbackClient) {
-		log.WithField("client", client.Name()).Debugf("registered client")
+	for _, file := range []struct {
+		path   string
+		assign *string
+	}{
+		{opts.Files.DockerToken, &opts.Docker.Token},
+		{opts.Files.GHCRToken, &opts.GHCR.Token},
+		{opts.Files.QuayToken, &opts.Quay.Token},
+	} {
+		if err := readCredentialFile(file.path, file.assign); err != nil {
+			return err
+		}
 	}
 
-	return c, nil
+	for name, files := range opts.Files.Selfhosted {
+		sOpts, ok := opts.Selfhosted[name]
+		if !ok {
+			return fmt.Errorf("credential files given for unknown selfhosted registry %q", name)
+		}
+
+		for _, file := range []struct {
+			path   string
+			assign *string
+		}{
+			{files.Username, &sOpts.Username},
+			{files.Password, &sOpts.Password},
+			{files.Bearer, &sOpts.Bearer},
+		} {
+			if err := readCredentialFile(file.path, file.assign); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// readCredentialFile assigns the trimmed contents of the file, if it is set.
+func readCredentialFile(path string, assign *string) error {
+	if len(path) == 0 {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	*assign = strings.TrimSpace(string(data))
+	return nil
+}
+
+// setKeychain sets the Keychain of each client that can use it. The GHCR
+// token is the password of its registry, as the client only uses it if set.
+// ECR and Quay authenticate to their APIs with other credentials than their
+// registries, so don't use the Keychain.
+func setKeychain(opts *Options) error {
+	opts.ACR.Keychain = opts.Keychain
+	opts.Docker.Keychain = opts.Keychain
+	opts.GCR.Keychain = opts.Keychain
+	opts.OCI.Keychain = opts.Keychain
+
+	if len(opts.GHCR.Token) == 0 {
+		host := opts.GHCR.Hostname
+		if len(host) == 0 {
+			host = "ghcr.io"
+		}
+		auth, err := util.Authorization(opts.Keychain, host)
+		if err != nil {
+			return fmt.Errorf("failed to get %s credentials from keychain: %w", host, err)
+		}
+		if auth != nil {
+			opts.GHCR.Token = auth.Password
+		}
+	}
+
+	return nil
 }
 
 // Tags returns the full list of image tags available, for a given image URL.
 func (c *Client) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, error) {
-	client, host, path := c.fromImageURL(imageURL)
+	client, host, path, err := c.fromImageURL(ctx, imageURL)
+	if err != nil {
+		return nil, err
+	}
 
 	c.log.Debugf("using client %q for image URL %q", client.Name(), imageURL)
 	repo, image := client.RepoImageFromPath(path)
 
-	return client.Tags(ctx, host, repo, image)
+	tags, err := client.Tags(ctx, host, repo, image)
+	c.recordRoute(c.Route(ctx, host))
+
+	return tags, err
 }
 
 // fromImageURL will return the appropriate registry client for a given
 // image URL, and the host + path to search.
-func (c *Client) fromImageURL(imageURL string) (api.ImageClient, string, string) {
+func (c *Client) fromImageURL(ctx context.Context, imageURL string) (api.ImageClient, string, string, error) {
+	regs := c.registries.Load()
+
 	var host, path string
 
 	if strings.Contains(imageURL, ".") || strings.Contains(imageURL, ":") {
@@ -148,12 +411,10 @@ func (c *Client) fromImageURL(imageURL string) (api.ImageClient, string, string)
 		path = imageURL
 	}
 
-	for _, client := range c.clients {
-		if client.IsHost(host) {
-			return client, host, path
-		}
+	client, _, err := c.clientForHost(ctx, regs, host)
+	if err != nil {
+		return nil, "", "", err
 	}
 
-	// fall back to selfhosted with no path split
-	return c.fallbackClient, host, path
+	return client, host, path, nil
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/jetstack/version-checker/pkg/api"
 	"github.com/jetstack/version-checker/pkg/client/acr"
@@ -180,7 +181,8 @@ func TestFromImageURL(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			client, host, path := handler.fromImageURL(test.url)
+			client, host, path, err := handler.fromImageURL(context.TODO(), test.url)
+			require.NoError(t, err)
 
 			assert.IsType(t, test.expClient, client)
 			assert.Equal(t, test.expHost, host)
@@ -8,28 +8,20 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
-	"golang.org/x/time/rate"
-
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/sirupsen/logrus"
 
-	retryablehttp "github.com/hashicorp/go-retryablehttp"
 	"github.com/jetstack/version-checker/pkg/api"
 	"github.com/jetstack/version-checker/pkg/client/util"
-	leveledlogger "github.com/jetstack/version-checker/pkg/leveledlogrus"
 )
 
 // Ensure that we are an ImageClient
 var _ api.ImageClient = (*Client)(nil)
 
-// Values taken from: https://docs.docker.com/docker-hub/usage/#abuse-rate-limit
-const (
-	windowDuration = time.Minute
-	APIRateLimit   = 500
-	maxWait        = time.Hour
-)
-
 const (
 	loginURL  = "https://hub.docker.com/v2/users/login/"
 	lookupURL = "https://registry.hub.docker.com/v2/repositories/%s/%s/tags?page_size=100"
@@ -40,38 +32,46 @@ type Options struct {
 	Username    string
 	Password    string
 	Token       string
+
+	// Keychain, if set, provides the username and password when none are
+	// given.
+	Keychain authn.Keychain
 }
 
 type Client struct {
 	*http.Client
 	Options
 
-	log     *logrus.Entry
-	limiter *rate.Limiter
+	log *logrus.Entry
+
+	// tokens are the tokens of the Docker Hub credentials of requests, such
+	// as of a Pod's imagePullSecrets, so that they only log in once.
+	tokensMu sync.Mutex
+	tokens   map[authn.AuthConfig]string
 }
 
 func New(opts Options, log *logrus.Entry) (*Client, error) {
 	ctx := context.Background()
 
-	limiter := rate.NewLimiter(
-		rate.Every(windowDuration/APIRateLimit),
-		1,
-	)
 	log = log.WithField("client", "docker")
 
-	retryclient := retryablehttp.NewClient()
-	if opts.Transporter != nil {
-		retryclient.HTTPClient.Transport = opts.Transporter
+	// Docker Hub reports its quota with RateLimit-Remaining, which the
+	// Retrier slows down for.
+	transport := opts.Transporter
+	if transport == nil {
+		transport = util.NewRetrier(log, util.RetryOptions{}).RoundTripper(nil)
+	}
+	client := &http.Client{Transport: transport}
+
+	if len(opts.Username) == 0 && len(opts.Password) == 0 && len(opts.Token) == 0 {
+		auth, err := util.Authorization(opts.Keychain, name.DefaultRegistry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get credentials from keychain: %s", err)
+		}
+		if auth != nil {
+			opts.Username, opts.Password = auth.Username, auth.Password
+		}
 	}
-	retryclient.Backoff = util.RateLimitedBackoffLimiter(log, limiter, maxWait)
-	retryclient.HTTPClient.Timeout = 10 * time.Second
-	retryclient.RetryMax = 10
-	retryclient.RetryWaitMax = 10 * time.Minute
-	retryclient.RetryWaitMin = 1 * time.Second
-	// This custom backoff will fail requests that have a max wait of the RetryWaitMax
-	retryclient.Backoff = util.HTTPBackOff
-	retryclient.Logger = &leveledlogger.Logger{Entry: log.WithField("client", "docker")}
-	client := retryclient.StandardClient()
 
 	// Setup Auth if username and password used.
 	if len(opts.Username) > 0 || len(opts.Password) > 0 {
@@ -90,7 +90,6 @@ func New(opts Options, log *logrus.Entry) (*Client, error) {
 		Options: opts,
 		Client:  client,
 		log:     log,
-		limiter: limiter,
 	}, nil
 }
 
@@ -170,8 +169,13 @@ func (c *Client) doRequest(ctx context.Context, url string) (*TagResponse, error
 
 	req.URL.Scheme = "https"
 	req = req.WithContext(ctx)
-	if len(c.Token) > 0 {
-		req.Header.Add("Authorization", "Bearer "+c.Token)
+
+	token, err := c.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(token) > 0 {
+		req.Header.Add("Authorization", "Bearer "+token)
 	}
 	req.Header.Set("User-Agent", "version-checker/docker")
 
@@ -194,6 +198,38 @@ func (c *Client) doRequest(ctx context.Context, url string) (*TagResponse, error
 	return response, nil
 }
 
+// token returns the token to authenticate the request with. The Docker Hub
+// credentials of the request's keychain, e.g. of the Pod's imagePullSecrets,
+// are preferred over the Token.
+func (c *Client) token(ctx context.Context) (string, error) {
+	auth, err := util.Authorization(util.ContextKeychain(ctx, nil), name.DefaultRegistry)
+	if err != nil {
+		return "", fmt.Errorf("failed to get credentials from keychain: %s", err)
+	}
+	if auth == nil || (len(auth.Username) == 0 && len(auth.Password) == 0) {
+		return c.Token, nil
+	}
+
+	c.tokensMu.Lock()
+	defer c.tokensMu.Unlock()
+
+	if token, ok := c.tokens[*auth]; ok {
+		return token, nil
+	}
+
+	token, err := basicAuthSetup(ctx, c.Client, Options{Username: auth.Username, Password: auth.Password})
+	if err != nil {
+		return "", fmt.Errorf("failed to setup auth: %s", err)
+	}
+
+	if c.tokens == nil {
+		c.tokens = make(map[authn.AuthConfig]string)
+	}
+	c.tokens[*auth] = token
+
+	return token, nil
+}
+
 func basicAuthSetup(ctx context.Context, client *http.Client, opts Options) (string, error) {
 	upReader := strings.NewReader(
 		fmt.Sprintf(`{"username": "%s", "password": "%s"}`,
@@ -7,10 +7,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/dockerconfig"
+	"github.com/jetstack/version-checker/pkg/client/util"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -135,4 +138,42 @@ func TestTags(t *testing.T) {
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "unexpected image")
 	})
+
+	t.Run("pull secret credentials are preferred and log in once", func(t *testing.T) {
+		var logins atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/v2/users/login/" {
+				logins.Add(1)
+				var creds struct{ Username, Password string }
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&creds))
+				assert.Equal(t, "pod-user", creds.Username)
+				assert.Equal(t, "pod-password", creds.Password)
+				_ = json.NewEncoder(w).Encode(AuthResponse{Token: "pod-token"})
+				return
+			}
+
+			assert.Equal(t, "Bearer pod-token", r.Header.Get("Authorization"))
+			_ = json.NewEncoder(w).Encode(TagResponse{})
+		}))
+		defer server.Close()
+
+		client := &Client{
+			Client: server.Client(),
+			log:    log,
+			Options: Options{
+				Token: "testtoken",
+			},
+		}
+		client.Transport = &hostnameOverride{RT: server.Client().Transport, Host: server.URL}
+
+		keychain, err := dockerconfig.Parse([]byte(`{"auths": {"https://index.docker.io/v1/": {"username": "pod-user", "password": "pod-password"}}}`))
+		require.NoError(t, err)
+		podCtx := util.ContextWithKeychain(ctx, keychain, "default/pod-secret")
+
+		for i := 0; i < 2; i++ {
+			_, err := client.Tags(podCtx, "NOT USED!", "testrepo", "testimage")
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(1), logins.Load())
+	})
 }
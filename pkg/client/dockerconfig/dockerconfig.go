@@ -0,0 +1,124 @@
+package dockerconfig
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// cacheTimeout is how long credentials are cached for, so that credential
+// helpers aren't run on every request.
+const cacheTimeout = 5 * time.Minute
+
+// Ensure that we are a Keychain
+var _ authn.Keychain = (*Keychain)(nil)
+
+// Keychain is a Keychain of the credentials in a Docker config file, such
+// as ~/.docker/config.json or the .dockerconfigjson key of a
+// kubernetes.io/dockerconfigjson Secret. Credentials are read from its
+// auths, or from the credential helpers given by its credHelpers and
+// credsStore, which must be installed.
+type Keychain struct {
+	file *configfile.ConfigFile
+
+	mu    sync.Mutex
+	cache map[string]cachedAuth
+}
+
+// cachedAuth is the resolved credentials of a registry.
+type cachedAuth struct {
+	auth    authn.AuthConfig
+	expires time.Time
+}
+
+// Load returns a Keychain of the Docker config file at the given path.
+func Load(path string) (*Keychain, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open docker config: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	file, err := config.LoadFromReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse docker config %q: %w", path, err)
+	}
+
+	return newKeychain(file), nil
+}
+
+// Parse returns a Keychain of the given Docker config file data, such as the
+// .dockerconfigjson key of an imagePullSecret. Its credHelpers and
+// credsStore are ignored, so that untrusted data can't run programs.
+func Parse(data []byte) (*Keychain, error) {
+	file, err := config.LoadFromReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse docker config: %w", err)
+	}
+
+	file.CredentialHelpers = nil
+	file.CredentialsStore = ""
+
+	return newKeychain(file), nil
+}
+
+func newKeychain(file *configfile.ConfigFile) *Keychain {
+	return &Keychain{
+		file:  file,
+		cache: make(map[string]cachedAuth),
+	}
+}
+
+// Resolve returns the credentials of the target registry or repository, or
+// Anonymous if the config file has none.
+func (k *Keychain) Resolve(target authn.Resource) (authn.Authenticator, error) {
+	for _, key := range []string{target.String(), target.RegistryStr()} {
+		// Docker Hub is keyed by its legacy URL
+		if key == name.DefaultRegistry {
+			key = authn.DefaultAuthKey
+		}
+
+		auth, err := k.authConfig(key)
+		if err != nil {
+			return nil, err
+		}
+		if auth != (authn.AuthConfig{}) {
+			return authn.FromConfig(auth), nil
+		}
+	}
+
+	return authn.Anonymous, nil
+}
+
+// authConfig returns the credentials of the key, which are empty if there
+// are none.
+func (k *Keychain) authConfig(key string) (authn.AuthConfig, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if cached, ok := k.cache[key]; ok && time.Now().Before(cached.expires) {
+		return cached.auth, nil
+	}
+
+	cfg, err := k.file.GetAuthConfig(key)
+	if err != nil {
+		return authn.AuthConfig{}, fmt.Errorf("failed to get credentials of %q: %w", key, err)
+	}
+
+	auth := authn.AuthConfig{
+		Username:      cfg.Username,
+		Password:      cfg.Password,
+		IdentityToken: cfg.IdentityToken,
+		RegistryToken: cfg.RegistryToken,
+	}
+	k.cache[key] = cachedAuth{auth: auth, expires: time.Now().Add(cacheTimeout)}
+
+	return auth, nil
+}
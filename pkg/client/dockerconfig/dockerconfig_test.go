@@ -0,0 +1,143 @@
+package dockerconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `{
+	"auths": {
+		"https://index.docker.io/v1/": {"auth": "aHViLXVzZXI6aHViLXBhc3N3b3Jk"},
+		"registry.example.com": {"username": "user", "password": "password"},
+		"myregistry.azurecr.io": {"identitytoken": "refresh-token"},
+		"registry.example.com/team": {"username": "team-user", "password": "team-password"}
+	},
+	"credHelpers": {
+		"helper.example.com": "fake"
+	}
+}`
+
+// fakeHelper is a docker credential helper returning fixed credentials.
+const fakeHelper = `#!/bin/sh
+read server
+echo "{\"ServerURL\":\"$server\",\"Username\":\"helper-user\",\"Secret\":\"helper-secret\"}"
+`
+
+func TestKeychain(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(testConfig), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docker-credential-fake"), []byte(fakeHelper), 0700)) // #nosec G306
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	keychain, err := Load(path)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		target  authn.Resource
+		expAuth *authn.AuthConfig
+	}{
+		"docker hub is keyed by its legacy url": {
+			target:  mustRegistry(t, "docker.io"),
+			expAuth: &authn.AuthConfig{Username: "hub-user", Password: "hub-password"},
+		},
+		"username and password": {
+			target:  mustRegistry(t, "registry.example.com"),
+			expAuth: &authn.AuthConfig{Username: "user", Password: "password"},
+		},
+		"identity token": {
+			target:  mustRegistry(t, "myregistry.azurecr.io"),
+			expAuth: &authn.AuthConfig{IdentityToken: "refresh-token"},
+		},
+		"repository credentials are preferred": {
+			target:  mustRepository(t, "registry.example.com/team"),
+			expAuth: &authn.AuthConfig{Username: "team-user", Password: "team-password"},
+		},
+		"other repositories use the registry credentials": {
+			target:  mustRepository(t, "registry.example.com/other"),
+			expAuth: &authn.AuthConfig{Username: "user", Password: "password"},
+		},
+		"credential helper": {
+			target:  mustRegistry(t, "helper.example.com"),
+			expAuth: &authn.AuthConfig{Username: "helper-user", Password: "helper-secret"},
+		},
+		"unknown registry is anonymous": {
+			target: mustRegistry(t, "quay.io"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			auth, err := keychain.Resolve(test.target)
+			require.NoError(t, err)
+
+			if test.expAuth == nil {
+				assert.Equal(t, authn.Anonymous, auth)
+				return
+			}
+
+			cfg, err := auth.Authorization()
+			require.NoError(t, err)
+			assert.Equal(t, test.expAuth, cfg)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := Load(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte("{"), 0600))
+	_, err = Load(invalid)
+	assert.Error(t, err)
+}
+
+func TestParse(t *testing.T) {
+	// The helper is installed, but must not be run for parsed data
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docker-credential-fake"), []byte(fakeHelper), 0700)) // #nosec G306
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	keychain, err := Parse([]byte(`{
+		"auths": {"registry.example.com": {"username": "user", "password": "password"}},
+		"credHelpers": {"helper.example.com": "fake"},
+		"credsStore": "fake"
+	}`))
+	require.NoError(t, err)
+
+	auth, err := keychain.Resolve(mustRegistry(t, "registry.example.com"))
+	require.NoError(t, err)
+	cfg, err := auth.Authorization()
+	require.NoError(t, err)
+	assert.Equal(t, &authn.AuthConfig{Username: "user", Password: "password"}, cfg)
+
+	for _, host := range []string{"helper.example.com", "quay.io"} {
+		auth, err := keychain.Resolve(mustRegistry(t, host))
+		require.NoError(t, err)
+		assert.Equal(t, authn.Anonymous, auth, host)
+	}
+
+	_, err = Parse([]byte("{"))
+	assert.Error(t, err)
+}
+
+func mustRegistry(t *testing.T, host string) name.Registry {
+	reg, err := name.NewRegistry(host)
+	require.NoError(t, err)
+	return reg
+}
+
+func mustRepository(t *testing.T, repo string) name.Repository {
+	r, err := name.NewRepository(repo)
+	require.NoError(t, err)
+	return r
+}
@@ -2,13 +2,18 @@ package ecr
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 
 	"github.com/jetstack/version-checker/pkg/api"
 	"github.com/jetstack/version-checker/pkg/client/util"
@@ -17,9 +22,18 @@ import (
 // Ensure that we are an ImageClient
 var _ api.ImageClient = (*Client)(nil)
 
+// envWebIdentityTokenFile is the token file of IRSA, which EKS injects into
+// Pods of ServiceAccounts annotated with an IAM role.
+const envWebIdentityTokenFile = "AWS_WEB_IDENTITY_TOKEN_FILE" // #nosec G101
+
 type Client struct {
 	Options
 	Config aws.Config
+
+	// configs are the configs of each region, so that their credentials are
+	// cached and refreshed before they expire rather than on every request.
+	configsMu sync.Mutex
+	configs   map[string]aws.Config
 }
 
 type Options struct {
@@ -28,11 +42,17 @@ type Options struct {
 	AccessKeyID     string
 	SecretAccessKey string
 	SessionToken    string
+
+	// WebIdentityTokenFile is the projected ServiceAccount token which is
+	// exchanged for credentials of the IamRoleArn. Defaults to that of IRSA
+	// when the IamRoleArn is given.
+	WebIdentityTokenFile string
 }
 
 func New(opts Options) *Client {
 	return &Client{
 		Options: opts,
+		configs: make(map[string]aws.Config),
 	}
 }
 
@@ -100,25 +120,54 @@ func (c *Client) Tags(ctx context.Context, host, repo, image string) ([]api.Imag
 }
 
 func (c *Client) createClient(ctx context.Context, region string) (*ecr.Client, error) {
-	var cfg aws.Config
-	var err error
-
-	if c.IamRoleArn != "" {
-		cfg, err = config.LoadDefaultConfig(ctx,
-			config.WithRegion(region),
-			config.WithHTTPClient(&http.Client{Transport: c.Transporter}),
-		)
-	} else {
-		cfg, err = config.LoadDefaultConfig(ctx,
-			config.WithCredentialsProvider(
-				credentials.NewStaticCredentialsProvider(c.AccessKeyID, c.SecretAccessKey, c.SessionToken),
-			),
-			config.WithRegion(region),
-			config.WithHTTPClient(&http.Client{Transport: c.Transporter}),
-		)
-	}
+	cfg, err := c.config(ctx, region)
 	if err != nil {
 		return nil, fmt.Errorf("failed to construct aws credentials: %s", err)
 	}
 	return ecr.NewFromConfig(cfg), nil
 }
+
+// config returns the config of the region. Static credentials are used if
+// given, otherwise those of the default credential chain, which includes
+// IRSA and EKS Pod Identity. With an IamRoleArn and web identity token, the
+// token is exchanged for credentials of the role.
+func (c *Client) config(ctx context.Context, region string) (aws.Config, error) {
+	c.configsMu.Lock()
+	defer c.configsMu.Unlock()
+
+	if cfg, ok := c.configs[region]; ok {
+		return cfg, nil
+	}
+
+	optFns := []func(*config.LoadOptions) error{
+		config.WithRegion(region),
+		config.WithHTTPClient(&http.Client{Transport: c.Transporter}),
+	}
+	if len(c.AccessKeyID) > 0 || len(c.SecretAccessKey) > 0 || len(c.SessionToken) > 0 {
+		optFns = append(optFns, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(c.AccessKeyID, c.SecretAccessKey, c.SessionToken),
+		))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, optFns...)
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	tokenFile := c.WebIdentityTokenFile
+	if len(tokenFile) == 0 && len(c.IamRoleArn) > 0 {
+		tokenFile = os.Getenv(envWebIdentityTokenFile)
+	}
+	if len(tokenFile) > 0 {
+		if len(c.IamRoleArn) == 0 {
+			return aws.Config{}, errors.New("an IAM role ARN is required with a web identity token file")
+		}
+		cfg.Credentials = aws.NewCredentialsCache(stscreds.NewWebIdentityRoleProvider(
+			sts.NewFromConfig(cfg), c.IamRoleArn, stscreds.IdentityTokenFile(tokenFile),
+		))
+	}
+
+	c.configs[region] = cfg
+
+	return cfg, nil
+}
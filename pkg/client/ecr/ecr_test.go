@@ -0,0 +1,190 @@
+package ecr
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+const describeImagesResponse = `{"imageDetails":[{
+	"imageDigest":"sha256:abc",
+	"imagePushedAt":1700000000,
+	"imageTags":["v1.0.0"]
+}]}`
+
+// fakeAWS is a fake of the STS, ECR and EKS Pod Identity endpoints.
+type fakeAWS struct {
+	*httptest.Server
+
+	assumeRoles  atomic.Int32
+	podIdentity  atomic.Int32
+	webIdentity  atomic.Value
+	ecrAuthorize atomic.Value
+}
+
+func newFakeAWS(t *testing.T) *fakeAWS {
+	f := new(fakeAWS)
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/pod-identity":
+			f.podIdentity.Add(1)
+			assert.Equal(t, "pod-identity-token", r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte(`{
+				"AccessKeyId":"POD_IDENTITY_KEY",
+				"SecretAccessKey":"secret",
+				"Token":"token",
+				"Expiration":"2100-01-01T00:00:00Z"
+			}`))
+
+		case strings.HasPrefix(r.Header.Get("X-Amz-Target"), "AmazonEC2ContainerRegistry"):
+			f.ecrAuthorize.Store(r.Header.Get("Authorization"))
+			w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+			_, _ = w.Write([]byte(describeImagesResponse))
+
+		default:
+			body, _ := io.ReadAll(r.Body)
+			form, err := url.ParseQuery(string(body))
+			require.NoError(t, err)
+			assert.Equal(t, "AssumeRoleWithWebIdentity", form.Get("Action"))
+			assert.Equal(t, "arn:aws:iam::123456789012:role/version-checker", form.Get("RoleArn"))
+			f.assumeRoles.Add(1)
+			f.webIdentity.Store(form.Get("WebIdentityToken"))
+
+			w.Header().Set("Content-Type", "text/xml")
+			_, _ = w.Write([]byte(`<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>WEB_IDENTITY_KEY</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>token</SessionToken>
+      <Expiration>2100-01-01T00:00:00Z</Expiration>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`))
+		}
+	}))
+	t.Cleanup(f.Close)
+
+	// Isolate the default credential chain from the environment
+	dir := t.TempDir()
+	for key, value := range map[string]string{
+		"AWS_CONFIG_FILE":                        filepath.Join(dir, "config"),
+		"AWS_SHARED_CREDENTIALS_FILE":            filepath.Join(dir, "credentials"),
+		"AWS_EC2_METADATA_DISABLED":              "true",
+		"AWS_CA_BUNDLE":                          "",
+		"AWS_ACCESS_KEY_ID":                      "",
+		"AWS_SECRET_ACCESS_KEY":                  "",
+		"AWS_SESSION_TOKEN":                      "",
+		"AWS_PROFILE":                            "",
+		"AWS_ROLE_ARN":                           "",
+		"AWS_WEB_IDENTITY_TOKEN_FILE":            "",
+		"AWS_CONTAINER_CREDENTIALS_FULL_URI":     "",
+		"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI": "",
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE": "",
+		"AWS_ENDPOINT_URL_STS":                   f.URL,
+		"AWS_ENDPOINT_URL_ECR":                   f.URL,
+	} {
+		t.Setenv(key, value)
+	}
+
+	return f
+}
+
+func writeFile(t *testing.T, name, data string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	return path
+}
+
+func TestClientCredentials(t *testing.T) {
+	const (
+		host    = "123456789012.dkr.ecr.us-east-1.amazonaws.com"
+		roleARN = "arn:aws:iam::123456789012:role/version-checker"
+	)
+
+	expTags := []api.ImageTag{{Tag: "v1.0.0", SHA: "sha256:abc"}}
+
+	t.Run("static credentials", func(t *testing.T) {
+		f := newFakeAWS(t)
+		client := New(Options{AccessKeyID: "STATIC_KEY", SecretAccessKey: "secret"})
+
+		tags, err := client.Tags(context.Background(), host, "", "app")
+		require.NoError(t, err)
+		assertTags(t, expTags, tags)
+		assert.Contains(t, f.ecrAuthorize.Load(), "Credential=STATIC_KEY/")
+		assert.Zero(t, f.assumeRoles.Load())
+	})
+
+	t.Run("web identity token file of the option", func(t *testing.T) {
+		f := newFakeAWS(t)
+		client := New(Options{
+			IamRoleArn:           roleARN,
+			WebIdentityTokenFile: writeFile(t, "token", "option-token"),
+		})
+
+		for i := 0; i < 3; i++ {
+			tags, err := client.Tags(context.Background(), host, "", "app")
+			require.NoError(t, err)
+			assertTags(t, expTags, tags)
+		}
+		assert.Contains(t, f.ecrAuthorize.Load(), "Credential=WEB_IDENTITY_KEY/")
+		assert.Equal(t, "option-token", f.webIdentity.Load())
+		// Credentials are cached until they expire
+		assert.Equal(t, int32(1), f.assumeRoles.Load())
+	})
+
+	t.Run("IRSA of the default credential chain", func(t *testing.T) {
+		f := newFakeAWS(t)
+		t.Setenv("AWS_ROLE_ARN", roleARN)
+		t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", writeFile(t, "token", "irsa-token"))
+		client := New(Options{})
+
+		_, err := client.Tags(context.Background(), host, "", "app")
+		require.NoError(t, err)
+		assert.Contains(t, f.ecrAuthorize.Load(), "Credential=WEB_IDENTITY_KEY/")
+		assert.Equal(t, "irsa-token", f.webIdentity.Load())
+	})
+
+	t.Run("EKS Pod Identity of the default credential chain", func(t *testing.T) {
+		f := newFakeAWS(t)
+		t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", f.URL+"/pod-identity")
+		t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE", writeFile(t, "token", "pod-identity-token"))
+		client := New(Options{})
+
+		for i := 0; i < 2; i++ {
+			_, err := client.Tags(context.Background(), host, "", "app")
+			require.NoError(t, err)
+		}
+		assert.Contains(t, f.ecrAuthorize.Load(), "Credential=POD_IDENTITY_KEY/")
+		assert.Equal(t, int32(1), f.podIdentity.Load())
+	})
+
+	t.Run("web identity token file requires a role", func(t *testing.T) {
+		newFakeAWS(t)
+		client := New(Options{WebIdentityTokenFile: writeFile(t, "token", "token")})
+
+		_, err := client.Tags(context.Background(), host, "", "app")
+		assert.ErrorContains(t, err, "an IAM role ARN is required with a web identity token file")
+	})
+}
+
+func assertTags(t *testing.T, exp, tags []api.ImageTag) {
+	t.Helper()
+	require.Len(t, tags, len(exp))
+	for i := range exp {
+		assert.Equal(t, exp[i].Tag, tags[i].Tag)
+		assert.Equal(t, exp[i].SHA, tags[i].SHA)
+	}
+}
@@ -66,6 +66,17 @@ func (c *Client) Tags(ctx context.Context, host, repo, image string) (tags []api
 	return nil, fmt.Errorf("failed to fetch tags for host: %s, repo: %s, image: %s", host, repo, image)
 }
 
+// HostClient returns the name of the client which last succeeded for the
+// host, if it is cached.
+func (c *Client) HostClient(host string) (string, bool) {
+	if client, found := c.hostCache.Get(host); found {
+		if client, ok := client.(api.ImageClient); ok {
+			return client.Name(), true
+		}
+	}
+	return "", false
+}
+
 func (c *Client) IsHost(_ string) bool {
 	return true
 }
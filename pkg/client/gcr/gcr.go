@@ -7,9 +7,13 @@ import (
 	"io"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
+	"github.com/google/go-containerregistry/pkg/authn"
+
 	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
 )
 
 const (
@@ -22,11 +26,26 @@ var _ api.ImageClient = (*Client)(nil)
 type Options struct {
 	Transporter http.RoundTripper
 	Token       string
+
+	// Keychain, if set, provides the credentials of each registry when no
+	// Token is given.
+	Keychain authn.Keychain
+
+	// WorkloadIdentity uses the access token of the metadata server when no
+	// other credentials are found, such as that of GKE Workload Identity.
+	// MetadataHost defaults to GCE_METADATA_HOST, or else the GKE metadata
+	// server.
+	WorkloadIdentity bool
+	MetadataHost     string
 }
 
 type Client struct {
 	*http.Client
 	Options
+
+	metadataTokenMu     sync.Mutex
+	metadataToken       string
+	metadataTokenExpiry time.Time
 }
 
 type Response struct {
@@ -40,10 +59,13 @@ type ManifestItem struct {
 }
 
 func New(opts Options) *Client {
+	if opts.WorkloadIdentity && len(opts.MetadataHost) == 0 {
+		opts.MetadataHost = metadataHost()
+	}
+
 	return &Client{
 		Options: opts,
 		Client: &http.Client{
-			Timeout:   time.Second * 5,
 			Transport: opts.Transporter,
 		},
 	}
@@ -57,7 +79,7 @@ func (c *Client) Tags(ctx context.Context, host, repo, image string) ([]api.Imag
 	image = c.constructImageName(repo, image)
 	url := fmt.Sprintf(lookupURL, host, image)
 
-	req, err := c.buildRequest(ctx, url)
+	req, err := c.buildRequest(ctx, host, url)
 	if err != nil {
 		return nil, err
 	}
@@ -88,14 +110,34 @@ func (c *Client) constructImageName(repo, image string) string {
 	return image
 }
 
-func (c *Client) buildRequest(ctx context.Context, url string) (*http.Request, error) {
+func (c *Client) buildRequest(ctx context.Context, host, url string) (*http.Request, error) {
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(c.Token) > 0 {
+	// The credentials of the request's keychain, e.g. of the Pod's
+	// imagePullSecrets, are preferred over the Token.
+	keychain := util.ContextKeychain(ctx, nil)
+	if len(c.Token) == 0 {
+		keychain = util.ContextKeychain(ctx, c.Keychain)
+	}
+	auth, err := util.Authorization(keychain, host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credentials from keychain: %w", err)
+	}
+
+	switch {
+	case auth != nil:
+		req.SetBasicAuth(auth.Username, auth.Password)
+	case len(c.Token) > 0:
 		req.SetBasicAuth("oauth2accesstoken", c.Token)
+	case c.WorkloadIdentity:
+		token, err := c.workloadIdentityToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.SetBasicAuth("oauth2accesstoken", token)
 	}
 
 	return req.WithContext(ctx), nil
@@ -0,0 +1,71 @@
+package gcr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	// envMetadataHost overrides the host of the metadata server, as with
+	// Google's client libraries.
+	envMetadataHost     = "GCE_METADATA_HOST"
+	defaultMetadataHost = "metadata.google.internal"
+	metadataTokenURL    = "http://%s/computeMetadata/v1/instance/service-accounts/default/token"
+
+	// tokenExpiryMargin is how long before it expires a token is refreshed.
+	tokenExpiryMargin = time.Minute
+)
+
+type metadataTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// workloadIdentityToken returns the access token of the Pod's Google service
+// account from the metadata server, which is the GKE metadata server with
+// Workload Identity. The token is cached until shortly before it expires.
+func (c *Client) workloadIdentityToken(ctx context.Context) (string, error) {
+	c.metadataTokenMu.Lock()
+	defer c.metadataTokenMu.Unlock()
+
+	if len(c.metadataToken) > 0 && time.Now().Before(c.metadataTokenExpiry) {
+		return c.metadataToken, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(metadataTokenURL, c.MetadataHost), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request metadata server token: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to request metadata server token: unexpected status %s", resp.Status)
+	}
+
+	var token metadataTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode metadata server token: %w", err)
+	}
+
+	c.metadataToken = token.AccessToken
+	c.metadataTokenExpiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - tokenExpiryMargin)
+
+	return c.metadataToken, nil
+}
+
+func metadataHost() string {
+	if host := os.Getenv(envMetadataHost); len(host) > 0 {
+		return host
+	}
+	return defaultMetadataHost
+}
@@ -0,0 +1,107 @@
+package gcr
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkloadIdentity(t *testing.T) {
+	var (
+		tokens atomic.Int32
+		// expiresIn is the lifetime of the tokens issued, in seconds
+		expiresIn atomic.Int64
+	)
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/computeMetadata/v1/instance/service-accounts/default/token", r.URL.Path)
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		n := tokens.Add(1)
+		_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":%d,"token_type":"Bearer"}`, n, expiresIn.Load())
+	}))
+	defer metadata.Close()
+
+	var authorization atomic.Value
+	registry := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization.Store(r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"manifest":{"sha256:abc":{"timeCreatedMs":"1700000000000","tag":["v1.0.0"]}}}`))
+	}))
+	defer registry.Close()
+	host := registry.Listener.Addr().String()
+
+	password := func() string {
+		t.Helper()
+		header, _ := authorization.Load().(string)
+		req := &http.Request{Header: http.Header{"Authorization": {header}}}
+		username, password, ok := req.BasicAuth()
+		require.True(t, ok)
+		assert.Equal(t, "oauth2accesstoken", username)
+		return password
+	}
+
+	t.Run("metadata host defaults to the environment", func(t *testing.T) {
+		t.Setenv(envMetadataHost, "metadata.example.com")
+		assert.Equal(t, "metadata.example.com", New(Options{WorkloadIdentity: true}).MetadataHost)
+
+		t.Setenv(envMetadataHost, "")
+		assert.Equal(t, defaultMetadataHost, New(Options{WorkloadIdentity: true}).MetadataHost)
+	})
+
+	t.Run("token is cached until shortly before it expires", func(t *testing.T) {
+		tokens.Store(0)
+		expiresIn.Store(3600)
+		client := New(Options{
+			Transporter:      registry.Client().Transport,
+			WorkloadIdentity: true,
+			MetadataHost:     strings.TrimPrefix(metadata.URL, "http://"),
+		})
+
+		for i := 0; i < 3; i++ {
+			tags, err := client.Tags(context.Background(), host, "project", "app")
+			require.NoError(t, err)
+			require.Len(t, tags, 1)
+			assert.Equal(t, "token-1", password())
+		}
+		assert.Equal(t, int32(1), tokens.Load())
+	})
+
+	t.Run("token is refreshed when it is about to expire", func(t *testing.T) {
+		tokens.Store(0)
+		expiresIn.Store(30)
+		client := New(Options{
+			Transporter:      registry.Client().Transport,
+			WorkloadIdentity: true,
+			MetadataHost:     strings.TrimPrefix(metadata.URL, "http://"),
+		})
+
+		for i := 1; i <= 2; i++ {
+			_, err := client.Tags(context.Background(), host, "project", "app")
+			require.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("token-%d", i), password())
+		}
+	})
+
+	t.Run("configured token is preferred", func(t *testing.T) {
+		tokens.Store(0)
+		client := New(Options{
+			Transporter:      registry.Client().Transport,
+			Token:            "configured-token",
+			WorkloadIdentity: true,
+			MetadataHost:     strings.TrimPrefix(metadata.URL, "http://"),
+		})
+
+		_, err := client.Tags(context.Background(), host, "project", "app")
+		require.NoError(t, err)
+		assert.Equal(t, "configured-token", password())
+		assert.Zero(t, tokens.Load())
+	})
+}
@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"net/http"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/patrickmn/go-cache"
 	"github.com/sirupsen/logrus"
 
 	"github.com/google/go-containerregistry/pkg/authn"
@@ -16,6 +19,7 @@ import (
 	"github.com/google/go-containerregistry/pkg/v1/types"
 
 	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
 )
 
 var numWorkers = runtime.NumCPU() * 5
@@ -23,6 +27,13 @@ var numWorkers = runtime.NumCPU() * 5
 type Options struct {
 	Transporter http.RoundTripper
 	Auth        *authn.AuthConfig
+
+	// Keychain, if set, resolves the credentials of each registry when
+	// Auth is not set.
+	Keychain authn.Keychain
+
+	// ResponseTimeout is how long tag list responses are kept to revalidate.
+	ResponseTimeout time.Duration
 }
 
 func (o *Options) Authorization() (*authn.AuthConfig, error) {
@@ -37,6 +48,23 @@ type Client struct {
 	*Options
 	log    *logrus.Entry
 	puller *remote.Puller
+
+	// pullOpts are the options of the puller, without authentication, for
+	// pullers of requests with their own keychain.
+	pullOpts []remote.Option
+
+	// manifests holds the manifests seen on the last call to Manifests,
+	// keyed by repository and tag, so that unchanged tags aren't fetched
+	// again. Repositories are dropped once they haven't been listed for the
+	// ResponseTimeout, the same as their tag list responses.
+	manifestsMu sync.Mutex
+	manifests   *cache.Cache
+}
+
+// knownManifest is the tag built from a manifest, along with its digest.
+type knownManifest struct {
+	digest string
+	tag    api.ImageTag
 }
 
 // Ensure that we are an ImageClient
@@ -44,25 +72,69 @@ var _ api.ImageClient = (*Client)(nil)
 
 // New returns a new client
 func New(opts *Options, log *logrus.Entry) (*Client, error) {
+	transport := opts.Transporter
+	if transport == nil {
+		transport = remote.DefaultTransport
+	}
+
 	pullOpts := []remote.Option{
 		remote.WithJobs(numWorkers),
 		remote.WithUserAgent("version-checker/oci"),
-		remote.WithAuth(opts),
+		remote.WithTransport(util.NewConditionalTransport(transport, util.IsTagsListRequest, opts.ResponseTimeout)),
+	}
+
+	c := &Client{
+		log:      log.WithField("client", "OCI"),
+		Options:  opts,
+		pullOpts: pullOpts,
+	}
+
+	var authOpt remote.Option
+	if opts.Auth == nil && opts.Keychain != nil {
+		authOpt = remote.WithAuthFromKeychain(opts.Keychain)
+	} else {
+		authOpt = remote.WithAuth(opts)
 	}
-	if opts.Transporter != nil {
-		pullOpts = append(pullOpts, remote.WithTransport(opts.Transporter))
+
+	var err error
+	c.puller, err = c.newPuller(authOpt)
+	if err != nil {
+		return nil, err
 	}
 
-	puller, err := remote.NewPuller(pullOpts...)
+	return c, nil
+}
+
+func (c *Client) newPuller(authOpt remote.Option) (*remote.Puller, error) {
+	puller, err := remote.NewPuller(append(slices.Clip(c.pullOpts), authOpt)...)
 	if err != nil {
 		return nil, fmt.Errorf("creating puller: %w", err)
 	}
+	return puller, nil
+}
+
+// pullerFor returns the puller of requests made with the context. Requests
+// with a keychain, such as of a Pod's imagePullSecrets, use their own puller
+// preferring its credentials, so that they aren't shared with others.
+func (c *Client) pullerFor(ctx context.Context) (*remote.Puller, error) {
+	if util.ContextKeychain(ctx, nil) == nil {
+		return c.puller, nil
+	}
+
+	fallback := c.Keychain
+	if c.Auth != nil {
+		fallback = authKeychain{c.Options}
+	}
+	return c.newPuller(remote.WithAuthFromKeychain(util.ContextKeychain(ctx, fallback)))
+}
+
+// authKeychain is a Keychain resolving every registry to the Authenticator.
+type authKeychain struct {
+	authn.Authenticator
+}
 
-	return &Client{
-		puller:  puller,
-		log:     log.WithField("client", "OCI"),
-		Options: opts,
-	}, nil
+func (k authKeychain) Resolve(authn.Resource) (authn.Authenticator, error) {
+	return k.Authenticator, nil
 }
 
 // Name is the name of this client
@@ -77,20 +149,38 @@ func (c *Client) Tags(ctx context.Context, host, repo, image string) ([]api.Imag
 		return nil, fmt.Errorf("parsing registry host: %w", err)
 	}
 
-	bareTags, err := c.puller.List(ctx, reg.Repo(repo, image))
+	puller, err := c.pullerFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bareTags, err := puller.List(ctx, reg.Repo(repo, image))
 	if err != nil {
 		return nil, fmt.Errorf("listing tags: %w", err)
 	}
 	c.log.Infof("Collected %v tags..", len(bareTags))
-	return c.Manifests(ctx, reg.Repo(repo, image), bareTags)
+	return c.fetchManifests(ctx, puller, reg.Repo(repo, image), bareTags)
+}
+
+// Manifests fetches the manifest of each tag. Tags that were seen on the
+// previous call are only re-fetched if their digest has changed.
+func (c *Client) Manifests(ctx context.Context, repo name.Repository, tags []string) ([]api.ImageTag, error) {
+	puller, err := c.pullerFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.fetchManifests(ctx, puller, repo, tags)
 }
 
-func (c *Client) Manifests(ctx context.Context, repo name.Repository, tags []string) (fulltags []api.ImageTag, err error) {
+func (c *Client) fetchManifests(ctx context.Context, puller *remote.Puller, repo name.Repository, tags []string) (fulltags []api.ImageTag, err error) {
 	wg := sync.WaitGroup{}
 	sem := make(chan struct{}, numWorkers) // limit concurrent fetches
 	wg.Add(len(tags))
 	mu := sync.Mutex{}
 
+	known := c.knownManifests(repo.Name())
+	manifests := make(map[string]knownManifest, len(tags))
+
 	// Lets lookup all the child Manifests (where applicable)
 	for _, tag := range tags {
 		go func(repo name.Repository, tag string) {
@@ -106,8 +196,22 @@ func (c *Client) Manifests(ctx context.Context, repo name.Repository, tags []str
 				return
 			}
 
+			// Reuse the known manifest if its digest hasn't changed
+			if m, ok := known[tag]; ok {
+				desc, err := puller.Head(ctx, t)
+				if err != nil {
+					log.Debugf("revalidating manifest, fetching: %s", err)
+				} else if desc.Digest.String() == m.digest {
+					mu.Lock()
+					defer mu.Unlock()
+					fulltags = append(fulltags, m.tag)
+					manifests[tag] = m
+					return
+				}
+			}
+
 			// Fetch the manifest
-			manifest, err := c.puller.Get(ctx, t)
+			manifest, err := puller.Get(ctx, t)
 			if err != nil {
 				log.Errorf("getting manifest: %s", err)
 				return
@@ -143,12 +247,18 @@ func (c *Client) Manifests(ctx context.Context, repo name.Repository, tags []str
 					return
 				}
 				for _, img := range idxman.Manifests {
-
-					children = append(children, &api.ImageTag{
-						Tag: tag,
-						SHA: img.Digest.String(),
-					})
+					child := &api.ImageTag{
+						Tag:       tag,
+						SHA:       img.Digest.String(),
+						Timestamp: ts,
+					}
+					if img.Platform != nil {
+						child.OS = api.OS(img.Platform.OS)
+						child.Architecture = api.Architecture(img.Platform.Architecture)
+					}
+					children = append(children, child)
 				}
+				baseTag.SHA = manifest.Digest.String()
 				baseTag.Children = children
 
 			case types.OCIManifestSchema1, types.DockerManifestSchema2:
@@ -167,14 +277,45 @@ func (c *Client) Manifests(ctx context.Context, repo name.Repository, tags []str
 
 			// Add it to the full tags
 			fulltags = append(fulltags, baseTag)
+			manifests[tag] = knownManifest{digest: manifest.Digest.String(), tag: baseTag}
 		}(repo, tag)
 	}
 	// Wait for everything to complete!
 	wg.Wait()
 
+	c.setKnownManifests(repo.Name(), manifests)
+
 	return fulltags, err
 }
 
+// knownManifests returns the manifests of the repository seen on the last
+// call to Manifests.
+func (c *Client) knownManifests(repo string) map[string]knownManifest {
+	c.manifestsMu.Lock()
+	defer c.manifestsMu.Unlock()
+
+	if c.manifests == nil {
+		return nil
+	}
+	manifests, _ := c.manifests.Get(repo)
+	known, _ := manifests.(map[string]knownManifest)
+	return known
+}
+
+func (c *Client) setKnownManifests(repo string, manifests map[string]knownManifest) {
+	c.manifestsMu.Lock()
+	defer c.manifestsMu.Unlock()
+
+	if c.manifests == nil {
+		timeout := util.DefaultConditionalTimeout
+		if c.Options != nil && c.ResponseTimeout > 0 {
+			timeout = c.ResponseTimeout
+		}
+		c.manifests = cache.New(timeout, timeout)
+	}
+	c.manifests.SetDefault(repo, manifests)
+}
+
 // IsHost always returns true because it supports any host
 func (c *Client) IsHost(_ string) bool {
 	return true
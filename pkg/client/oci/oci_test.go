@@ -2,22 +2,32 @@ package oci
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
 )
 
 func TestClientTags(t *testing.T) {
@@ -106,6 +116,51 @@ func TestClientTags(t *testing.T) {
 			}
 			return tc
 		},
+		"should list the platforms of multi-arch indexes": func(t *testing.T, host string) *testCase {
+			amd64, err := random.Image(64, 1)
+			require.NoError(t, err)
+			arm64, err := random.Image(64, 1)
+			require.NoError(t, err)
+
+			idx := mutate.AppendManifests(empty.Index,
+				mutate.IndexAddendum{
+					Add:        amd64,
+					Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}},
+				},
+				mutate.IndexAddendum{
+					Add:        arm64,
+					Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "arm64"}},
+				},
+			)
+			idxSha, err := idx.Digest()
+			require.NoError(t, err)
+			amd64Sha, err := amd64.Digest()
+			require.NoError(t, err)
+			arm64Sha, err := arm64.Digest()
+			require.NoError(t, err)
+
+			tc := &testCase{
+				repo: "foo",
+				img:  "multiarch",
+				wantTags: []api.ImageTag{
+					{
+						Tag: "v1",
+						SHA: idxSha.String(),
+						Children: []*api.ImageTag{
+							{Tag: "v1", SHA: amd64Sha.String(), OS: "linux", Architecture: "amd64"},
+							{Tag: "v1", SHA: arm64Sha.String(), OS: "linux", Architecture: "arm64"},
+						},
+					},
+				},
+			}
+			repo, err := name.NewRepository(fmt.Sprintf("%s/%s/%s", host, tc.repo, tc.img))
+			require.NoError(t, err)
+
+			require.NoError(t,
+				remote.WriteIndex(repo.Tag("v1"), idx),
+			)
+			return tc
+		},
 		"should return an empty list and no error for a repository with no tags": func(t *testing.T, host string) *testCase {
 			tc := &testCase{
 				repo: "foo",
@@ -156,6 +211,130 @@ func TestClientTags(t *testing.T) {
 	}
 }
 
+func TestClientManifestsRevalidation(t *testing.T) {
+	ctx := context.Background()
+	host := setupRegistry(t)
+
+	repo, err := name.NewRepository(host + "/foo/bar")
+	require.NoError(t, err)
+	for _, tag := range []string{"a", "b"} {
+		require.NoError(t, remote.Write(repo.Tag(tag), empty.Image))
+	}
+
+	var gets, heads atomic.Int32
+	counter := func(req *http.Request) (*http.Response, error) {
+		if strings.Contains(req.URL.Path, "/manifests/") {
+			switch req.Method {
+			case http.MethodGet:
+				gets.Add(1)
+			case http.MethodHead:
+				heads.Add(1)
+			}
+		}
+		return http.DefaultTransport.RoundTrip(req)
+	}
+
+	c, err := New(&Options{Transporter: roundTripFunc(counter)}, logrus.NewEntry(logrus.New()))
+	require.NoError(t, err)
+
+	_, err = c.Tags(ctx, host, "foo", "bar")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), gets.Load())
+	assert.Equal(t, int32(0), heads.Load())
+
+	// Unchanged tags are not fetched again
+	_, err = c.Tags(ctx, host, "foo", "bar")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), gets.Load())
+	assert.Equal(t, int32(2), heads.Load())
+
+	// Changed tags are
+	img, err := random.Image(10, 1)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(repo.Tag("b"), img))
+	imgSha, err := img.Digest()
+	require.NoError(t, err)
+
+	tags, err := c.Tags(ctx, host, "foo", "bar")
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), gets.Load())
+	assert.Equal(t, int32(4), heads.Load())
+
+	emptySha, err := empty.Image.Digest()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []api.ImageTag{
+		{Tag: "a", SHA: emptySha.String()},
+		{Tag: "b", SHA: imgSha.String()},
+	}, tags)
+}
+
+func TestClientKnownManifestsExpire(t *testing.T) {
+	c := &Client{Options: &Options{ResponseTimeout: 50 * time.Millisecond}}
+
+	known := map[string]knownManifest{"v1": {digest: "sha:123", tag: api.ImageTag{Tag: "v1", SHA: "sha:123"}}}
+	c.setKnownManifests("localhost/repo/image", known)
+	assert.Equal(t, known, c.knownManifests("localhost/repo/image"))
+
+	// Repositories which aren't listed again are dropped after the timeout
+	assert.Eventually(t, func() bool {
+		return c.knownManifests("localhost/repo/image") == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestClientContextKeychain(t *testing.T) {
+	reg := registry.New(registry.Logger(log.New(io.Discard, "", log.LstdFlags)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "pod-user" || pass != "pod-password" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		reg.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	host := u.Host
+
+	repo, err := name.NewRepository(host + "/foo/bar")
+	require.NoError(t, err)
+	podAuth := remote.WithAuth(&authn.Basic{Username: "pod-user", Password: "pod-password"})
+	require.NoError(t, remote.Write(repo.Tag("a"), empty.Image, podAuth))
+
+	c, err := New(&Options{
+		Auth: &authn.AuthConfig{Username: "global-user", Password: "global-password"},
+	}, logrus.NewEntry(logrus.New()))
+	require.NoError(t, err)
+
+	_, err = c.Tags(context.Background(), host, "foo", "bar")
+	assert.Error(t, err, "expected the global credentials to be rejected")
+
+	keychain := authn.NewKeychainFromHelper(fakeHelper{host: {"pod-user", "pod-password"}})
+	ctx := util.ContextWithKeychain(context.Background(), keychain, "default/pod-secret")
+	tags, err := c.Tags(ctx, host, "foo", "bar")
+	require.NoError(t, err)
+
+	emptySha, err := empty.Image.Digest()
+	require.NoError(t, err)
+	assert.Equal(t, []api.ImageTag{{Tag: "a", SHA: emptySha.String()}}, tags)
+}
+
+type fakeHelper map[string][2]string
+
+func (f fakeHelper) Get(serverURL string) (string, string, error) {
+	creds, ok := f[serverURL]
+	if !ok {
+		return "", "", errors.New("credentials not found in native keychain")
+	}
+	return creds[0], creds[1], nil
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
 func TestClientRepoImageFromPath(t *testing.T) {
 	tests := map[string]struct {
 		path              string
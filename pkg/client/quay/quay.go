@@ -7,12 +7,10 @@ import (
 	"net/http"
 	"time"
 
-	"github.com/hashicorp/go-retryablehttp"
 	"github.com/sirupsen/logrus"
 
 	"github.com/jetstack/version-checker/pkg/api"
 	"github.com/jetstack/version-checker/pkg/client/util"
-	leveledlogger "github.com/jetstack/version-checker/pkg/leveledlogrus"
 )
 
 const (
@@ -26,7 +24,7 @@ type Options struct {
 }
 
 type Client struct {
-	*retryablehttp.Client
+	*http.Client
 	Options
 }
 
@@ -34,17 +32,14 @@ type Client struct {
 var _ api.ImageClient = (*Client)(nil)
 
 func New(opts Options, log *logrus.Entry) *Client {
-	client := retryablehttp.NewClient()
-	client.RetryMax = 10
-	client.Logger = log.WithField("client", "quay")
-	if opts.Transporter != nil {
-		client.HTTPClient.Transport = opts.Transporter
+	transport := opts.Transporter
+	if transport == nil {
+		transport = util.NewRetrier(log.WithField("client", "quay"), util.RetryOptions{}).RoundTripper(nil)
 	}
-	client.Logger = &leveledlogger.Logger{Entry: log.WithField("client", "quay")}
 
 	return &Client{
 		Options: opts,
-		Client:  client,
+		Client:  &http.Client{Transport: transport},
 	}
 }
 
@@ -127,9 +122,8 @@ func (c *Client) callManifests(ctx context.Context, timestamp time.Time, tag *ap
 }
 
 // makeRequest will make a call and write the response to the object.
-// Implements backoff.
 func (c *Client) makeRequest(ctx context.Context, url string, obj interface{}) error {
-	req, err := retryablehttp.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return err
 	}
@@ -139,7 +133,6 @@ func (c *Client) makeRequest(ctx context.Context, url string, obj interface{}) e
 	}
 
 	req.URL.Scheme = "https"
-	req = req.WithContext(ctx)
 
 	resp, err := c.Do(req)
 	if err != nil {
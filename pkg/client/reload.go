@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// defaultWatchDebounce is how long Watch waits for changes to files to
+// settle, as they are often written in several steps.
+const defaultWatchDebounce = time.Second
+
+// WatchedFiles returns the credential and CA files of the registry clients,
+// which the clients are rebuilt with when they change.
+func (c *Client) WatchedFiles() []string {
+	var files []string
+	for _, file := range []string{
+		c.opts.Files.DockerConfig,
+		c.opts.Files.DockerToken,
+		c.opts.Files.GHCRToken,
+		c.opts.Files.QuayToken,
+	} {
+		if len(file) > 0 {
+			files = append(files, file)
+		}
+	}
+	for _, sFiles := range c.opts.Files.Selfhosted {
+		for _, file := range []string{sFiles.Username, sFiles.Password, sFiles.Bearer} {
+			if len(file) > 0 {
+				files = append(files, file)
+			}
+		}
+	}
+	for _, sOpts := range c.opts.Selfhosted {
+		if len(sOpts.CAPath) > 0 {
+			files = append(files, sOpts.CAPath)
+		}
+	}
+
+	slices.Sort(files)
+	return slices.Compact(files)
+}
+
+// Watch reloads the registry clients when the contents of their credential
+// or CA files change, until the context is done. The directories of the
+// files are watched, rather than the files themselves, so that files which
+// are replaced are followed, such as Kubernetes' atomic updates of mounted
+// Secrets.
+func (c *Client) Watch(ctx context.Context) error {
+	files := c.WatchedFiles()
+	if len(files) == 0 {
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer func() { _ = watcher.Close() }()
+
+	var dirs []string
+	for _, file := range files {
+		dirs = append(dirs, filepath.Dir(file))
+	}
+	slices.Sort(dirs)
+	for _, dir := range slices.Compact(dirs) {
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("failed to watch %q: %w", dir, err)
+		}
+	}
+
+	log := c.log.WithField("files", files)
+	log.Info("watching registry credential files for changes")
+
+	sum := filesChecksum(files)
+
+	// The timer only fires after an event has reset it
+	timer := time.NewTimer(0)
+	if !timer.Stop() {
+		<-timer.C
+	}
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.WithError(err).Error("error watching registry credential files")
+
+		case _, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			timer.Reset(c.watchDebounce)
+
+		case <-timer.C:
+			newSum := filesChecksum(files)
+			if newSum == sum {
+				continue
+			}
+
+			if err := c.Reload(ctx); err != nil {
+				// The files may still be changing, so are checked again on
+				// their next event
+				log.WithError(err).Error("failed to reload registry clients, keeping the current clients")
+				continue
+			}
+			sum = newSum
+			log.Info("reloaded registry clients after credential files changed")
+		}
+	}
+}
+
+// filesChecksum returns a checksum of the contents of the files, which
+// changes when any of them do.
+func filesChecksum(files []string) [sha256.Size]byte {
+	h := sha256.New()
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			_, _ = fmt.Fprintf(h, "%s\x00missing\x00", file)
+		case err != nil:
+			_, _ = fmt.Fprintf(h, "%s\x00error\x00%s\x00", file, err)
+		default:
+			_, _ = fmt.Fprintf(h, "%s\x00%d\x00", file, len(data))
+			h.Write(data)
+		}
+	}
+
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/version-checker/pkg/client/quay"
+	"github.com/jetstack/version-checker/pkg/client/selfhosted"
+)
+
+// quayToken returns the token of the current Quay client.
+func quayToken(t *testing.T, c *Client) string {
+	t.Helper()
+	client, _, _, err := c.fromImageURL(context.Background(), "quay.io/jetstack/version-checker")
+	require.NoError(t, err)
+	quayClient, ok := client.(*quay.Client)
+	require.True(t, ok)
+	return quayClient.Token
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "quay-token")
+	configFile := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("token-1\n"), 0o600))
+	require.NoError(t, os.WriteFile(configFile, []byte(`{"auths":{}}`), 0o600))
+
+	c, err := New(context.Background(), logrus.NewEntry(logrus.New()), Options{
+		Files: CredentialFiles{DockerConfig: configFile, QuayToken: tokenFile},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", quayToken(t, c))
+
+	require.NoError(t, os.WriteFile(tokenFile, []byte("token-2\n"), 0o600))
+	require.NoError(t, c.Reload(context.Background()))
+	assert.Equal(t, "token-2", quayToken(t, c))
+
+	// The current clients are kept if the new ones fail to build
+	require.NoError(t, os.WriteFile(tokenFile, []byte("token-3\n"), 0o600))
+	require.NoError(t, os.WriteFile(configFile, []byte(`{`), 0o600))
+	assert.Error(t, c.Reload(context.Background()))
+	assert.Equal(t, "token-2", quayToken(t, c))
+}
+
+func TestReloadSelfhostedFiles(t *testing.T) {
+	dir := t.TempDir()
+	usernameFile := filepath.Join(dir, "username")
+	passwordFile := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(usernameFile, []byte("user\n"), 0o600))
+	require.NoError(t, os.WriteFile(passwordFile, []byte("password-1\n"), 0o600))
+
+	opts := Options{
+		Selfhosted: map[string]*selfhosted.Options{
+			"FOO": {Host: "https://registry.example.com", Password: "ignored"},
+		},
+		Files: CredentialFiles{Selfhosted: map[string]SelfhostedFiles{
+			"FOO": {Username: usernameFile, Password: passwordFile},
+		}},
+	}
+	require.NoError(t, readCredentialFiles(&opts))
+	assert.Equal(t, "user", opts.Selfhosted["FOO"].Username)
+	assert.Equal(t, "password-1", opts.Selfhosted["FOO"].Password)
+
+	// Files of registries which aren't configured are an error
+	opts.Files.Selfhosted["BAR"] = SelfhostedFiles{Bearer: passwordFile}
+	assert.EqualError(t, readCredentialFiles(&opts), `credential files given for unknown selfhosted registry "BAR"`)
+}
+
+func TestWatchedFiles(t *testing.T) {
+	c := &Client{opts: Options{
+		Files: CredentialFiles{
+			GHCRToken: "/secrets/ghcr",
+			QuayToken: "/secrets/quay",
+			Selfhosted: map[string]SelfhostedFiles{
+				"a": {Username: "/secrets/a/username", Password: "/secrets/a/password"},
+				"b": {Bearer: "/secrets/b/token"},
+			},
+		},
+		Selfhosted: map[string]*selfhosted.Options{
+			"a": {Host: "https://a.example.com"},
+			"b": {Host: "https://b.example.com", CAPath: "/secrets/ca.crt"},
+			"c": {Host: "https://c.example.com", CAPath: "/secrets/ca.crt"},
+		},
+	}}
+
+	assert.Equal(t, []string{
+		"/secrets/a/password", "/secrets/a/username", "/secrets/b/token",
+		"/secrets/ca.crt", "/secrets/ghcr", "/secrets/quay",
+	}, c.WatchedFiles())
+}
+
+func TestWatch(t *testing.T) {
+	t.Run("files written in place are reloaded", func(t *testing.T) {
+		tokenFile := filepath.Join(t.TempDir(), "quay-token")
+		require.NoError(t, os.WriteFile(tokenFile, []byte("token-1"), 0o600))
+
+		c := watchedClient(t, tokenFile)
+
+		require.NoError(t, os.WriteFile(tokenFile, []byte("token-2"), 0o600))
+		assert.Eventually(t, func() bool { return quayToken(t, c) == "token-2" }, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("mounted Secrets which are atomically swapped are reloaded", func(t *testing.T) {
+		// Kubernetes writes each version of a Secret to a new directory,
+		// and swaps the ..data symlink to it, which the files link through.
+		dir := t.TempDir()
+		writeVersion := func(version, token string) {
+			require.NoError(t, os.Mkdir(filepath.Join(dir, version), 0o700))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, version, "token"), []byte(token), 0o600))
+			require.NoError(t, os.Symlink(version, filepath.Join(dir, "..data_tmp")))
+			require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+		}
+		writeVersion("..v1", "token-1")
+		require.NoError(t, os.Symlink(filepath.Join("..data", "token"), filepath.Join(dir, "token")))
+
+		c := watchedClient(t, filepath.Join(dir, "token"))
+		assert.Equal(t, "token-1", quayToken(t, c))
+
+		writeVersion("..v2", "token-2")
+		assert.Eventually(t, func() bool { return quayToken(t, c) == "token-2" }, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+// watchedClient returns a Client with the Quay token file, which is watched
+// until the test ends.
+func watchedClient(t *testing.T, tokenFile string) *Client {
+	t.Helper()
+
+	c, err := New(context.Background(), logrus.NewEntry(logrus.New()), Options{
+		Files: CredentialFiles{QuayToken: tokenFile},
+	})
+	require.NoError(t, err)
+	c.watchDebounce = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- c.Watch(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	// Wait for the watch to start
+	time.Sleep(50 * time.Millisecond)
+
+	return c
+}
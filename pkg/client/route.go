@@ -0,0 +1,269 @@
+package client
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/fallback"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+// Names of the registry clients which routes and the registry-client
+// annotation may name.
+const (
+	ClientACR        = "acr"
+	ClientDocker     = "docker"
+	ClientECR        = "ecr"
+	ClientFallback   = "fallback"
+	ClientGCR        = "gcr"
+	ClientGHCR       = "ghcr"
+	ClientOCI        = "oci"
+	ClientQuay       = "quay"
+	ClientSelfhosted = "selfhosted"
+)
+
+// ClientNames are the names of every registry client.
+var ClientNames = []string{
+	ClientACR,
+	ClientDocker,
+	ClientECR,
+	ClientFallback,
+	ClientGCR,
+	ClientGHCR,
+	ClientOCI,
+	ClientQuay,
+	ClientSelfhosted,
+}
+
+// credentialClients are the registry clients which send the credentials
+// they are configured with to every host they look up, rather than those of
+// a keychain for the host.
+var credentialClients = []string{
+	ClientACR,
+	ClientDocker,
+	ClientECR,
+	ClientGCR,
+	ClientGHCR,
+	ClientQuay,
+}
+
+// Sources of the registry client of a host.
+const (
+	sourceAnnotation = "annotation"
+	sourceRoute      = "route"
+	sourceHost       = "host"
+	sourceFallback   = "fallback"
+)
+
+// Route routes the registry hosts matching a glob pattern to a registry
+// client, regardless of the hosts the client recognises.
+type Route struct {
+	Host   string `json:"host"`
+	Client string `json:"client"`
+}
+
+// HostRoute is the registry client which handles a host, and why.
+type HostRoute struct {
+	Host string `json:"host"`
+
+	// Client is the name of the client, and Source is one of annotation,
+	// route, host or fallback.
+	Client string `json:"client,omitempty"`
+	Source string `json:"source,omitempty"`
+
+	// FallbackClient is the client the fallback last succeeded with.
+	FallbackClient string `json:"fallbackClient,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// ParseRoutes parses routes of the form "<host glob>=<client>", e.g.
+// "harbor.corp=oci".
+func ParseRoutes(values []string) ([]Route, error) {
+	var routes []Route
+
+	for _, value := range values {
+		host, client, ok := strings.Cut(value, "=")
+		if !ok || len(host) == 0 {
+			return nil, fmt.Errorf("invalid registry route %q, must be of the form <host>=<client>", value)
+		}
+		if _, err := path.Match(host, ""); err != nil {
+			return nil, fmt.Errorf("invalid registry route host %q: %s", host, err)
+		}
+		if !slices.Contains(ClientNames, client) {
+			return nil, fmt.Errorf("invalid registry route %q, client must be one of %s",
+				value, strings.Join(ClientNames, ", "))
+		}
+
+		routes = append(routes, Route{Host: host, Client: client})
+	}
+
+	return routes, nil
+}
+
+// matches returns whether the route matches the host.
+func (r Route) matches(host string) bool {
+	ok, _ := path.Match(r.Host, host)
+	return ok
+}
+
+// clientForHost returns the registry client of the host, and its source. A
+// client named by the context takes precedence over the first matching
+// route, which takes precedence over the first client recognising the host.
+// Hosts matching none of them are looked up by the fallback client, unless
+// it is disabled.
+func (c *Client) clientForHost(ctx context.Context, regs *registries, host string) (api.ImageClient, string, error) {
+	if name := util.ContextRegistryClient(ctx); len(name) > 0 {
+		client, err := regs.named(name, host)
+		if err != nil {
+			return nil, sourceAnnotation, err
+		}
+		if !c.annotationAllowed(name, client, host) {
+			return nil, sourceAnnotation, fmt.Errorf("registry client %q has credentials, so only looks up the hosts "+
+				"it recognises or is routed, not %q", name, host)
+		}
+		return client, sourceAnnotation, nil
+	}
+
+	for _, route := range c.opts.Routes {
+		if route.matches(host) {
+			client, err := regs.named(route.Client, host)
+			return client, sourceRoute, err
+		}
+	}
+
+	for _, client := range regs.clients {
+		if client.IsHost(host) {
+			return client, sourceHost, nil
+		}
+	}
+
+	if c.opts.DisableFallback {
+		return nil, "", fmt.Errorf("no registry client for host %q, add a route for it", host)
+	}
+
+	return regs.fallbackClient, sourceFallback, nil
+}
+
+// annotationAllowed returns whether the registry client named by an
+// annotation may look up the host. Clients with credentials of their own
+// only look up the hosts they recognise or the first matching route names,
+// so that Pods can't send their credentials to other hosts.
+func (c *Client) annotationAllowed(name string, client api.ImageClient, host string) bool {
+	if !slices.Contains(credentialClients, name) || client.IsHost(host) {
+		return true
+	}
+
+	for _, route := range c.opts.Routes {
+		if route.matches(host) {
+			return route.Client == name
+		}
+	}
+
+	return false
+}
+
+// named returns the registry client of the name. Selfhosted is the
+// configured selfhosted client of the host, if any.
+func (r *registries) named(name, host string) (api.ImageClient, error) {
+	if name == ClientSelfhosted {
+		for _, client := range r.selfhosted {
+			if client.IsHost(host) {
+				return client, nil
+			}
+		}
+	}
+
+	client, ok := r.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown registry client %q, must be one of %s",
+			name, strings.Join(ClientNames, ", "))
+	}
+
+	return client, nil
+}
+
+// Route returns the registry client which handles the host.
+func (c *Client) Route(ctx context.Context, host string) HostRoute {
+	route := HostRoute{Host: host}
+
+	client, source, err := c.clientForHost(ctx, c.registries.Load(), host)
+	if err != nil {
+		route.Error = err.Error()
+		return route
+	}
+
+	route.Client = client.Name()
+	route.Source = source
+	if fallbackClient, ok := client.(*fallback.Client); ok {
+		route.FallbackClient, _ = fallbackClient.HostClient(host)
+	}
+
+	return route
+}
+
+// hostRouteKey is the key of the route of a host which was looked up.
+type hostRouteKey struct {
+	host, client, source string
+}
+
+// recordRoute records the route of a host which was looked up, keeping the
+// routes of hosts looked up by more than one client.
+func (c *Client) recordRoute(route HostRoute) {
+	c.hostsMu.Lock()
+	defer c.hostsMu.Unlock()
+
+	if c.hosts == nil {
+		c.hosts = make(map[hostRouteKey]HostRoute)
+	}
+	c.hosts[hostRouteKey{route.Host, route.Client, route.Source}] = route
+}
+
+// RoutesHandler returns a handler which shows the routes, and the registry
+// client of each host looked up so far, as JSON. The client which would
+// look up a host is shown with the "host" query parameter.
+func (c *Client) RoutesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		if r.URL.Query().Has("host") {
+			_ = enc.Encode(c.Route(r.Context(), r.URL.Query().Get("host")))
+			return
+		}
+
+		routes := struct {
+			Fallback bool        `json:"fallback"`
+			Routes   []Route     `json:"routes"`
+			Hosts    []HostRoute `json:"hosts"`
+		}{
+			Fallback: !c.opts.DisableFallback,
+			Routes:   c.opts.Routes,
+			Hosts:    []HostRoute{},
+		}
+
+		c.hostsMu.Lock()
+		for _, route := range c.hosts {
+			routes.Hosts = append(routes.Hosts, route)
+		}
+		c.hostsMu.Unlock()
+
+		slices.SortFunc(routes.Hosts, func(a, b HostRoute) int {
+			return cmp.Or(
+				strings.Compare(a.Host, b.Host),
+				strings.Compare(a.Client, b.Client),
+				strings.Compare(a.Source, b.Source),
+			)
+		})
+
+		_ = enc.Encode(routes)
+	})
+}
@@ -0,0 +1,222 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/docker"
+	"github.com/jetstack/version-checker/pkg/client/fallback"
+	"github.com/jetstack/version-checker/pkg/client/oci"
+	"github.com/jetstack/version-checker/pkg/client/quay"
+	"github.com/jetstack/version-checker/pkg/client/selfhosted"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+func TestParseRoutes(t *testing.T) {
+	tests := map[string]struct {
+		values    []string
+		expRoutes []Route
+		expErr    string
+	}{
+		"no routes": {},
+		"routes are kept in order": {
+			values: []string{"harbor.corp=oci", "*.corp=selfhosted"},
+			expRoutes: []Route{
+				{Host: "harbor.corp", Client: ClientOCI},
+				{Host: "*.corp", Client: ClientSelfhosted},
+			},
+		},
+		"missing client": {
+			values: []string{"harbor.corp"},
+			expErr: `invalid registry route "harbor.corp", must be of the form <host>=<client>`,
+		},
+		"invalid glob": {
+			values: []string{"[harbor.corp=oci"},
+			expErr: `invalid registry route host "[harbor.corp": syntax error in pattern`,
+		},
+		"unknown client": {
+			values: []string{"harbor.corp=harbor"},
+			expErr: `invalid registry route "harbor.corp=harbor", client must be one of acr, docker, ecr, fallback, gcr, ghcr, oci, quay, selfhosted`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			routes, err := ParseRoutes(test.values)
+			if len(test.expErr) > 0 {
+				assert.EqualError(t, err, test.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expRoutes, routes)
+		})
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	newClient := func(t *testing.T, disableFallback bool) *Client {
+		c, err := New(context.TODO(), logrus.NewEntry(logrus.New()), Options{
+			Selfhosted: map[string]*selfhosted.Options{
+				"harbor": {Host: "https://harbor.corp"},
+			},
+			Routes: []Route{
+				{Host: "quay.corp", Client: ClientQuay},
+				{Host: "mirror.corp", Client: ClientOCI},
+				{Host: "*.corp", Client: ClientSelfhosted},
+				{Host: "docker.io", Client: ClientFallback},
+			},
+			DisableFallback: disableFallback,
+		})
+		require.NoError(t, err)
+		return c
+	}
+
+	tests := map[string]struct {
+		imageURL        string
+		registryClient  string
+		disableFallback bool
+		expClient       api.ImageClient
+		expName         string
+		expErr          string
+	}{
+		"route takes precedence over recognised hosts": {
+			imageURL:  "docker.io/library/nginx",
+			expClient: new(fallback.Client),
+		},
+		"route to a client": {
+			imageURL:  "quay.corp/jetstack/version-checker",
+			expClient: new(quay.Client),
+		},
+		"route to selfhosted uses the configured client of the host": {
+			imageURL:  "harbor.corp/team/app",
+			expClient: new(selfhosted.Client),
+			expName:   "https://harbor.corp",
+		},
+		"route to selfhosted without a configured client": {
+			imageURL:  "nexus.corp/team/app",
+			expClient: new(selfhosted.Client),
+			expName:   "selfhosted",
+		},
+		"route to oci": {
+			imageURL:  "mirror.corp/team/app",
+			expClient: new(oci.Client),
+		},
+		"unmatched host uses the fallback": {
+			imageURL:  "registry.example.com/team/app",
+			expClient: new(fallback.Client),
+		},
+		"unmatched host fails without the fallback": {
+			imageURL:        "registry.example.com/team/app",
+			disableFallback: true,
+			expErr:          `no registry client for host "registry.example.com", add a route for it`,
+		},
+		"registry client of the context takes precedence": {
+			imageURL:       "docker.io/library/nginx",
+			registryClient: ClientDocker,
+			expClient:      new(docker.Client),
+		},
+		"registry client of the context with credentials may look up routed hosts": {
+			imageURL:       "quay.corp/jetstack/version-checker",
+			registryClient: ClientQuay,
+			expClient:      new(quay.Client),
+		},
+		"registry client of the context with credentials may not look up other hosts": {
+			imageURL:       "quay.corp/jetstack/version-checker",
+			registryClient: ClientDocker,
+			expErr:         `registry client "docker" has credentials, so only looks up the hosts it recognises or is routed, not "quay.corp"`,
+		},
+		"registry client of the context with credentials may not look up unrouted hosts": {
+			imageURL:       "attacker.example.com/team/app",
+			registryClient: ClientGCR,
+			expErr:         `registry client "gcr" has credentials, so only looks up the hosts it recognises or is routed, not "attacker.example.com"`,
+		},
+		"registry client of the context takes precedence over the disabled fallback": {
+			imageURL:        "registry.example.com/team/app",
+			registryClient:  ClientOCI,
+			disableFallback: true,
+			expClient:       new(oci.Client),
+		},
+		"unknown registry client of the context": {
+			imageURL:       "quay.corp/jetstack/version-checker",
+			registryClient: "harbor",
+			expErr:         `unknown registry client "harbor", must be one of acr, docker, ecr, fallback, gcr, ghcr, oci, quay, selfhosted`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := newClient(t, test.disableFallback)
+
+			ctx := context.TODO()
+			if len(test.registryClient) > 0 {
+				ctx = util.ContextWithRegistryClient(ctx, test.registryClient)
+			}
+
+			client, _, _, err := c.fromImageURL(ctx, test.imageURL)
+			if len(test.expErr) > 0 {
+				assert.EqualError(t, err, test.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.IsType(t, test.expClient, client)
+			if len(test.expName) > 0 {
+				assert.Equal(t, test.expName, client.Name())
+			}
+		})
+	}
+}
+
+func TestRoutesHandler(t *testing.T) {
+	c, err := New(context.TODO(), logrus.NewEntry(logrus.New()), Options{
+		Routes:          []Route{{Host: "harbor.corp", Client: ClientOCI}},
+		DisableFallback: true,
+	})
+	require.NoError(t, err)
+
+	c.recordRoute(c.Route(context.TODO(), "quay.io"))
+	c.recordRoute(c.Route(util.ContextWithRegistryClient(context.TODO(), ClientOCI), "quay.io"))
+	c.recordRoute(c.Route(context.TODO(), "harbor.corp"))
+
+	get := func(t *testing.T, target string, v interface{}) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		c.RoutesHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), v))
+	}
+
+	t.Run("routes and hosts looked up", func(t *testing.T) {
+		var routes struct {
+			Fallback bool
+			Routes   []Route
+			Hosts    []HostRoute
+		}
+		get(t, "/debug/registry-routes", &routes)
+
+		assert.False(t, routes.Fallback)
+		assert.Equal(t, []Route{{Host: "harbor.corp", Client: ClientOCI}}, routes.Routes)
+		assert.Equal(t, []HostRoute{
+			{Host: "harbor.corp", Client: "oci", Source: sourceRoute},
+			{Host: "quay.io", Client: "oci", Source: sourceAnnotation},
+			{Host: "quay.io", Client: "quay", Source: sourceHost},
+		}, routes.Hosts)
+	})
+
+	t.Run("single host", func(t *testing.T) {
+		var route HostRoute
+		get(t, "/debug/registry-routes?host=registry.example.com", &route)
+		assert.Equal(t, HostRoute{
+			Host:  "registry.example.com",
+			Error: `no registry client for host "registry.example.com", add a route for it`,
+		}, route)
+	})
+}
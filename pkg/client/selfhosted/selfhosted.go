@@ -9,14 +9,18 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/patrickmn/go-cache"
 	"github.com/sirupsen/logrus"
 
 	"github.com/go-chi/transport"
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/hashicorp/go-cleanhttp"
 
 	"github.com/jetstack/version-checker/pkg/api"
@@ -39,6 +43,8 @@ const (
 	dockerAPIv1Header       = "application/vnd.docker.distribution.manifest.v1+json"
 	dockerAPIv2Header       = "application/vnd.docker.distribution.manifest.v2+json"
 	dockerAPIv2ManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+
+	manifestAcceptHeader = dockerAPIv2Header + "," + dockerAPIv2ManifestList
 )
 
 type Options struct {
@@ -51,6 +57,22 @@ type Options struct {
 	TokenPath string
 	CAPath    string
 	Insecure  bool
+
+	// RateLimiter, if set, limits the requests to the registry. It is not
+	// needed when Transporter is already rate limited.
+	RateLimiter *util.RateLimiter
+
+	// Retrier, if set, retries failed requests to the registry. It is not
+	// needed when Transporter already retries.
+	Retrier *util.Retrier
+
+	// Keychain, if set, provides the credentials of the registry when none
+	// are given. Without a Host, it provides the credentials of each
+	// registry requested.
+	Keychain authn.Keychain
+
+	// ResponseTimeout is how long tag list responses are kept to revalidate.
+	ResponseTimeout time.Duration
 }
 type Client struct {
 	*http.Client
@@ -60,6 +82,19 @@ type Client struct {
 
 	hostRegex  *regexp.Regexp
 	httpScheme string
+
+	// manifests holds the manifests seen on the last call to Tags,
+	// keyed by repository and tag, so that unchanged tags aren't fetched
+	// again. Repositories are dropped once they haven't been listed for the
+	// ResponseTimeout, the same as their tag list responses.
+	manifestsMu sync.Mutex
+	manifests   *cache.Cache
+}
+
+// knownManifest is the tag built from a manifest, along with its digest.
+type knownManifest struct {
+	digest string
+	tag    api.ImageTag
 }
 
 func New(ctx context.Context, log *logrus.Entry, opts *Options) (*Client, error) {
@@ -103,6 +138,12 @@ func configureHost(ctx context.Context, client *Client, opts *Options) error {
 }
 
 func configureAuth(ctx context.Context, client *Client, opts *Options) error {
+	if len(opts.Username) == 0 && len(opts.Password) == 0 && len(opts.Bearer) == 0 {
+		if err := keychainAuth(opts); err != nil {
+			return err
+		}
+	}
+
 	if len(opts.Username) == 0 && len(opts.Password) == 0 {
 		return nil
 	}
@@ -131,6 +172,29 @@ func configureAuth(ctx context.Context, client *Client, opts *Options) error {
 	return nil
 }
 
+// keychainAuth sets the credentials of the registry from the Keychain.
+func keychainAuth(opts *Options) error {
+	u, err := url.Parse(opts.Host)
+	if err != nil {
+		return fmt.Errorf("failed parsing url: %s", err)
+	}
+
+	auth, err := util.Authorization(opts.Keychain, u.Host)
+	if err != nil {
+		return fmt.Errorf("failed to get credentials from keychain: %s", err)
+	}
+	if auth == nil {
+		return nil
+	}
+
+	if len(auth.RegistryToken) > 0 {
+		opts.Bearer = auth.RegistryToken
+	} else {
+		opts.Username, opts.Password = auth.Username, auth.Password
+	}
+	return nil
+}
+
 func configureTransport(client *Client, opts *Options) error {
 	if client.httpScheme == "" {
 		client.httpScheme = "https"
@@ -146,9 +210,22 @@ func configureTransport(client *Client, opts *Options) error {
 		baseTransport.TLSClientConfig = tlsConfig
 	}
 
+	// The first middleware is the outermost, so each retry waits for the
+	// rate limiter, and the Transporter replaces the base transport.
 	client.Transport = transport.Chain(baseTransport,
 		transport.If(logrus.IsLevelEnabled(logrus.DebugLevel), transport.LogRequests(transport.LogOptions{Concise: true})),
-		transport.If(opts.Transporter != nil, func(rt http.RoundTripper) http.RoundTripper { return opts.Transporter }))
+		func(rt http.RoundTripper) http.RoundTripper {
+			return util.NewConditionalTransport(rt, util.IsTagsListRequest, opts.ResponseTimeout)
+		},
+		transport.If(opts.Transporter == nil && opts.Retrier != nil, opts.Retrier.RoundTripper),
+		transport.If(opts.Transporter == nil && opts.RateLimiter != nil, opts.RateLimiter.RoundTripper),
+		transport.If(opts.Transporter != nil, func(rt http.RoundTripper) http.RoundTripper { return opts.Transporter }),
+	)
+
+	// Retries have their own timeout per attempt
+	if opts.Transporter != nil || opts.Retrier != nil {
+		client.Timeout = 0
+	}
 	return nil
 }
 
@@ -163,20 +240,34 @@ func (c *Client) Name() string {
 
 // Tags will fetch the image tags from a given image URL. It must first query
 // the tags that are available, then query the 2.1 and 2.2 API endpoints to
-// gather the image digest and created time.
+// gather the image digest and created time. Tags that were seen on the
+// previous call are only re-fetched if their digest has changed.
 func (c *Client) Tags(ctx context.Context, host, repo, image string) ([]api.ImageTag, error) {
 	path := util.JoinRepoImage(repo, image)
-	tagURL := fmt.Sprintf(tagsPath, host, path)
 
-	var tagResponse TagResponse
-	if _, err := c.doRequest(ctx, tagURL, "", &tagResponse); err != nil {
+	tagNames, err := c.listTags(ctx, host, path)
+	if err != nil {
 		return nil, err
 	}
 
+	known := c.knownManifests(host + "/" + path)
+	manifests := make(map[string]knownManifest, len(tagNames))
+
 	tags := map[string]api.ImageTag{}
-	for _, tag := range tagResponse.Tags {
+	for _, tag := range tagNames {
 		manifestURL := fmt.Sprintf(manifestPath, host, path, tag)
 
+		if m, ok := known[tag]; ok {
+			digest, err := c.manifestDigest(ctx, manifestURL)
+			if err != nil {
+				c.log.Debugf("%s: failed to revalidate manifest, fetching: %s", manifestURL, err)
+			} else if digest == m.digest {
+				tags[tag] = m.tag
+				manifests[tag] = m
+				continue
+			}
+		}
+
 		var manifestResponse ManifestResponse
 		_, err := c.doRequest(ctx, manifestURL, dockerAPIv1Header, &manifestResponse)
 
@@ -200,7 +291,7 @@ func (c *Client) Tags(ctx context.Context, host, repo, image string) ([]api.Imag
 		}
 
 		var manifestListResponse V2ManifestListResponse
-		header, err := c.doRequest(ctx, manifestURL, strings.Join([]string{dockerAPIv2Header, dockerAPIv2ManifestList}, ","), &manifestListResponse)
+		header, err := c.doRequest(ctx, manifestURL, manifestAcceptHeader, &manifestListResponse)
 		if httpErr, ok := selfhostederrors.IsHTTPError(err); ok {
 			c.log.Errorf("%s: failed to get manifest sha response for tag, skipping (%d): %s",
 				manifestURL, httpErr.StatusCode, httpErr.Body)
@@ -230,27 +321,95 @@ func (c *Client) Tags(ctx context.Context, host, repo, image string) ([]api.Imag
 
 			util.BuildTags(tags, tag, &current)
 		}
+
+		// Only remember tags we can revalidate by digest
+		if digest := header.Get("Docker-Content-Digest"); len(digest) > 0 {
+			manifests[tag] = knownManifest{digest: digest, tag: tags[tag]}
+		}
 	}
+
+	c.setKnownManifests(host+"/"+path, manifests)
+
 	return util.TagMaptoList(tags), nil
 }
 
-func (c *Client) doRequest(ctx context.Context, url, header string, obj interface{}) (http.Header, error) {
-	url = fmt.Sprintf("%s://%s", c.httpScheme, url)
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+// listTags returns all tag names of the repository, following the "next"
+// Link of each page. Pages are requested with the "last" marker the registry
+// returns. As tags are listed in lexical order, new tags may appear on any
+// page, so every page is revalidated rather than only those after the last
+// known tag.
+func (c *Client) listTags(ctx context.Context, host, path string) ([]string, error) {
+	var tags []string
+
+	seen := make(map[string]bool)
+	for tagURL := fmt.Sprintf(tagsPath, host, path); len(tagURL) > 0 && !seen[tagURL]; {
+		seen[tagURL] = true
+
+		var tagResponse TagResponse
+		header, err := c.doRequest(ctx, tagURL, "", &tagResponse)
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tagResponse.Tags...)
+		tagURL = nextLink(host, header)
+	}
+
+	return tags, nil
+}
+
+// manifestDigest returns the digest of the manifest, without fetching it.
+func (c *Client) manifestDigest(ctx context.Context, url string) (string, error) {
+	req, err := c.newRequest(ctx, http.MethodHead, url, manifestAcceptHeader)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	req.Header.Set("User-Agent", "version-checker/selfhosted")
 
-	req = req.WithContext(ctx)
-	if len(c.Bearer) > 0 {
-		req.Header.Add("Authorization", "Bearer "+c.Bearer)
-	} else if c.Username != "" && c.Password != "" {
-		req.SetBasicAuth(c.Username, c.Password)
+	resp, err := c.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get %q image: %s", c.Name(), err)
 	}
+	_ = resp.Body.Close()
 
-	if len(header) > 0 {
-		req.Header.Set("Accept", header)
+	if resp.StatusCode != http.StatusOK {
+		return "", selfhostederrors.NewHTTPError(resp.StatusCode, nil)
+	}
+
+	return resp.Header.Get("Docker-Content-Digest"), nil
+}
+
+// knownManifests returns the manifests of the repository seen on the last
+// call to Tags.
+func (c *Client) knownManifests(repo string) map[string]knownManifest {
+	c.manifestsMu.Lock()
+	defer c.manifestsMu.Unlock()
+
+	if c.manifests == nil {
+		return nil
+	}
+	manifests, _ := c.manifests.Get(repo)
+	known, _ := manifests.(map[string]knownManifest)
+	return known
+}
+
+func (c *Client) setKnownManifests(repo string, manifests map[string]knownManifest) {
+	c.manifestsMu.Lock()
+	defer c.manifestsMu.Unlock()
+
+	if c.manifests == nil {
+		timeout := util.DefaultConditionalTimeout
+		if c.Options != nil && c.ResponseTimeout > 0 {
+			timeout = c.ResponseTimeout
+		}
+		c.manifests = cache.New(timeout, timeout)
+	}
+	c.manifests.SetDefault(repo, manifests)
+}
+
+func (c *Client) doRequest(ctx context.Context, url, header string, obj interface{}) (http.Header, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, url, header)
+	if err != nil {
+		return nil, err
 	}
 
 	resp, err := c.Do(req)
@@ -270,12 +429,76 @@ func (c *Client) doRequest(ctx context.Context, url, header string, obj interfac
 	}
 
 	if err := json.Unmarshal(body, obj); err != nil {
-		return nil, fmt.Errorf("unexpected %s response: %s - %w", url, body, err)
+		return nil, fmt.Errorf("unexpected %s response: %s - %w", req.URL, body, err)
 	}
 
 	return resp.Header, nil
 }
 
+// newRequest returns an authenticated request to the URL, which is given
+// without scheme.
+func (c *Client) newRequest(ctx context.Context, method, url, header string) (*http.Request, error) {
+	url = fmt.Sprintf("%s://%s", c.httpScheme, url)
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "version-checker/selfhosted")
+
+	req = req.WithContext(ctx)
+
+	// The credentials of the request's keychain, e.g. of the Pod's
+	// imagePullSecrets, are preferred. Without a host, the credentials of
+	// the Keychain depend on the registry requested, otherwise they have
+	// already been applied.
+	keychain := util.ContextKeychain(ctx, nil)
+	if len(c.Host) == 0 {
+		keychain = util.ContextKeychain(ctx, c.Keychain)
+	}
+	auth, err := util.Authorization(keychain, req.URL.Host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credentials from keychain: %s", err)
+	}
+
+	switch {
+	case auth != nil && len(auth.RegistryToken) > 0:
+		req.Header.Add("Authorization", "Bearer "+auth.RegistryToken)
+	case auth != nil:
+		req.SetBasicAuth(auth.Username, auth.Password)
+	case len(c.Bearer) > 0:
+		req.Header.Add("Authorization", "Bearer "+c.Bearer)
+	case c.Username != "" && c.Password != "":
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	if len(header) > 0 {
+		req.Header.Set("Accept", header)
+	}
+
+	return req, nil
+}
+
+// nextLink returns the URL, without scheme, of the next page given in the
+// Link header, or an empty string if this is the last page.
+func nextLink(host string, header http.Header) string {
+	for _, link := range header.Values("Link") {
+		for _, value := range strings.Split(link, ",") {
+			parts := strings.Split(value, ";")
+			if len(parts) < 2 || !strings.Contains(parts[1], `rel="next"`) {
+				continue
+			}
+
+			next := strings.Trim(strings.TrimSpace(parts[0]), "<>")
+			if u, err := url.Parse(next); err == nil && u.IsAbs() {
+				return u.Host + u.RequestURI()
+			}
+			return host + next
+		}
+	}
+
+	return ""
+}
+
 func (c *Client) setupBasicAuth(ctx context.Context, url, tokenPath string) (string, error) {
 	upReader := strings.NewReader(
 		fmt.Sprintf(`{"username": "%s", "password": "%s"}`,
@@ -14,12 +14,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/jetstack/version-checker/pkg/api"
 	selfhostederrors "github.com/jetstack/version-checker/pkg/client/selfhosted/errors"
+	"github.com/jetstack/version-checker/pkg/client/util"
 )
 
 func TestNew(t *testing.T) {
@@ -47,6 +49,29 @@ func TestNew(t *testing.T) {
 		assert.Equal(t, "testtoken", client.Bearer)
 	})
 
+	t.Run("successful client creation with keychain credentials", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var creds map[string]string
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&creds))
+			assert.Equal(t, map[string]string{"username": "keychainuser", "password": "keychainpass"}, creds)
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"token":"testtoken"}`))
+		}))
+		defer server.Close()
+
+		h, err := url.Parse(server.URL)
+		require.NoError(t, err)
+
+		client, err := New(ctx, log, &Options{
+			Host:     server.URL,
+			Keychain: authn.NewKeychainFromHelper(fakeHelper{h.Host: {"keychainuser", "keychainpass"}}),
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "keychainuser", client.Username)
+		assert.Equal(t, "testtoken", client.Bearer)
+	})
+
 	t.Run("error on invalid URL", func(t *testing.T) {
 		opts := &Options{
 			Host: "://invalid-url",
@@ -241,6 +266,127 @@ func TestTags(t *testing.T) {
 	})
 }
 
+func TestTagsRevalidation(t *testing.T) {
+	log := logrus.NewEntry(logrus.New())
+	ctx := context.Background()
+
+	digests := map[string]string{"v1.0.0": "sha256:1", "v2.0.0": "sha256:2"}
+	var tagRequests, notModified, manifestGets, manifestHeads int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/repo/image/tags/list":
+			tagRequests++
+			etag := `"` + r.URL.Query().Get("last") + `"`
+			w.Header().Set("ETag", etag)
+			if r.Header.Get("If-None-Match") == etag {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			// Two pages, using the "last" marker
+			if r.URL.Query().Get("last") == "" {
+				w.Header().Set("Link", `</v2/repo/image/tags/list?n=500&last=v1.0.0>; rel="next"`)
+				_ = json.NewEncoder(w).Encode(TagResponse{Tags: []string{"v1.0.0"}})
+			} else {
+				_ = json.NewEncoder(w).Encode(TagResponse{Tags: []string{"v2.0.0"}})
+			}
+
+		case "/v2/repo/image/manifests/v1.0.0", "/v2/repo/image/manifests/v2.0.0":
+			tag := strings.TrimPrefix(r.URL.Path, "/v2/repo/image/manifests/")
+			w.Header().Set("Docker-Content-Digest", digests[tag])
+			if r.Method == http.MethodHead {
+				manifestHeads++
+				return
+			}
+
+			manifestGets++
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	h, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	client := &Client{
+		Client: &http.Client{
+			Transport: util.NewConditionalTransport(nil, util.IsTagsListRequest, 0),
+		},
+		log:        log,
+		Options:    &Options{},
+		httpScheme: "http",
+	}
+
+	tags, err := client.Tags(ctx, h.Host, "repo", "image")
+	require.NoError(t, err)
+	require.Len(t, tags, 2)
+	assert.Equal(t, 2, tagRequests)
+	assert.Equal(t, 0, notModified)
+	assert.Equal(t, 4, manifestGets)
+	assert.Equal(t, 0, manifestHeads)
+
+	// Unchanged tags are revalidated, without fetching their manifests
+	digests["v2.0.0"] = "sha256:22"
+	tags, err = client.Tags(ctx, h.Host, "repo", "image")
+	require.NoError(t, err)
+	require.Len(t, tags, 2)
+	assert.Equal(t, 4, tagRequests)
+	assert.Equal(t, 2, notModified)
+	assert.Equal(t, 6, manifestGets)
+	assert.Equal(t, 2, manifestHeads)
+
+	shas := map[string]string{}
+	for _, tag := range tags {
+		shas[tag.Tag] = tag.SHA
+	}
+	assert.Equal(t, map[string]string{"v1.0.0": "sha256:1", "v2.0.0": "sha256:22"}, shas)
+}
+
+func TestKnownManifestsExpire(t *testing.T) {
+	c := &Client{Options: &Options{ResponseTimeout: 50 * time.Millisecond}}
+
+	known := map[string]knownManifest{"v1": {digest: "sha:123", tag: api.ImageTag{Tag: "v1", SHA: "sha:123"}}}
+	c.setKnownManifests("localhost/repo/image", known)
+	assert.Equal(t, known, c.knownManifests("localhost/repo/image"))
+
+	// Repositories which aren't listed again are dropped after the timeout
+	assert.Eventually(t, func() bool {
+		return c.knownManifests("localhost/repo/image") == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestNextLink(t *testing.T) {
+	tests := map[string]struct {
+		link    []string
+		expNext string
+	}{
+		"no link": {
+			expNext: "",
+		},
+		"relative link": {
+			link:    []string{`</v2/repo/image/tags/list?n=500&last=v1>; rel="next"`},
+			expNext: "registry.io/v2/repo/image/tags/list?n=500&last=v1",
+		},
+		"absolute link": {
+			link:    []string{`<https://other.io/v2/repo/image/tags/list?last=v1>; rel="next"`},
+			expNext: "other.io/v2/repo/image/tags/list?last=v1",
+		},
+		"only next is followed": {
+			link:    []string{`</v2/repo/image/tags/list?last=v0>; rel="prev", </v2/repo/image/tags/list?last=v2>; rel="next"`},
+			expNext: "registry.io/v2/repo/image/tags/list?last=v2",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			header := http.Header{"Link": test.link}
+			assert.Equal(t, test.expNext, nextLink("registry.io", header))
+		})
+	}
+}
+
 func TestDoRequest(t *testing.T) {
 	log := logrus.NewEntry(logrus.New())
 	ctx := context.Background()
@@ -337,6 +483,80 @@ func TestDoRequest(t *testing.T) {
 		assert.NotNil(t, headers)
 		assert.Equal(t, []string{"v1", "v2"}, tagResponse.Tags)
 	})
+
+	t.Run("use keychain credentials of the requested host without a host", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			username, password, ok := r.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "keychainuser", username)
+			assert.Equal(t, "keychainpass", password)
+			_, _ = w.Write([]byte(`{"tags":["v1"]}`))
+		}))
+		defer server.Close()
+
+		h, err := url.Parse(server.URL)
+		require.NoError(t, err)
+
+		anonClient := &Client{
+			Client: &http.Client{},
+			Options: &Options{
+				Keychain: authn.NewKeychainFromHelper(fakeHelper{h.Host: {"keychainuser", "keychainpass"}}),
+			},
+			log:        log,
+			httpScheme: "http",
+		}
+
+		var tagResponse TagResponse
+		_, err = anonClient.doRequest(ctx, h.Host+"/v2/repo/image/tags/list", "", &tagResponse)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"v1"}, tagResponse.Tags)
+	})
+
+	t.Run("prefer credentials of the request keychain", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			username, password, ok := r.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "poduser", username)
+			assert.Equal(t, "podpass", password)
+			_, _ = w.Write([]byte(`{"tags":["v1"]}`))
+		}))
+		defer server.Close()
+
+		h, err := url.Parse(server.URL)
+		require.NoError(t, err)
+
+		hostClient := &Client{
+			Client: &http.Client{},
+			Options: &Options{
+				Host:     h.Host,
+				Username: "testuser",
+				Password: "testpass",
+			},
+			log:        log,
+			httpScheme: "http",
+		}
+
+		podCtx := util.ContextWithKeychain(ctx, authn.NewKeychainFromHelper(fakeHelper{h.Host: {"poduser", "podpass"}}), "default/pod-secret")
+
+		var tagResponse TagResponse
+		_, err = hostClient.doRequest(podCtx, h.Host+"/v2/repo/image/tags/list", "", &tagResponse)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"v1"}, tagResponse.Tags)
+	})
+}
+
+// fakeHelper is a credential helper of the given usernames and passwords,
+// keyed by registry host.
+type fakeHelper map[string][2]string
+
+func (f fakeHelper) Get(serverURL string) (string, string, error) {
+	creds, ok := f[serverURL]
+	if !ok {
+		return "", "", errors.New("credentials not found in native keychain")
+	}
+	return creds[0], creds[1], nil
 }
 
 func TestSetupBasicAuth(t *testing.T) {
@@ -0,0 +1,141 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// DefaultConditionalTimeout is how long responses are kept for revalidation
+// when no timeout is given.
+const DefaultConditionalTimeout = time.Hour
+
+// conditionalTransport makes requests conditional on the validators of the
+// last response to the same URL, answering 304 Not Modified responses from
+// the response body it already has.
+type conditionalTransport struct {
+	next  http.RoundTripper
+	match func(*http.Request) bool
+
+	// responses holds the last response to each URL until it hasn't been
+	// revalidated for the timeout, so that bodies aren't held forever.
+	timeout   time.Duration
+	responses *cache.Cache
+}
+
+// conditionalResponse is a cached response, along with its validators.
+type conditionalResponse struct {
+	etag         string
+	lastModified string
+	header       http.Header
+	body         []byte
+}
+
+// NewConditionalTransport returns a RoundTripper that revalidates GET
+// requests accepted by match with If-None-Match and If-Modified-Since,
+// using the ETag and Last-Modified headers of the previous response. When
+// the registry responds with 304 Not Modified, the previous response is
+// returned as a 200 instead, so that callers don't need to handle it.
+// Responses are dropped once they haven't been used for the timeout, which
+// should outlive the image cache entries built from them.
+func NewConditionalTransport(next http.RoundTripper, match func(*http.Request) bool, timeout time.Duration) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if timeout <= 0 {
+		timeout = DefaultConditionalTimeout
+	}
+
+	return &conditionalTransport{
+		next:      next,
+		match:     match,
+		timeout:   timeout,
+		responses: cache.New(timeout, timeout),
+	}
+}
+
+// IsTagsListRequest returns true if the request lists the tags of a
+// repository, using the Docker Registry HTTP API V2.
+func IsTagsListRequest(req *http.Request) bool {
+	return strings.HasSuffix(req.URL.Path, "/tags/list")
+}
+
+func (c *conditionalTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || !c.match(req) {
+		return c.next.RoundTrip(req)
+	}
+
+	key := req.URL.String() + " " + req.Header.Get("Accept")
+
+	var cached *conditionalResponse
+	if obj, ok := c.responses.Get(key); ok {
+		cached = obj.(*conditionalResponse)
+	}
+
+	if cached != nil {
+		req = req.Clone(req.Context())
+		if len(cached.etag) > 0 {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if len(cached.lastModified) > 0 {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	resp, err := c.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		_ = resp.Body.Close()
+		// Keep the response for as long as it is still being revalidated
+		c.responses.Set(key, cached, c.timeout)
+		return cached.response(req), nil
+
+	case resp.StatusCode == http.StatusOK:
+		etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+		if len(etag) == 0 && len(lastModified) == 0 {
+			return resp, nil
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		c.responses.Set(key, &conditionalResponse{
+			etag:         etag,
+			lastModified: lastModified,
+			header:       resp.Header.Clone(),
+			body:         body,
+		}, c.timeout)
+
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return resp, nil
+
+	default:
+		return resp, nil
+	}
+}
+
+// response returns the cached response to the given request.
+func (r *conditionalResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(r.body)),
+		ContentLength: int64(len(r.body)),
+		Request:       req,
+	}
+}
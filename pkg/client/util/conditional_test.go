@@ -0,0 +1,117 @@
+package util
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditionalTransport(t *testing.T) {
+	var requests, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		switch r.URL.Path {
+		case "/v2/repo/etag/tags/list":
+			w.Header().Set("ETag", `"abc"`)
+			if r.Header.Get("If-None-Match") == `"abc"` {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/v2/repo/last-modified/tags/list":
+			w.Header().Set("Last-Modified", "Wed, 01 Jan 2025 00:00:00 GMT")
+			if r.Header.Get("If-Modified-Since") == "Wed, 01 Jan 2025 00:00:00 GMT" {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/v2/repo/etag/manifests/v1":
+			w.Header().Set("ETag", `"abc"`)
+			assert.Empty(t, r.Header.Get("If-None-Match"))
+		}
+
+		_, _ = w.Write([]byte(`{"tags":["v1"]}`))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewConditionalTransport(nil, IsTagsListRequest, 0)}
+
+	tests := map[string]struct {
+		path           string
+		expNotModified int
+	}{
+		"ETag is revalidated": {
+			path:           "/v2/repo/etag/tags/list",
+			expNotModified: 2,
+		},
+		"Last-Modified is revalidated": {
+			path:           "/v2/repo/last-modified/tags/list",
+			expNotModified: 2,
+		},
+		"response without validators is not cached": {
+			path:           "/v2/repo/none/tags/list",
+			expNotModified: 0,
+		},
+		"requests not matched are not made conditional": {
+			path:           "/v2/repo/etag/manifests/v1",
+			expNotModified: 0,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			requests, notModified = 0, 0
+
+			for i := 0; i < 3; i++ {
+				resp, err := client.Get(server.URL + test.path)
+				require.NoError(t, err)
+
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				_ = resp.Body.Close()
+
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Equal(t, `{"tags":["v1"]}`, string(body))
+			}
+
+			assert.Equal(t, 3, requests)
+			assert.Equal(t, test.expNotModified, notModified)
+		})
+	}
+}
+
+func TestConditionalTransportTimeout(t *testing.T) {
+	var notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"abc"`)
+		if r.Header.Get("If-None-Match") == `"abc"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte(`{"tags":["v1"]}`))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewConditionalTransport(nil, IsTagsListRequest, 50*time.Millisecond)}
+	get := func() {
+		resp, err := client.Get(server.URL + "/v2/repo/tags/list")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	get()
+	get()
+	assert.Equal(t, 1, notModified, "response should be revalidated")
+
+	time.Sleep(100 * time.Millisecond)
+	get()
+	assert.Equal(t, 1, notModified, "expired response should not be revalidated")
+}
@@ -0,0 +1,112 @@
+package util
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// Authorization returns the credentials of the registry host in the
+// keychain, or nil if there is no keychain or it has no credentials for the
+// host.
+func Authorization(keychain authn.Keychain, host string) (*authn.AuthConfig, error) {
+	if keychain == nil {
+		return nil, nil
+	}
+
+	reg, err := name.NewRegistry(host)
+	if err != nil {
+		return nil, fmt.Errorf("parsing registry host: %w", err)
+	}
+
+	auth, err := keychain.Resolve(reg)
+	if err != nil {
+		return nil, err
+	}
+	if auth == authn.Anonymous {
+		return nil, nil
+	}
+
+	return auth.Authorization()
+}
+
+// keychainKey is the context key of a request's Keychain.
+type keychainKey struct{}
+
+// contextKeychain is the keychain of a context, along with the identity of
+// its credentials.
+type contextKeychain struct {
+	keychain authn.Keychain
+	id       string
+}
+
+// ContextWithKeychain returns a copy of the context carrying the keychain,
+// such as that of a Pod's imagePullSecrets. Clients prefer its credentials
+// over their own Keychain for requests made with the context. The id
+// identifies the credentials, such as the Secrets they were resolved from,
+// so that results looked up with them are only shared with requests made
+// with the same credentials.
+func ContextWithKeychain(ctx context.Context, keychain authn.Keychain, id string) context.Context {
+	return context.WithValue(ctx, keychainKey{}, contextKeychain{keychain: keychain, id: id})
+}
+
+// ContextKeychain returns the keychain of the context, falling back to the
+// given keychain for registries it has no credentials for. It is nil if
+// neither is set.
+func ContextKeychain(ctx context.Context, fallback authn.Keychain) authn.Keychain {
+	value, _ := ctx.Value(keychainKey{}).(contextKeychain)
+	keychain := value.keychain
+	switch {
+	case keychain == nil:
+		return fallback
+	case fallback == nil:
+		return keychain
+	default:
+		return authn.NewMultiKeychain(keychain, fallback)
+	}
+}
+
+// ContextCacheIndex returns the cache index of results looked up with the
+// context, which includes the identity of the keychain and the registry
+// client of the context, if it has them.
+func ContextCacheIndex(ctx context.Context, index string) string {
+	if keychain, _ := ctx.Value(keychainKey{}).(contextKeychain); keychain.keychain != nil {
+		index += "#" + keychain.id
+	}
+	if name := ContextRegistryClient(ctx); len(name) > 0 {
+		index += "#client=" + name
+	}
+	return index
+}
+
+// registryClientKey is the context key of a request's registry client.
+type registryClientKey struct{}
+
+// ContextWithRegistryClient returns a copy of the context naming the
+// registry client which requests made with it use, such as that of a
+// container's annotation, regardless of the routes of their hosts.
+func ContextWithRegistryClient(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, registryClientKey{}, name)
+}
+
+// ContextRegistryClient returns the name of the registry client of the
+// context, or an empty string if it has none.
+func ContextRegistryClient(ctx context.Context) string {
+	name, _ := ctx.Value(registryClientKey{}).(string)
+	return name
+}
+
+// HostKeychain is a Keychain of the credentials of each registry host, such
+// as those of a configuration file.
+type HostKeychain map[string]authn.AuthConfig
+
+// Resolve returns the credentials of the resource's registry host, or
+// Anonymous if it has none.
+func (k HostKeychain) Resolve(target authn.Resource) (authn.Authenticator, error) {
+	if auth, ok := k[target.RegistryStr()]; ok {
+		return authn.FromConfig(auth), nil
+	}
+	return authn.Anonymous, nil
+}
@@ -0,0 +1,155 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeHelper map[string][2]string
+
+func (f fakeHelper) Get(serverURL string) (string, string, error) {
+	creds, ok := f[serverURL]
+	if !ok {
+		return "", "", errors.New("credentials not found in native keychain")
+	}
+	return creds[0], creds[1], nil
+}
+
+func TestAuthorization(t *testing.T) {
+	keychain := authn.NewKeychainFromHelper(fakeHelper{
+		"registry.example.com": {"user", "password"},
+	})
+
+	tests := map[string]struct {
+		keychain authn.Keychain
+		host     string
+		expAuth  *authn.AuthConfig
+		expErr   bool
+	}{
+		"no keychain": {
+			host: "registry.example.com",
+		},
+		"credentials": {
+			keychain: keychain,
+			host:     "registry.example.com",
+			expAuth:  &authn.AuthConfig{Username: "user", Password: "password"},
+		},
+		"no credentials": {
+			keychain: keychain,
+			host:     "quay.io",
+		},
+		"host keychain": {
+			keychain: HostKeychain{"registry.example.com": {Username: "host-user", Password: "host-password"}},
+			host:     "registry.example.com",
+			expAuth:  &authn.AuthConfig{Username: "host-user", Password: "host-password"},
+		},
+		"host keychain without credentials": {
+			keychain: HostKeychain{"registry.example.com": {Username: "host-user", Password: "host-password"}},
+			host:     "quay.io",
+		},
+		"invalid host": {
+			keychain: keychain,
+			host:     "in valid",
+			expErr:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			auth, err := Authorization(test.keychain, test.host)
+			if test.expErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expAuth, auth)
+		})
+	}
+}
+
+func TestContextKeychain(t *testing.T) {
+	global := authn.NewKeychainFromHelper(fakeHelper{
+		"registry.example.com": {"global-user", "global-password"},
+		"quay.io":              {"quay-user", "quay-password"},
+	})
+	pod := authn.NewKeychainFromHelper(fakeHelper{
+		"registry.example.com": {"pod-user", "pod-password"},
+	})
+
+	tests := map[string]struct {
+		ctx      context.Context
+		fallback authn.Keychain
+		host     string
+		expAuth  *authn.AuthConfig
+	}{
+		"neither keychain": {
+			ctx:  context.Background(),
+			host: "registry.example.com",
+		},
+		"fallback keychain": {
+			ctx:      context.Background(),
+			fallback: global,
+			host:     "registry.example.com",
+			expAuth:  &authn.AuthConfig{Username: "global-user", Password: "global-password"},
+		},
+		"context keychain": {
+			ctx:     ContextWithKeychain(context.Background(), pod, "default/pod-secret"),
+			host:    "registry.example.com",
+			expAuth: &authn.AuthConfig{Username: "pod-user", Password: "pod-password"},
+		},
+		"context keychain is preferred": {
+			ctx:      ContextWithKeychain(context.Background(), pod, "default/pod-secret"),
+			fallback: global,
+			host:     "registry.example.com",
+			expAuth:  &authn.AuthConfig{Username: "pod-user", Password: "pod-password"},
+		},
+		"fallback for hosts without context credentials": {
+			ctx:      ContextWithKeychain(context.Background(), pod, "default/pod-secret"),
+			fallback: global,
+			host:     "quay.io",
+			expAuth:  &authn.AuthConfig{Username: "quay-user", Password: "quay-password"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			auth, err := Authorization(ContextKeychain(test.ctx, test.fallback), test.host)
+			require.NoError(t, err)
+			assert.Equal(t, test.expAuth, auth)
+		})
+	}
+}
+
+func TestContextCacheIndex(t *testing.T) {
+	keychain := HostKeychain{"registry.example.com": {Username: "pod-user"}}
+
+	tests := map[string]struct {
+		ctx      context.Context
+		expIndex string
+	}{
+		"without a keychain the index is unchanged": {
+			ctx:      context.Background(),
+			expIndex: "registry.example.com/app",
+		},
+		"the keychain identity is part of the index": {
+			ctx:      ContextWithKeychain(context.Background(), keychain, "default/pod-secret"),
+			expIndex: "registry.example.com/app#default/pod-secret",
+		},
+		"the registry client is part of the index": {
+			ctx: ContextWithRegistryClient(
+				ContextWithKeychain(context.Background(), keychain, "default/pod-secret"), "oci"),
+			expIndex: "registry.example.com/app#default/pod-secret#client=oci",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expIndex, ContextCacheIndex(test.ctx, "registry.example.com/app"))
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package util
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RegistryRateLimit is a request rate budget for the registry hosts matching
+// a glob pattern. Each matching host has its own budget.
+type RegistryRateLimit struct {
+	// Host is the glob pattern of the registry hosts, e.g. "*.azurecr.io".
+	Host string
+	// Requests is the number of requests allowed per Interval.
+	Requests int
+	Interval time.Duration
+}
+
+// RateLimitObserver observes how long requests waited for the rate limiter.
+type RateLimitObserver interface {
+	RegistryRateLimitWait(host string, wait time.Duration)
+}
+
+// RateLimiter limits the request rate and concurrency of requests to each
+// registry host.
+type RateLimiter struct {
+	limits         []RegistryRateLimit
+	maxConcurrency int
+	observer       RateLimitObserver
+
+	mu    sync.Mutex
+	hosts map[string]*hostLimiter
+}
+
+// hostLimiter is the budget of a single registry host.
+type hostLimiter struct {
+	limiter *rate.Limiter
+	sem     chan struct{}
+}
+
+// ParseRegistryRateLimits parses rate limits of the form
+// "<host glob>=<requests>/<s|m|h>", e.g. "quay.io=100/m".
+func ParseRegistryRateLimits(values []string) ([]RegistryRateLimit, error) {
+	var limits []RegistryRateLimit
+
+	for _, value := range values {
+		host, budget, ok := strings.Cut(value, "=")
+		if !ok || len(host) == 0 {
+			return nil, fmt.Errorf("invalid registry rate limit %q, must be of the form <host>=<requests>/<s|m|h>", value)
+		}
+		if _, err := path.Match(host, ""); err != nil {
+			return nil, fmt.Errorf("invalid registry rate limit host %q: %s", host, err)
+		}
+
+		count, unit, ok := strings.Cut(budget, "/")
+		if !ok {
+			return nil, fmt.Errorf("invalid registry rate limit %q, must be of the form <host>=<requests>/<s|m|h>", value)
+		}
+
+		requests, err := strconv.Atoi(count)
+		if err != nil || requests <= 0 {
+			return nil, fmt.Errorf("invalid registry rate limit %q, requests must be a positive integer", value)
+		}
+
+		var interval time.Duration
+		switch unit {
+		case "s":
+			interval = time.Second
+		case "m":
+			interval = time.Minute
+		case "h":
+			interval = time.Hour
+		default:
+			return nil, fmt.Errorf("invalid registry rate limit %q, unit must be one of s, m or h", value)
+		}
+
+		limits = append(limits, RegistryRateLimit{
+			Host:     host,
+			Requests: requests,
+			Interval: interval,
+		})
+	}
+
+	return limits, nil
+}
+
+// NewRateLimiter returns a RateLimiter for the given rate limits, which are
+// matched against hosts in order. Hosts that don't match a limit have no
+// rate limit. If maxConcurrency is greater than zero, it is the maximum
+// number of requests to each host that are waiting on their response. The
+// observer is optional.
+func NewRateLimiter(limits []RegistryRateLimit, maxConcurrency int, observer RateLimitObserver) *RateLimiter {
+	return &RateLimiter{
+		limits:         limits,
+		maxConcurrency: maxConcurrency,
+		observer:       observer,
+		hosts:          make(map[string]*hostLimiter),
+	}
+}
+
+// RoundTripper returns a RoundTripper which waits for the budget of the
+// request's host before making it.
+func (r *RateLimiter) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		host := req.URL.Host
+		l := r.host(host)
+
+		start := time.Now()
+		if l.sem != nil {
+			select {
+			case l.sem <- struct{}{}:
+				defer func() { <-l.sem }()
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			}
+		}
+		if l.limiter != nil {
+			if err := l.limiter.Wait(req.Context()); err != nil {
+				return nil, fmt.Errorf("waiting for %s rate limit: %w", host, err)
+			}
+		}
+		if r.observer != nil {
+			r.observer.RegistryRateLimitWait(host, time.Since(start))
+		}
+
+		return next.RoundTrip(req)
+	})
+}
+
+// host returns the budget of the host, creating it on first use.
+func (r *RateLimiter) host(host string) *hostLimiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if l, ok := r.hosts[host]; ok {
+		return l
+	}
+
+	l := new(hostLimiter)
+	if r.maxConcurrency > 0 {
+		l.sem = make(chan struct{}, r.maxConcurrency)
+	}
+
+	// Match the hostname without port, as well as the full host
+	hostname, _, _ := strings.Cut(host, ":")
+	for _, limit := range r.limits {
+		if matchHost(limit.Host, host) || matchHost(limit.Host, hostname) {
+			l.limiter = rate.NewLimiter(
+				rate.Every(limit.Interval/time.Duration(limit.Requests)),
+				limit.Requests,
+			)
+			break
+		}
+	}
+
+	r.hosts[host] = l
+	return l
+}
+
+func matchHost(pattern, host string) bool {
+	ok, _ := path.Match(pattern, host)
+	return ok
+}
+
+// roundTripperFunc is an adapter to allow the use of ordinary functions as
+// http.RoundTrippers.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
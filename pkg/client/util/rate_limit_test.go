@@ -0,0 +1,157 @@
+package util
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRegistryRateLimits(t *testing.T) {
+	tests := map[string]struct {
+		values    []string
+		expLimits []RegistryRateLimit
+		expErr    bool
+	}{
+		"no limits": {},
+		"multiple limits": {
+			values: []string{"quay.io=100/m", "*.azurecr.io=20/s", "*=1000/h"},
+			expLimits: []RegistryRateLimit{
+				{Host: "quay.io", Requests: 100, Interval: time.Minute},
+				{Host: "*.azurecr.io", Requests: 20, Interval: time.Second},
+				{Host: "*", Requests: 1000, Interval: time.Hour},
+			},
+		},
+		"missing host":       {values: []string{"=100/m"}, expErr: true},
+		"missing budget":     {values: []string{"quay.io"}, expErr: true},
+		"missing unit":       {values: []string{"quay.io=100"}, expErr: true},
+		"invalid unit":       {values: []string{"quay.io=100/d"}, expErr: true},
+		"invalid requests":   {values: []string{"quay.io=lots/m"}, expErr: true},
+		"zero requests":      {values: []string{"quay.io=0/m"}, expErr: true},
+		"invalid host glob":  {values: []string{"[quay.io=1/m"}, expErr: true},
+		"one invalid of two": {values: []string{"quay.io=1/m", "gcr.io"}, expErr: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			limits, err := ParseRegistryRateLimits(test.values)
+			if test.expErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expLimits, limits)
+		})
+	}
+}
+
+type fakeObserver struct {
+	mu    sync.Mutex
+	waits map[string][]time.Duration
+}
+
+func (f *fakeObserver) RegistryRateLimitWait(host string, wait time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.waits == nil {
+		f.waits = make(map[string][]time.Duration)
+	}
+	f.waits[host] = append(f.waits[host], wait)
+}
+
+func TestRateLimiter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	t.Run("requests past the burst wait for the rate limit", func(t *testing.T) {
+		observer := new(fakeObserver)
+		limiter := NewRateLimiter([]RegistryRateLimit{
+			{Host: "127.0.0.1", Requests: 2, Interval: 200 * time.Millisecond},
+		}, 0, observer)
+		client := &http.Client{Transport: limiter.RoundTripper(nil)}
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			resp, err := client.Get(server.URL)
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+		}
+		assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+
+		waits := observer.waits[server.Listener.Addr().String()]
+		require.Len(t, waits, 3)
+		assert.Less(t, waits[0], 50*time.Millisecond)
+		assert.GreaterOrEqual(t, waits[2], 50*time.Millisecond)
+	})
+
+	t.Run("hosts without a matching limit are not limited", func(t *testing.T) {
+		limiter := NewRateLimiter([]RegistryRateLimit{
+			{Host: "*.azurecr.io", Requests: 1, Interval: time.Hour},
+		}, 0, nil)
+		client := &http.Client{Transport: limiter.RoundTripper(nil)}
+
+		for i := 0; i < 3; i++ {
+			resp, err := client.Get(server.URL)
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+		}
+	})
+
+	t.Run("waiting is cancelled with the request", func(t *testing.T) {
+		limiter := NewRateLimiter([]RegistryRateLimit{
+			{Host: "127.0.0.1", Requests: 1, Interval: time.Hour},
+		}, 0, nil)
+		client := &http.Client{Transport: limiter.RoundTripper(nil)}
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		_, err = client.Do(req)
+		assert.Error(t, err)
+	})
+
+	t.Run("concurrency is limited per host", func(t *testing.T) {
+		var inFlight, maxInFlight atomic.Int32
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				m := maxInFlight.Load()
+				if n <= m || maxInFlight.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+		}))
+		defer slow.Close()
+
+		limiter := NewRateLimiter(nil, 2, nil)
+		client := &http.Client{Transport: limiter.RoundTripper(nil)}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := client.Get(slow.URL)
+				if assert.NoError(t, err) {
+					_ = resp.Body.Close()
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(2), maxInFlight.Load())
+	})
+}
@@ -0,0 +1,381 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Defaults of RetryOptions.
+const (
+	defaultMaxRetries     = 5
+	defaultMinWait        = time.Second
+	defaultMaxWait        = time.Minute
+	defaultAttemptTimeout = 10 * time.Second
+	defaultSlowDownBelow  = 10
+)
+
+// QuotaObserver observes the remaining request quota that registries report.
+type QuotaObserver interface {
+	RegistryRateLimitRemaining(host string, remaining int)
+}
+
+// RetryOptions configure a Retrier. Zero values are replaced with defaults.
+type RetryOptions struct {
+	// MaxRetries is the number of times a failed request is retried.
+	MaxRetries int
+
+	// MinWait and MaxWait bound the exponential backoff between retries.
+	// Requests which the registry asks to wait longer than MaxWait for,
+	// using Retry-After or an exhausted quota, fail rather than wait.
+	MinWait time.Duration
+	MaxWait time.Duration
+
+	// AttemptTimeout is the timeout of each attempt of a request, including
+	// reading its response body.
+	AttemptTimeout time.Duration
+
+	// SlowDownBelow is the remaining quota below which requests to a
+	// registry are spread out evenly until its quota resets, rather than
+	// being made as fast as possible until they are rejected.
+	SlowDownBelow int
+
+	// Observer is the optional QuotaObserver of the remaining quota.
+	Observer QuotaObserver
+}
+
+// Retrier retries failed requests to registries, honouring the Retry-After
+// header of responses. It tracks the quota that registries report with the
+// RateLimit-Remaining and RateLimit-Reset headers, such as Docker Hub, and
+// slows requests down before the quota is exhausted.
+type Retrier struct {
+	log  *logrus.Entry
+	opts RetryOptions
+
+	mu     sync.Mutex
+	quotas map[string]*quota
+}
+
+// quota is the request quota of a single registry host.
+type quota struct {
+	remaining int
+	reset     time.Time
+
+	// next is when the next request may be made, when slowed down.
+	next time.Time
+}
+
+// NewRetrier returns a Retrier configured with the given options.
+func NewRetrier(log *logrus.Entry, opts RetryOptions) *Retrier {
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = defaultMaxRetries
+	}
+	if opts.MinWait == 0 {
+		opts.MinWait = defaultMinWait
+	}
+	if opts.MaxWait == 0 {
+		opts.MaxWait = defaultMaxWait
+	}
+	if opts.AttemptTimeout == 0 {
+		opts.AttemptTimeout = defaultAttemptTimeout
+	}
+	if opts.SlowDownBelow == 0 {
+		opts.SlowDownBelow = defaultSlowDownBelow
+	}
+
+	return &Retrier{
+		log:    log.WithField("component", "retrier"),
+		opts:   opts,
+		quotas: make(map[string]*quota),
+	}
+}
+
+// RoundTripper returns a RoundTripper which retries requests made with next.
+// Connection errors, 429 Too Many Requests and 5xx responses are retried.
+func (r *Retrier) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		ctx := req.Context()
+		host := req.URL.Host
+
+		for attempt := 0; ; attempt++ {
+			if err := r.waitQuota(ctx, host); err != nil {
+				return nil, err
+			}
+
+			attemptReq, err := rewindRequest(req, attempt)
+			if err != nil {
+				return nil, err
+			}
+
+			attemptCtx, cancel := context.WithTimeout(ctx, r.opts.AttemptTimeout)
+			resp, err := next.RoundTrip(attemptReq.WithContext(attemptCtx))
+			if resp != nil {
+				r.observe(host, resp)
+			}
+
+			wait, retry := r.retryWait(ctx, req, resp, err, attempt)
+			if !retry {
+				if err != nil {
+					cancel()
+					return nil, err
+				}
+				// The attempt's timeout applies until the body is closed
+				resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+				return resp, nil
+			}
+
+			if resp != nil {
+				_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+				_ = resp.Body.Close()
+			}
+			cancel()
+
+			r.log.WithFields(logrus.Fields{
+				"host": host, "attempt": attempt + 1, "wait": wait,
+			}).Debugf("retrying request: %s", retryReason(resp, err))
+
+			if err := sleep(ctx, wait); err != nil {
+				return nil, err
+			}
+		}
+	})
+}
+
+// retryWait returns how long to wait before retrying the request, and
+// whether it should be retried at all.
+func (r *Retrier) retryWait(ctx context.Context, req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= r.opts.MaxRetries || ctx.Err() != nil {
+		return 0, false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return 0, false
+	}
+
+	if err == nil {
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests:
+		case resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented:
+		default:
+			return 0, false
+		}
+
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			if wait > r.opts.MaxWait {
+				return 0, false
+			}
+			r.exhaust(req.URL.Host, wait)
+			return wait, true
+		}
+	}
+
+	return HTTPBackOff(r.opts.MinWait, r.opts.MaxWait, attempt, resp), true
+}
+
+// waitQuota waits for the host's quota. Requests are spread out until the
+// quota resets when it is running low, and wait for the reset when it is
+// exhausted.
+func (r *Retrier) waitQuota(ctx context.Context, host string) error {
+	r.mu.Lock()
+	q, ok := r.quotas[host]
+	now := time.Now()
+	if !ok || !now.Before(q.reset) {
+		r.mu.Unlock()
+		return nil
+	}
+
+	var wait time.Duration
+	switch untilReset := q.reset.Sub(now); {
+	case q.remaining <= 0:
+		if untilReset > r.opts.MaxWait {
+			r.mu.Unlock()
+			return fmt.Errorf("rate limit of %s exhausted, resets in %s", host, untilReset.Round(time.Second))
+		}
+		wait = untilReset
+
+	case q.remaining < r.opts.SlowDownBelow:
+		start := now
+		if q.next.After(start) {
+			start = q.next
+		}
+		q.next = start.Add(untilReset / time.Duration(q.remaining+1))
+		q.remaining--
+		wait = min(start.Sub(now), r.opts.MaxWait)
+
+	default:
+		q.remaining--
+	}
+	r.mu.Unlock()
+
+	if wait > 0 {
+		r.log.WithFields(logrus.Fields{"host": host, "wait": wait}).Debug("slowing down for registry rate limit")
+	}
+	return sleep(ctx, wait)
+}
+
+// observe records the quota that the response reports, if any.
+func (r *Retrier) observe(host string, resp *http.Response) {
+	remaining, window, ok := parseRateLimitRemaining(resp.Header.Get("RateLimit-Remaining"))
+	if !ok {
+		return
+	}
+
+	if r.opts.Observer != nil {
+		r.opts.Observer.RegistryRateLimitRemaining(host, remaining)
+	}
+
+	// Without a reset, the quota can't be spread out
+	untilReset := window
+	if reset, err := strconv.Atoi(strings.TrimSpace(resp.Header.Get("RateLimit-Reset"))); err == nil && reset >= 0 {
+		untilReset = time.Duration(reset) * time.Second
+	}
+	if untilReset <= 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	q, ok := r.quotas[host]
+	if !ok {
+		q = new(quota)
+		r.quotas[host] = q
+	}
+	now := time.Now()
+	q.remaining = remaining
+	q.reset = now.Add(untilReset)
+
+	// Spread out from this response, rather than the next request
+	if remaining > 0 && remaining < r.opts.SlowDownBelow {
+		if next := now.Add(untilReset / time.Duration(remaining+1)); next.After(q.next) {
+			q.next = next
+		}
+	}
+}
+
+// exhaust marks the host's quota as exhausted for the given duration, so
+// that concurrent requests to it wait as well.
+func (r *Retrier) exhaust(host string, wait time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	q, ok := r.quotas[host]
+	if !ok {
+		q = new(quota)
+		r.quotas[host] = q
+	}
+	q.remaining = 0
+	if reset := time.Now().Add(wait); reset.After(q.reset) {
+		q.reset = reset
+	}
+}
+
+// parseRateLimitRemaining parses a RateLimit-Remaining header, e.g. "76" or
+// Docker Hub's "76;w=21600", returning the remaining quota and its window.
+func parseRateLimitRemaining(value string) (int, time.Duration, bool) {
+	if len(value) == 0 {
+		return 0, 0, false
+	}
+
+	params := strings.Split(value, ";")
+	remaining, err := strconv.Atoi(strings.TrimSpace(params[0]))
+	if err != nil || remaining < 0 {
+		return 0, 0, false
+	}
+
+	var window time.Duration
+	for _, param := range params[1:] {
+		key, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if key != "w" {
+			continue
+		}
+		if seconds, err := strconv.Atoi(val); err == nil && seconds > 0 {
+			window = time.Duration(seconds) * time.Second
+		}
+	}
+
+	return remaining, window, true
+}
+
+// parseRetryAfter parses a Retry-After header of either delay seconds or an
+// HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(date.Sub(now), 0), true
+}
+
+// rewindRequest returns the request to make for the given attempt, with its
+// body rewound for retries.
+func rewindRequest(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.GetBody == nil {
+		return req, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Body = body
+	return req, nil
+}
+
+func retryReason(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return resp.Status
+}
+
+// sleep waits for the duration, or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// cancelBody cancels the context of a request when its body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
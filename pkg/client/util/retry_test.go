@@ -0,0 +1,309 @@
+package util
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeQuotaObserver struct {
+	mu        sync.Mutex
+	remaining map[string]int
+}
+
+func (f *fakeQuotaObserver) RegistryRateLimitRemaining(host string, remaining int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.remaining == nil {
+		f.remaining = make(map[string]int)
+	}
+	f.remaining[host] = remaining
+}
+
+func TestRetrier(t *testing.T) {
+	log := logrus.NewEntry(logrus.New())
+	opts := RetryOptions{
+		MaxRetries: 3,
+		MinWait:    time.Millisecond,
+		MaxWait:    500 * time.Millisecond,
+	}
+
+	tests := map[string]struct {
+		responses   []func(w http.ResponseWriter)
+		method      string
+		expStatus   int
+		expRequests int32
+	}{
+		"successful request is not retried": {
+			responses:   []func(w http.ResponseWriter){ok},
+			expStatus:   http.StatusOK,
+			expRequests: 1,
+		},
+		"server errors are retried": {
+			responses:   []func(w http.ResponseWriter){status(http.StatusBadGateway), status(http.StatusServiceUnavailable), ok},
+			expStatus:   http.StatusOK,
+			expRequests: 3,
+		},
+		"too many requests is retried after Retry-After": {
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(http.StatusTooManyRequests)
+				},
+				ok,
+			},
+			expStatus:   http.StatusOK,
+			expRequests: 2,
+		},
+		"Retry-After longer than the max wait is not retried": {
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.Header().Set("Retry-After", "3600")
+					w.WriteHeader(http.StatusTooManyRequests)
+				},
+				ok,
+			},
+			expStatus:   http.StatusTooManyRequests,
+			expRequests: 1,
+		},
+		"client errors are not retried": {
+			responses:   []func(w http.ResponseWriter){status(http.StatusNotFound), ok},
+			expStatus:   http.StatusNotFound,
+			expRequests: 1,
+		},
+		"not implemented is not retried": {
+			responses:   []func(w http.ResponseWriter){status(http.StatusNotImplemented), ok},
+			expStatus:   http.StatusNotImplemented,
+			expRequests: 1,
+		},
+		"retries give up after max retries": {
+			responses:   []func(w http.ResponseWriter){status(http.StatusInternalServerError)},
+			expStatus:   http.StatusInternalServerError,
+			expRequests: 4,
+		},
+		"request bodies are rewound for retries": {
+			method:      http.MethodPost,
+			responses:   []func(w http.ResponseWriter){status(http.StatusInternalServerError), ok},
+			expStatus:   http.StatusOK,
+			expRequests: 2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPost {
+					body, _ := io.ReadAll(r.Body)
+					assert.Equal(t, "body", string(body))
+				}
+				i := int(requests.Add(1)) - 1
+				test.responses[min(i, len(test.responses)-1)](w)
+			}))
+			defer server.Close()
+
+			method := test.method
+			if len(method) == 0 {
+				method = http.MethodGet
+			}
+			req, err := http.NewRequest(method, server.URL, strings.NewReader("body"))
+			require.NoError(t, err)
+
+			client := &http.Client{Transport: NewRetrier(log, opts).RoundTripper(nil)}
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+
+			assert.Equal(t, test.expStatus, resp.StatusCode)
+			assert.Equal(t, test.expRequests, requests.Load())
+		})
+	}
+}
+
+func TestRetrierAttemptTimeout(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	retrier := NewRetrier(logrus.NewEntry(logrus.New()), RetryOptions{
+		MinWait:        time.Millisecond,
+		AttemptTimeout: 50 * time.Millisecond,
+	})
+	client := &http.Client{Transport: retrier.RoundTripper(nil)}
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	// The body is still readable after the attempt returned
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestRetrierQuota(t *testing.T) {
+	log := logrus.NewEntry(logrus.New())
+
+	t.Run("exhausted quota fails without making the request", func(t *testing.T) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.Header().Set("RateLimit-Remaining", "0;w=21600")
+		}))
+		defer server.Close()
+
+		observer := new(fakeQuotaObserver)
+		retrier := NewRetrier(log, RetryOptions{Observer: observer})
+		client := &http.Client{Transport: retrier.RoundTripper(nil)}
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		_, err = client.Get(server.URL)
+		assert.ErrorContains(t, err, "rate limit of "+server.Listener.Addr().String()+" exhausted")
+		assert.Equal(t, int32(1), requests.Load())
+		assert.Equal(t, map[string]int{server.Listener.Addr().String(): 0}, observer.remaining)
+	})
+
+	t.Run("low quota spreads requests until the reset", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("RateLimit-Remaining", "1")
+			w.Header().Set("RateLimit-Reset", "1")
+		}))
+		defer server.Close()
+
+		retrier := NewRetrier(log, RetryOptions{SlowDownBelow: 10})
+		client := &http.Client{Transport: retrier.RoundTripper(nil)}
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		// 1 request remaining over 1 second waits half of it
+		start := time.Now()
+		resp, err = client.Get(server.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+	})
+
+	t.Run("plenty of quota is not slowed down", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("RateLimit-Remaining", "100;w=21600")
+		}))
+		defer server.Close()
+
+		client := &http.Client{Transport: NewRetrier(log, RetryOptions{}).RoundTripper(nil)}
+
+		start := time.Now()
+		for i := 0; i < 5; i++ {
+			resp, err := client.Get(server.URL)
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+		}
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("waiting for quota is cancelled with the request", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("RateLimit-Remaining", "0")
+			w.Header().Set("RateLimit-Reset", "30")
+		}))
+		defer server.Close()
+
+		client := &http.Client{Transport: NewRetrier(log, RetryOptions{}).RoundTripper(nil)}
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		_, err = client.Do(req)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestParseRateLimitRemaining(t *testing.T) {
+	tests := map[string]struct {
+		value        string
+		expRemaining int
+		expWindow    time.Duration
+		expOK        bool
+	}{
+		"empty":            {value: "", expOK: false},
+		"invalid":          {value: "lots", expOK: false},
+		"negative":         {value: "-1", expOK: false},
+		"remaining":        {value: "76", expRemaining: 76, expOK: true},
+		"docker hub":       {value: "76;w=21600", expRemaining: 76, expWindow: 6 * time.Hour, expOK: true},
+		"unknown param":    {value: "76; q=1", expRemaining: 76, expOK: true},
+		"invalid window":   {value: "76;w=abc", expRemaining: 76, expOK: true},
+		"exhausted window": {value: "0;w=60", expRemaining: 0, expWindow: time.Minute, expOK: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			remaining, window, ok := parseRateLimitRemaining(test.value)
+			assert.Equal(t, test.expOK, ok)
+			assert.Equal(t, test.expRemaining, remaining)
+			assert.Equal(t, test.expWindow, window)
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		value   string
+		expWait time.Duration
+		expOK   bool
+	}{
+		"empty":       {value: "", expOK: false},
+		"seconds":     {value: "120", expWait: 2 * time.Minute, expOK: true},
+		"negative":    {value: "-1", expOK: false},
+		"http date":   {value: "Wed, 01 Jan 2025 12:00:30 GMT", expWait: 30 * time.Second, expOK: true},
+		"past date":   {value: "Wed, 01 Jan 2025 11:00:00 GMT", expWait: 0, expOK: true},
+		"invalid":     {value: "soon", expOK: false},
+		"padded":      {value: " 5 ", expWait: 5 * time.Second, expOK: true},
+		"zero":        {value: "0", expWait: 0, expOK: true},
+		"large value": {value: "86400", expWait: 24 * time.Hour, expOK: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			wait, ok := parseRetryAfter(test.value, now)
+			assert.Equal(t, test.expOK, ok)
+			assert.Equal(t, test.expWait, wait)
+		})
+	}
+}
+
+func ok(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func status(code int) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.WriteHeader(code)
+	}
+}
@@ -4,17 +4,34 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/google/go-containerregistry/pkg/authn"
 	corev1 "k8s.io/api/core/v1"
 
 	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
 	"github.com/jetstack/version-checker/pkg/controller/search"
-	"github.com/jetstack/version-checker/pkg/version/semver"
+	"github.com/jetstack/version-checker/pkg/version/scheme"
 	"github.com/sirupsen/logrus"
 )
 
 type Checker struct {
-	search search.Searcher
+	search        search.Searcher
+	pullSecrets   PullSecrets
+	nodePlatforms NodePlatforms
+	mirrors       []Mirror
+}
+
+// PullSecrets resolves the keychain of the imagePullSecrets of a pod spec,
+// along with the identity of its credentials.
+type PullSecrets interface {
+	Keychain(ctx context.Context, namespace string, spec *corev1.PodSpec) (authn.Keychain, string, error)
+}
+
+// NodePlatforms resolves the platform of a Node, or nil if it isn't known.
+type NodePlatforms interface {
+	Platform(ctx context.Context, nodeName string) (*api.Platform, error)
 }
 
 type Result struct {
@@ -22,14 +39,58 @@ type Result struct {
 	LatestVersion  string
 	ImageURL       string
 	IsLatest       bool
+
+	// MirrorURL is the image URL of the mirror the image is pulled through,
+	// if any, whose versions are looked up upstream at ImageURL.
+	MirrorURL string
+
+	// Platform is the platform of the manifest list's image the running
+	// digest was compared to, such as linux/arm64, if known.
+	Platform string
+
+	// LatestTimestamp is when the latest version was published, if known.
+	LatestTimestamp time.Time
+
+	// Distance is how far the current version is behind the latest. Only
+	// set when comparing tags, not SHA digests.
+	Distance *api.VersionDistance
+
+	// TagDrift is whether the digest of the running tag in the registry is
+	// still the running digest. Nil if it isn't known, such as for images
+	// pinned by digest.
+	TagDrift *api.TagDrift
 }
 
 func New(search search.Searcher) *Checker {
+	return NewWithPullSecrets(search, nil)
+}
+
+// NewWithPullSecrets returns a Checker which authenticates registry lookups
+// with the credentials of each Pod's imagePullSecrets, resolved with the
+// given PullSecrets. If nil, only the credentials of the client are used.
+func NewWithPullSecrets(search search.Searcher, pullSecrets PullSecrets) *Checker {
 	return &Checker{
-		search: search,
+		search:      search,
+		pullSecrets: pullSecrets,
 	}
 }
 
+// WithMirrors sets the mirrors whose images are looked up upstream, in
+// order, returning the Checker. The first matching mirror applies, and
+// containers with an override URL are not rewritten.
+func (c *Checker) WithMirrors(mirrors []Mirror) *Checker {
+	c.mirrors = mirrors
+	return c
+}
+
+// WithNodePlatforms sets the resolver of the platform of the Node each Pod
+// runs on, returning the Checker. Only tags built for that platform are then
+// considered, unless the container's options set a platform.
+func (c *Checker) WithNodePlatforms(nodePlatforms NodePlatforms) *Checker {
+	c.nodePlatforms = nodePlatforms
+	return c
+}
+
 // Container will return the result of the given container's current version, compared to the latest upstream.
 func (c *Checker) Container(ctx context.Context, log *logrus.Entry,
 	pod *corev1.Pod,
@@ -41,7 +102,146 @@ func (c *Checker) Container(ctx context.Context, log *logrus.Entry,
 		return nil, nil
 	}
 
+	ctx, err := c.withPullSecrets(ctx, pod.Namespace, &pod.Spec)
+	if err != nil {
+		return nil, err
+	}
+	ctx = withRegistryClient(ctx, opts)
+	c.withNodePlatform(ctx, log, pod, opts)
+
+	result, err := c.image(ctx, log, container.Image, statusSHA, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result.TagDrift = c.tagDrift(ctx, log, container.Image, result.ImageURL, statusSHA)
+
+	return result, nil
+}
+
+// Template will return the result of the given container from a workload's
+// pod template, compared to the latest upstream. As no Pod is running the
+// image, the current digest is resolved from the registry.
+func (c *Checker) Template(ctx context.Context, log *logrus.Entry,
+	namespace string,
+	spec *corev1.PodSpec,
+	container *corev1.Container,
+	opts *api.Options,
+) (*Result, error) {
+	ctx, err := c.withPullSecrets(ctx, namespace, spec)
+	if err != nil {
+		return nil, err
+	}
+	ctx = withRegistryClient(ctx, opts)
+
 	imageURL, currentTag, currentSHA := urlTagSHAFromImage(container.Image)
+
+	statusSHA := currentSHA
+	if len(statusSHA) == 0 {
+		if len(currentTag) == 0 {
+			currentTag = "latest"
+		}
+
+		lookupURL, _ := c.overrideImageURL(log, imageURL, opts)
+		sha, err := c.search.ResolveTagToSHA(ctx, lookupURL, currentTag, optionsPlatform(opts))
+		if err != nil {
+			return nil, err
+		}
+		if len(sha) == 0 {
+			log.Debugf("unable to resolve digest for %s:%s", lookupURL, currentTag)
+			return nil, nil
+		}
+		statusSHA = sha
+	}
+
+	return c.image(ctx, log, container.Image, statusSHA, opts)
+}
+
+// withPullSecrets returns the context carrying the keychain of the
+// imagePullSecrets of the pod spec, if the Checker uses them and it has any.
+func (c *Checker) withPullSecrets(ctx context.Context, namespace string, spec *corev1.PodSpec) (context.Context, error) {
+	if c.pullSecrets == nil {
+		return ctx, nil
+	}
+
+	keychain, id, err := c.pullSecrets.Keychain(ctx, namespace, spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve image pull secrets: %w", err)
+	}
+	if keychain == nil {
+		return ctx, nil
+	}
+
+	return util.ContextWithKeychain(ctx, keychain, id), nil
+}
+
+// withRegistryClient returns the context naming the registry client of the
+// options, if they set one.
+func withRegistryClient(ctx context.Context, opts *api.Options) context.Context {
+	if len(opts.RegistryClient) == 0 {
+		return ctx
+	}
+	return util.ContextWithRegistryClient(ctx, opts.RegistryClient)
+}
+
+// withNodePlatform sets the platform of the options to that of the Node the
+// pod runs on, unless they set one. Tags of every platform are considered if
+// it can't be resolved.
+func (c *Checker) withNodePlatform(ctx context.Context, log *logrus.Entry, pod *corev1.Pod, opts *api.Options) {
+	if c.nodePlatforms == nil || opts.Platform != nil || len(pod.Spec.NodeName) == 0 {
+		return
+	}
+
+	platform, err := c.nodePlatforms.Platform(ctx, pod.Spec.NodeName)
+	if err != nil {
+		log.WithError(err).Warn("failed to resolve node platform, considering tags of every platform")
+		return
+	}
+
+	opts.Platform = platform
+}
+
+// tagDrift compares the running digest to the digest the tag of the image
+// points to in the registry, regardless of the latest version. The running
+// digest may be that of the tag's manifest list, or of one of its children.
+// Returns nil if the image is pinned by digest, or the digest of the tag
+// isn't known.
+func (c *Checker) tagDrift(ctx context.Context, log *logrus.Entry, image, lookupURL, statusSHA string) *api.TagDrift {
+	_, tag, sha := urlTagSHAFromImage(image)
+	if len(sha) > 0 {
+		return nil
+	}
+	if len(tag) == 0 {
+		tag = "latest"
+	}
+
+	imageTag, err := c.search.Tag(ctx, lookupURL, tag)
+	if err != nil {
+		log.WithError(err).Warn("failed to resolve the digest of the running tag")
+		return nil
+	}
+
+	switch {
+	case imageTag == nil:
+		return nil
+	case imageTag.MatchesSHA(statusSHA):
+		return &api.TagDrift{Tag: tag}
+	case len(imageTag.SHA) == 0:
+		// Without the digest of the manifest list, a running digest of the
+		// list can't be told apart from a re-pushed tag
+		return nil
+	default:
+		return &api.TagDrift{Tag: tag, Drifted: true}
+	}
+}
+
+// image will return the result of the given image and the digest it is
+// running, compared to the latest upstream.
+func (c *Checker) image(ctx context.Context, log *logrus.Entry,
+	image, statusSHA string,
+	opts *api.Options,
+) (*Result, error) {
+	imageURL, currentTag, currentSHA := urlTagSHAFromImage(image)
 	usingSHA, usingTag := len(currentSHA) > 0, len(currentTag) > 0
 
 	if opts.ResolveSHAToTags {
@@ -65,13 +265,24 @@ func (c *Checker) Container(ctx context.Context, log *logrus.Entry,
 		usingTag = false
 	}
 
-	imageURL = c.overrideImageURL(log, imageURL, opts)
+	lookupURL, mirrorURL := c.overrideImageURL(log, imageURL, opts)
 
+	var (
+		result *Result
+		err    error
+	)
 	if opts.UseSHA {
-		return c.handleSHA(ctx, imageURL, statusSHA, opts, usingTag, currentTag)
+		result, err = c.handleSHA(ctx, lookupURL, statusSHA, opts, usingTag, currentTag)
+	} else {
+		result, err = c.handleSemver(ctx, lookupURL, statusSHA, currentTag, usingSHA, opts)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return c.handleSemver(ctx, imageURL, statusSHA, currentTag, usingSHA, opts)
+	result.MirrorURL = mirrorURL
+
+	return result, nil
 }
 
 func (c *Checker) handleLatestOrEmptyTag(log *logrus.Entry, currentTag, currentSHA string, opts *api.Options) {
@@ -79,16 +290,33 @@ func (c *Checker) handleLatestOrEmptyTag(log *logrus.Entry, currentTag, currentS
 	log.WithField("module", "checker").Debugf("image using %q tag, comparing image SHA %q", currentTag, currentSHA)
 }
 
-func (c *Checker) overrideImageURL(log *logrus.Entry, imageURL string, opts *api.Options) string {
+// overrideImageURL returns the image URL whose versions are looked up, and
+// the image URL of the mirror the image is pulled through, if any.
+func (c *Checker) overrideImageURL(log *logrus.Entry, imageURL string, opts *api.Options) (string, string) {
 	if opts.OverrideURL != nil && *opts.OverrideURL != imageURL {
 		log.Debugf("overriding image URL %s -> %s", imageURL, *opts.OverrideURL)
-		return *opts.OverrideURL
+		return *opts.OverrideURL, ""
 	}
-	return imageURL
+	if opts.OverrideURL == nil {
+		for _, mirror := range c.mirrors {
+			if mirror.Transparent {
+				if mirrorURL, ok := mirror.mirrorURL(imageURL); ok {
+					log.Debugf("image URL %s is pulled through mirror %s", imageURL, mirrorURL)
+					return imageURL, mirrorURL
+				}
+				continue
+			}
+			if upstream, ok := mirror.rewrite(imageURL); ok {
+				log.Debugf("looking up mirrored image URL %s upstream -> %s", imageURL, upstream)
+				return upstream, imageURL
+			}
+		}
+	}
+	return imageURL, ""
 }
 
 func (c *Checker) handleSHA(ctx context.Context, imageURL, statusSHA string, opts *api.Options, usingTag bool, currentTag string) (*Result, error) {
-	result, err := c.isLatestSHA(ctx, imageURL, statusSHA, opts)
+	result, err := c.isLatestSHA(ctx, imageURL, currentTag, statusSHA, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -101,26 +329,39 @@ func (c *Checker) handleSHA(ctx context.Context, imageURL, statusSHA string, opt
 }
 
 func (c *Checker) handleSemver(ctx context.Context, imageURL, statusSHA, currentTag string, usingSHA bool, opts *api.Options) (*Result, error) {
-	currentImage := semver.Parse(currentTag)
-	latestImage, isLatest, err := c.isLatestSemver(ctx, imageURL, statusSHA, currentImage, opts)
+	currentImage := scheme.ForOptions(opts).Parse(currentTag)
+	latestImage, isLatest, platform, err := c.isLatestSemver(ctx, imageURL, currentTag, statusSHA, currentImage, opts)
 	if err != nil {
 		return nil, err
 	}
 
+	distance := new(api.VersionDistance)
+	if !isLatest {
+		distance, err = c.search.Distance(ctx, imageURL, currentTag, latestImage, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	latestVersion := latestImage.Tag
 	if usingSHA && !strings.Contains(latestVersion, "@") && latestImage.SHA != "" {
 		latestVersion = fmt.Sprintf("%s@%s", latestVersion, latestImage.SHA)
 	}
 
-	if strings.Contains(latestVersion, "@") {
+	// Show the running digest when it is what makes the equal version out
+	// of date, even if the latest digest to compare it to is unknown.
+	if strings.Contains(latestVersion, "@") || (!isLatest && latestVersion == currentTag && len(statusSHA) > 0) {
 		currentTag = fmt.Sprintf("%s@%s", currentTag, statusSHA)
 	}
 
 	return &Result{
-		CurrentVersion: currentTag,
-		LatestVersion:  latestVersion,
-		IsLatest:       isLatest,
-		ImageURL:       imageURL,
+		CurrentVersion:  currentTag,
+		LatestVersion:   latestVersion,
+		IsLatest:        isLatest,
+		ImageURL:        imageURL,
+		LatestTimestamp: latestImage.Timestamp,
+		Distance:        distance,
+		Platform:        platform,
 	}, nil
 }
 
@@ -129,14 +370,15 @@ func (c *Checker) isLatestOrEmptyTag(tag string) bool {
 	return tag == "" || tag == "latest"
 }
 
-// isLatestSemver will return the latest image, and whether the given image is the latest.
-func (c *Checker) isLatestSemver(ctx context.Context, imageURL, currentSHA string, currentImage *semver.SemVer, opts *api.Options) (*api.ImageTag, bool, error) {
+// isLatestSemver will return the latest image, whether the given image is the
+// latest, and the platform whose digest was compared, if known.
+func (c *Checker) isLatestSemver(ctx context.Context, imageURL, currentTag, currentSHA string, currentImage scheme.Version, opts *api.Options) (*api.ImageTag, bool, string, error) {
 	latestImage, err := c.search.LatestImage(ctx, imageURL, opts)
 	if err != nil {
-		return nil, false, err
+		return nil, false, "", err
 	}
 
-	latestImageV := semver.Parse(latestImage.Tag)
+	latestImageV := scheme.ForOptions(opts).Parse(latestImage.Tag)
 
 	var isLatest bool
 
@@ -148,61 +390,127 @@ func (c *Checker) isLatestSemver(ctx context.Context, imageURL, currentSHA strin
 	// If using the same image version,
 	// but the SHA has been updated upstream,
 	// mark not latest
-	if currentImage.Equal(latestImageV) &&
-		!latestImage.MatchesSHA(currentSHA) {
-		isLatest = false
-		if latestImage.SHA != "" {
+	var platform string
+	if currentImage.Equal(latestImageV) {
+		var latestSHA string
+		latestSHA, isLatest, platform = c.compareDigest(ctx, imageURL, currentTag, latestImage, currentSHA, opts)
+
+		// Without the running platform, latestSHA is the digest of the
+		// manifest list, if known, rather than an arbitrary platform's.
+		if !isLatest && len(latestSHA) > 0 {
 			// Add the SHA as a prefix to identify that it has been updated!
-			latestImage.Tag = fmt.Sprintf("%s@%s", latestImage.Tag, latestImage.SHA)
-		} else {
-			for _, child := range latestImage.Children {
-				// Take first child's SHA for latest image tag
-				if child.SHA != currentSHA {
-					latestImage.Tag = fmt.Sprintf("%s@%s", latestImage.Tag, child.SHA)
-					break
-				}
-			}
+			latestImage.Tag = fmt.Sprintf("%s@%s", latestImage.Tag, latestSHA)
 		}
 	}
 
-	return latestImage, isLatest, nil
+	return latestImage, isLatest, platform, nil
 }
 
 // isLatestSHA will return the the result of whether the given image is the latest, according to image SHA.
-func (c *Checker) isLatestSHA(ctx context.Context, imageURL, currentSHA string, opts *api.Options) (*Result, error) {
+func (c *Checker) isLatestSHA(ctx context.Context, imageURL, currentTag, currentSHA string, opts *api.Options) (*Result, error) {
 	latestImage, err := c.search.LatestImage(ctx, imageURL, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	var (
-		isLatest      bool
-		latestVersion string
-	)
+	latestVersion, isLatest, platform := c.compareDigest(ctx, imageURL, currentTag, latestImage, currentSHA, opts)
 
-	if latestImage.SHA != "" {
-		isLatest = latestImage.SHA == currentSHA
-		latestVersion = latestImage.SHA
+	if len(latestImage.Tag) > 0 && latestVersion != "" {
+		latestVersion = fmt.Sprintf("%s@%s", latestImage.Tag, latestVersion)
+	}
+
+	return &Result{
+		CurrentVersion:  currentSHA,
+		LatestVersion:   latestVersion,
+		IsLatest:        isLatest,
+		ImageURL:        imageURL,
+		LatestTimestamp: latestImage.Timestamp,
+		Platform:        platform,
+	}, nil
+}
+
+// compareDigest compares the running digest to the latest image, returning
+// the digest of the latest image it is compared to, whether they match, and
+// the platform of that digest, if known. The running digest matches a
+// manifest list when it is the list's digest, or one of its children's.
+// Otherwise it is compared to the child built for the running platform,
+// rather than another platform's, falling back to the list's digest, never
+// an arbitrary child's.
+func (c *Checker) compareDigest(ctx context.Context, imageURL, currentTag string, latestImage *api.ImageTag, runningSHA string, opts *api.Options) (string, bool, string) {
+	if len(latestImage.SHA) > 0 && latestImage.SHA == runningSHA {
+		return latestImage.SHA, true, platformString(optionsPlatform(opts))
 	}
 
 	for _, child := range latestImage.Children {
-		if child.SHA == currentSHA {
-			isLatest = true
-			latestVersion = child.SHA
-			break
+		if child.SHA == runningSHA {
+			return child.SHA, true, childPlatform(child)
 		}
 	}
 
-	if len(latestImage.Tag) > 0 && latestVersion != "" {
-		latestVersion = fmt.Sprintf("%s@%s", latestImage.Tag, latestVersion)
+	if len(latestImage.Children) == 0 {
+		return latestImage.SHA, false, ""
 	}
 
-	return &Result{
-		CurrentVersion: currentSHA,
-		LatestVersion:  latestVersion,
-		IsLatest:       isLatest,
-		ImageURL:       imageURL,
-	}, nil
+	platform := c.runningPlatform(ctx, imageURL, currentTag, runningSHA, opts)
+	if platform != nil {
+		for _, child := range latestImage.Children {
+			if len(child.SHA) > 0 && platform.Matches(child) {
+				return child.SHA, false, childPlatform(child)
+			}
+		}
+	}
+
+	// A list with a single child and no digest of its own has only one
+	// digest to compare to, whatever the running platform.
+	if platform == nil && len(latestImage.SHA) == 0 && len(latestImage.Children) == 1 {
+		child := latestImage.Children[0]
+		return child.SHA, false, childPlatform(child)
+	}
+
+	return latestImage.SHA, false, ""
+}
+
+// runningPlatform returns the platform of the running digest, from the child
+// of the running tag's manifest list it is. The platform of the options, such
+// as that of the Pod's node, is used when the running tag doesn't tell.
+func (c *Checker) runningPlatform(ctx context.Context, imageURL, currentTag, runningSHA string, opts *api.Options) *api.Platform {
+	if len(currentTag) == 0 {
+		currentTag = "latest"
+	}
+
+	imageTag, err := c.search.Tag(ctx, imageURL, currentTag)
+	if err == nil && imageTag != nil {
+		for _, child := range imageTag.Children {
+			if child.SHA == runningSHA && len(child.Architecture) > 0 {
+				return &api.Platform{OS: child.OS, Architecture: child.Architecture}
+			}
+		}
+	}
+
+	return optionsPlatform(opts)
+}
+
+// childPlatform returns the platform of the manifest list's child, or empty
+// if the registry didn't report it.
+func childPlatform(child *api.ImageTag) string {
+	if len(child.Architecture) == 0 {
+		return ""
+	}
+	return api.Platform{OS: child.OS, Architecture: child.Architecture}.String()
+}
+
+func platformString(platform *api.Platform) string {
+	if platform == nil {
+		return ""
+	}
+	return platform.String()
+}
+
+func optionsPlatform(opts *api.Options) *api.Platform {
+	if opts == nil {
+		return nil
+	}
+	return opts.Platform
 }
 
 func (c *Checker) Search() search.Searcher {
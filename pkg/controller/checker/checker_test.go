@@ -2,18 +2,23 @@ package checker
 
 import (
 	"context"
+	"errors"
 	"reflect"
+	"regexp"
 	"testing"
 
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
 	"github.com/jetstack/version-checker/pkg/controller/internal/fake/search"
-	"github.com/jetstack/version-checker/pkg/version/semver"
+	"github.com/jetstack/version-checker/pkg/version/scheme"
 )
 
 func TestContainer(t *testing.T) {
@@ -44,6 +49,7 @@ func TestContainer(t *testing.T) {
 				LatestVersion:  "v0.2.0@sha:456",
 				ImageURL:       "localhost:5000/version-checker",
 				IsLatest:       false,
+				Distance:       &api.VersionDistance{},
 			},
 		},
 		"if v0.2.0 is latest version, but same sha, then latest": {
@@ -59,6 +65,7 @@ func TestContainer(t *testing.T) {
 				LatestVersion:  "v0.2.0",
 				ImageURL:       "localhost:5000/version-checker",
 				IsLatest:       true,
+				Distance:       &api.VersionDistance{},
 			},
 		},
 		"if v0.2.0 is latest version, but sha is in a child, then latest": {
@@ -75,6 +82,7 @@ func TestContainer(t *testing.T) {
 				LatestVersion:  "v0.2.0",
 				ImageURL:       "localhost:5000/version-checker",
 				IsLatest:       true,
+				Distance:       &api.VersionDistance{},
 			},
 		},
 		"if v0.2.0 is latest version, but sha is not in cache, then not latest": {
@@ -91,6 +99,7 @@ func TestContainer(t *testing.T) {
 				LatestVersion:  "v0.2.0@sha:789",
 				ImageURL:       "localhost:5000/version-checker",
 				IsLatest:       false,
+				Distance:       &api.VersionDistance{},
 			},
 		},
 		"if v0.2.0 is latest version, but sha is not in cache, and multiple possible shas, then not latest": {
@@ -104,9 +113,10 @@ func TestContainer(t *testing.T) {
 			},
 			expResult: &Result{
 				CurrentVersion: "v0.2.0@123",
-				LatestVersion:  "v0.2.0@789",
+				LatestVersion:  "v0.2.0",
 				ImageURL:       "localhost:5000/version-checker",
 				IsLatest:       false,
+				Distance:       &api.VersionDistance{},
 			},
 		},
 		"if v0.2.0@sha:123 is wrong sha, then not latest": {
@@ -122,6 +132,7 @@ func TestContainer(t *testing.T) {
 				LatestVersion:  "v0.2.0@sha:456",
 				ImageURL:       "localhost:5000/version-checker",
 				IsLatest:       false,
+				Distance:       &api.VersionDistance{},
 			},
 		},
 		"if v0.2.0@sha:123 is correct sha, then latest": {
@@ -137,6 +148,7 @@ func TestContainer(t *testing.T) {
 				LatestVersion:  "v0.2.0@sha:123",
 				ImageURL:       "localhost:5000/version-checker",
 				IsLatest:       true,
+				Distance:       &api.VersionDistance{},
 			},
 		},
 		"if empty is not latest version, then return false": {
@@ -310,11 +322,30 @@ func TestContainer(t *testing.T) {
 				IsLatest:       true,
 			},
 		},
+		"image pulled through a mirror is looked up upstream": {
+			statusSHA: "harbor.corp/dockerhub-proxy/library/nginx@sha:123",
+			imageURL:  "harbor.corp/dockerhub-proxy/library/nginx:1.27.0",
+			opts:      new(api.Options),
+			searchResp: &api.ImageTag{
+				Tag: "1.27.0",
+				SHA: "sha:123",
+			},
+			expResult: &Result{
+				CurrentVersion: "1.27.0",
+				LatestVersion:  "1.27.0",
+				ImageURL:       "docker.io/library/nginx",
+				MirrorURL:      "harbor.corp/dockerhub-proxy/library/nginx",
+				IsLatest:       true,
+				Distance:       &api.VersionDistance{},
+			},
+		},
 	}
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			checker := New(search.New().With(test.searchResp, nil))
+			checker := New(search.New().With(test.searchResp, nil)).WithMirrors([]Mirror{
+				{Prefix: "harbor.corp/dockerhub-proxy", Upstream: "docker.io"},
+			})
 			pod := &corev1.Pod{
 				Status: corev1.PodStatus{
 					ContainerStatuses: []corev1.ContainerStatus{
@@ -337,6 +368,321 @@ func TestContainer(t *testing.T) {
 	}
 }
 
+func TestTemplate(t *testing.T) {
+	tests := map[string]struct {
+		imageURL    string
+		resolvedSHA string
+		searchResp  *api.ImageTag
+		expResult   *Result
+	}{
+		"unresolved digest should return nil": {
+			imageURL:    "localhost:5000/version-checker:v0.2.0",
+			resolvedSHA: "",
+			searchResp:  &api.ImageTag{Tag: "v0.2.0", SHA: "sha:123"},
+			expResult:   nil,
+		},
+		"resolved digest matching latest should be latest": {
+			imageURL:    "localhost:5000/version-checker:v0.2.0",
+			resolvedSHA: "sha:123",
+			searchResp:  &api.ImageTag{Tag: "v0.2.0", SHA: "sha:123"},
+			expResult: &Result{
+				CurrentVersion: "v0.2.0",
+				LatestVersion:  "v0.2.0",
+				ImageURL:       "localhost:5000/version-checker",
+				IsLatest:       true,
+				Distance:       &api.VersionDistance{},
+			},
+		},
+		"older tag should not be latest": {
+			imageURL:    "localhost:5000/version-checker:v0.1.0",
+			resolvedSHA: "sha:123",
+			searchResp:  &api.ImageTag{Tag: "v0.2.0", SHA: "sha:456"},
+			expResult: &Result{
+				CurrentVersion: "v0.1.0",
+				LatestVersion:  "v0.2.0",
+				ImageURL:       "localhost:5000/version-checker",
+				IsLatest:       false,
+				Distance:       &api.VersionDistance{},
+			},
+		},
+		"pinned digest should not be resolved": {
+			imageURL:    "localhost:5000/version-checker:v0.2.0@sha:123",
+			resolvedSHA: "",
+			searchResp:  &api.ImageTag{Tag: "v0.2.0", SHA: "sha:456"},
+			expResult: &Result{
+				CurrentVersion: "v0.2.0@sha:123",
+				LatestVersion:  "v0.2.0@sha:456",
+				ImageURL:       "localhost:5000/version-checker",
+				IsLatest:       false,
+				Distance:       &api.VersionDistance{},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			checker := New(search.New().With(test.searchResp, nil).WithResolvedSHA(test.resolvedSHA, nil))
+			container := &corev1.Container{
+				Name:  "test-name",
+				Image: test.imageURL,
+			}
+
+			result, err := checker.Template(context.TODO(), logrus.NewEntry(logrus.New()), "test-namespace", new(corev1.PodSpec), container, new(api.Options))
+			require.NoError(t, err)
+			assert.Exactly(t, test.expResult, result)
+		})
+	}
+}
+
+func TestPullSecrets(t *testing.T) {
+	keychain := authn.NewMultiKeychain()
+	spec := corev1.PodSpec{
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "pull-secret"}},
+		Containers:       []corev1.Container{{Name: "test-name", Image: "localhost:5000/version-checker:v0.2.0"}},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test-namespace"},
+		Spec:       spec,
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "test-name", ImageID: "localhost:5000/version-checker@sha:123"}},
+		},
+	}
+
+	tests := map[string]struct {
+		pullSecrets *fakePullSecrets
+		check       func(c *Checker, ctx context.Context) error
+		expKeychain authn.Keychain
+		expIndex    string
+		expErr      string
+	}{
+		"container lookups use the pod's pull secrets": {
+			pullSecrets: &fakePullSecrets{keychain: keychain, id: "test-namespace/pull-secret"},
+			check: func(c *Checker, ctx context.Context) error {
+				_, err := c.Container(ctx, logrus.NewEntry(logrus.New()), pod, &spec.Containers[0], new(api.Options))
+				return err
+			},
+			expKeychain: keychain,
+			expIndex:    "localhost:5000/version-checker#test-namespace/pull-secret",
+		},
+		"template lookups use the template's pull secrets": {
+			pullSecrets: &fakePullSecrets{keychain: keychain, id: "test-namespace/pull-secret"},
+			check: func(c *Checker, ctx context.Context) error {
+				_, err := c.Template(ctx, logrus.NewEntry(logrus.New()), "test-namespace", &spec, &spec.Containers[0], new(api.Options))
+				return err
+			},
+			expKeychain: keychain,
+			expIndex:    "localhost:5000/version-checker#test-namespace/pull-secret",
+		},
+		"pods without pull secrets use the client's credentials": {
+			pullSecrets: &fakePullSecrets{},
+			check: func(c *Checker, ctx context.Context) error {
+				_, err := c.Container(ctx, logrus.NewEntry(logrus.New()), pod, &spec.Containers[0], new(api.Options))
+				return err
+			},
+			expIndex: "localhost:5000/version-checker",
+		},
+		"failing to resolve pull secrets fails the check": {
+			pullSecrets: &fakePullSecrets{err: errors.New("forbidden")},
+			check: func(c *Checker, ctx context.Context) error {
+				_, err := c.Container(ctx, logrus.NewEntry(logrus.New()), pod, &spec.Containers[0], new(api.Options))
+				return err
+			},
+			expErr: "failed to resolve image pull secrets: forbidden",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			searcher := &keychainSearch{FakeSearch: search.New().
+				With(&api.ImageTag{Tag: "v0.2.0", SHA: "sha:123"}, nil).
+				WithResolvedSHA("sha:123", nil)}
+			checker := NewWithPullSecrets(searcher, test.pullSecrets)
+
+			err := test.check(checker, context.TODO())
+			if len(test.expErr) > 0 {
+				assert.EqualError(t, err, test.expErr)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, "test-namespace", test.pullSecrets.namespace)
+			assert.Equal(t, spec.ImagePullSecrets, test.pullSecrets.spec.ImagePullSecrets)
+			assert.Equal(t, test.expKeychain, searcher.keychain)
+			assert.Equal(t, test.expIndex, searcher.index)
+		})
+	}
+}
+
+// fakePullSecrets returns the keychain, recording the pod spec it was
+// resolved for.
+type fakePullSecrets struct {
+	keychain authn.Keychain
+	id       string
+	err      error
+
+	namespace string
+	spec      *corev1.PodSpec
+}
+
+func (f *fakePullSecrets) Keychain(_ context.Context, namespace string, spec *corev1.PodSpec) (authn.Keychain, string, error) {
+	f.namespace, f.spec = namespace, spec
+	return f.keychain, f.id, f.err
+}
+
+// keychainSearch records the keychain of the context that the latest image
+// was searched with, and the cache index of the image.
+type keychainSearch struct {
+	*search.FakeSearch
+	keychain authn.Keychain
+	index    string
+}
+
+func (k *keychainSearch) LatestImage(ctx context.Context, imageURL string, opts *api.Options) (*api.ImageTag, error) {
+	k.keychain = util.ContextKeychain(ctx, nil)
+	k.index = util.ContextCacheIndex(ctx, imageURL)
+	return k.FakeSearch.LatestImage(ctx, imageURL, opts)
+}
+
+func TestNodePlatform(t *testing.T) {
+	arm64 := &api.Platform{OS: "linux", Architecture: "arm64"}
+	amd64 := &api.Platform{OS: "linux", Architecture: "amd64"}
+
+	tests := map[string]struct {
+		nodeName      string
+		nodePlatforms *fakeNodePlatforms
+		opts          *api.Options
+		expPlatform   *api.Platform
+	}{
+		"without node platforms, tags of every platform are considered": {
+			nodeName: "node-1",
+			opts:     new(api.Options),
+		},
+		"the platform of the pod's node is used": {
+			nodeName:      "node-1",
+			nodePlatforms: &fakeNodePlatforms{platform: arm64},
+			opts:          new(api.Options),
+			expPlatform:   arm64,
+		},
+		"the platform of the options takes precedence": {
+			nodeName:      "node-1",
+			nodePlatforms: &fakeNodePlatforms{platform: arm64},
+			opts:          &api.Options{Platform: amd64},
+			expPlatform:   amd64,
+		},
+		"pods not scheduled yet consider tags of every platform": {
+			nodePlatforms: &fakeNodePlatforms{platform: arm64},
+			opts:          new(api.Options),
+		},
+		"failing to resolve the platform considers tags of every platform": {
+			nodeName:      "node-1",
+			nodePlatforms: &fakeNodePlatforms{err: errors.New("forbidden")},
+			opts:          new(api.Options),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				Spec: corev1.PodSpec{
+					NodeName:   test.nodeName,
+					Containers: []corev1.Container{{Name: "test-name", Image: "localhost:5000/version-checker:v0.2.0"}},
+				},
+				Status: corev1.PodStatus{
+					ContainerStatuses: []corev1.ContainerStatus{{Name: "test-name", ImageID: "localhost:5000/version-checker@sha:123"}},
+				},
+			}
+
+			checker := New(search.New().With(&api.ImageTag{Tag: "v0.2.0", SHA: "sha:123"}, nil))
+			if test.nodePlatforms != nil {
+				checker = checker.WithNodePlatforms(test.nodePlatforms)
+			}
+
+			_, err := checker.Container(context.TODO(), logrus.NewEntry(logrus.New()), pod, &pod.Spec.Containers[0], test.opts)
+			require.NoError(t, err)
+			assert.Equal(t, test.expPlatform, test.opts.Platform)
+		})
+	}
+}
+
+// fakeNodePlatforms returns the platform of every node.
+type fakeNodePlatforms struct {
+	platform *api.Platform
+	err      error
+}
+
+func (f *fakeNodePlatforms) Platform(_ context.Context, _ string) (*api.Platform, error) {
+	return f.platform, f.err
+}
+
+func TestTagDrift(t *testing.T) {
+	tests := map[string]struct {
+		imageURL string
+		tag      *api.ImageTag
+		tagErr   error
+		expDrift *api.TagDrift
+	}{
+		"same digest has not drifted": {
+			imageURL: "localhost:5000/version-checker:v0.2.0",
+			tag:      &api.ImageTag{Tag: "v0.2.0", SHA: "sha:123"},
+			expDrift: &api.TagDrift{Tag: "v0.2.0"},
+		},
+		"re-pushed tag has drifted": {
+			imageURL: "localhost:5000/version-checker:stable",
+			tag:      &api.ImageTag{Tag: "stable", SHA: "sha:456"},
+			expDrift: &api.TagDrift{Tag: "stable", Drifted: true},
+		},
+		"image without a tag runs latest": {
+			imageURL: "localhost:5000/version-checker",
+			tag:      &api.ImageTag{Tag: "latest", SHA: "sha:456"},
+			expDrift: &api.TagDrift{Tag: "latest", Drifted: true},
+		},
+		"running digest of a child of the manifest list has not drifted": {
+			imageURL: "localhost:5000/version-checker:stable",
+			tag: &api.ImageTag{Tag: "stable", SHA: "sha:abc", Children: []*api.ImageTag{
+				{SHA: "sha:456", OS: "linux", Architecture: "amd64"},
+				{SHA: "sha:123", OS: "linux", Architecture: "arm64"},
+			}},
+			expDrift: &api.TagDrift{Tag: "stable"},
+		},
+		"manifest list without a digest of its own is unknown": {
+			imageURL: "localhost:5000/version-checker:stable",
+			tag:      &api.ImageTag{Tag: "stable", Children: []*api.ImageTag{{SHA: "sha:456"}}},
+		},
+		"image pinned by digest has no drift": {
+			imageURL: "localhost:5000/version-checker:stable@sha:123",
+			tag:      &api.ImageTag{Tag: "stable", SHA: "sha:456"},
+		},
+		"tag missing from the registry is unknown": {
+			imageURL: "localhost:5000/version-checker:stable",
+		},
+		"failing to get the tag is unknown": {
+			imageURL: "localhost:5000/version-checker:stable",
+			tagErr:   errors.New("unauthorized"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "test-name", Image: test.imageURL}},
+				},
+				Status: corev1.PodStatus{
+					ContainerStatuses: []corev1.ContainerStatus{{Name: "test-name", ImageID: "localhost:5000/version-checker@sha:123"}},
+				},
+			}
+
+			checker := New(search.New().
+				With(&api.ImageTag{Tag: "v0.3.0", SHA: "sha:789"}, nil).
+				WithTag(test.tag, test.tagErr))
+
+			result, err := checker.Container(context.TODO(), logrus.NewEntry(logrus.New()), pod, &pod.Spec.Containers[0], new(api.Options))
+			require.NoError(t, err)
+			assert.Equal(t, test.expDrift, result.TagDrift)
+		})
+	}
+}
+
 func TestContainerStatusImageSHA(t *testing.T) {
 	tests := map[string]struct {
 		status []corev1.ContainerStatus
@@ -453,17 +799,31 @@ func TestIsLatestOrEmptyTag(t *testing.T) {
 }
 
 func TestIsLatestSemver(t *testing.T) {
+	manifestList := func(tag string) *api.ImageTag {
+		return &api.ImageTag{
+			Tag: tag,
+			Children: []*api.ImageTag{
+				{SHA: "amd64-new", OS: "linux", Architecture: "amd64"},
+				{SHA: "arm64-new", OS: "linux", Architecture: "arm64"},
+			},
+		}
+	}
+
 	tests := map[string]struct {
-		imageURL, currentSHA string
-		currentImage         *semver.SemVer
+		imageURL, currentTag string
+		currentSHA           string
+		currentImage         scheme.Version
+		platform             *api.Platform
 		searchResp           *api.ImageTag
+		tagResp              *api.ImageTag
 		expLatestImage       *api.ImageTag
 		expIsLatest          bool
+		expPlatform          string
 	}{
 		"if current semver is less, then is less": {
 			imageURL:     "docker.io",
 			currentSHA:   "123",
-			currentImage: semver.Parse("v1.2.3"),
+			currentImage: scheme.SemVer{}.Parse("v1.2.3"),
 			searchResp: &api.ImageTag{
 				Tag: "v1.2.4",
 				SHA: "456",
@@ -477,7 +837,7 @@ func TestIsLatestSemver(t *testing.T) {
 		"if current semver is equal, but semver missmatch, then false": {
 			imageURL:     "docker.io",
 			currentSHA:   "123",
-			currentImage: semver.Parse("v1.2.4"),
+			currentImage: scheme.SemVer{}.Parse("v1.2.4"),
 			searchResp: &api.ImageTag{
 				Tag: "v1.2.4",
 				SHA: "456",
@@ -491,7 +851,7 @@ func TestIsLatestSemver(t *testing.T) {
 		"if current semver is equal, and semver match, then true": {
 			imageURL:     "docker.io",
 			currentSHA:   "456",
-			currentImage: semver.Parse("v1.2.4"),
+			currentImage: scheme.SemVer{}.Parse("v1.2.4"),
 			searchResp: &api.ImageTag{
 				Tag: "v1.2.4",
 				SHA: "456",
@@ -505,7 +865,7 @@ func TestIsLatestSemver(t *testing.T) {
 		"if current semver is more, then true": {
 			imageURL:     "docker.io",
 			currentSHA:   "123",
-			currentImage: semver.Parse("v1.2.5"),
+			currentImage: scheme.SemVer{}.Parse("v1.2.5"),
 			searchResp: &api.ImageTag{
 				Tag: "v1.2.4",
 				SHA: "456",
@@ -516,16 +876,79 @@ func TestIsLatestSemver(t *testing.T) {
 			},
 			expIsLatest: true,
 		},
+		"if current semver is equal, and a child of the manifest list matches, then true with its platform": {
+			imageURL:       "docker.io",
+			currentSHA:     "arm64-new",
+			currentImage:   scheme.SemVer{}.Parse("v1.2.4"),
+			searchResp:     manifestList("v1.2.4"),
+			expLatestImage: manifestList("v1.2.4"),
+			expIsLatest:    true,
+			expPlatform:    "linux/arm64",
+		},
+		"if current semver is equal, but the child of the platform was re-pushed, then false with its digest": {
+			imageURL:       "docker.io",
+			currentSHA:     "arm64-old",
+			currentImage:   scheme.SemVer{}.Parse("v1.2.4"),
+			platform:       &api.Platform{OS: "linux", Architecture: "arm64"},
+			searchResp:     manifestList("v1.2.4"),
+			expLatestImage: manifestList("v1.2.4@arm64-new"),
+			expIsLatest:    false,
+			expPlatform:    "linux/arm64",
+		},
+		"if current semver is equal, but re-pushed, then false with the digest of the running tag's platform": {
+			imageURL:     "docker.io",
+			currentTag:   "v1.2.4",
+			currentSHA:   "arm64-old",
+			currentImage: scheme.SemVer{}.Parse("v1.2.4"),
+			platform:     &api.Platform{OS: "linux", Architecture: "amd64"},
+			searchResp:   manifestList("v1.2.4"),
+			tagResp: &api.ImageTag{
+				Tag: "v1.2.4",
+				Children: []*api.ImageTag{
+					{SHA: "amd64-old", OS: "linux", Architecture: "amd64"},
+					{SHA: "arm64-old", OS: "linux", Architecture: "arm64"},
+				},
+			},
+			expLatestImage: manifestList("v1.2.4@arm64-new"),
+			expIsLatest:    false,
+			expPlatform:    "linux/arm64",
+		},
+		"if current semver is equal, but re-pushed without a known platform, then false without a digest": {
+			imageURL:       "docker.io",
+			currentSHA:     "arm64-old",
+			currentImage:   scheme.SemVer{}.Parse("v1.2.4"),
+			searchResp:     manifestList("v1.2.4"),
+			expLatestImage: manifestList("v1.2.4"),
+			expIsLatest:    false,
+		},
+		"if current semver is equal, but re-pushed without a known platform, then false with the manifest list's digest": {
+			imageURL:     "docker.io",
+			currentSHA:   "arm64-old",
+			currentImage: scheme.SemVer{}.Parse("v1.2.4"),
+			searchResp: func() *api.ImageTag {
+				tag := manifestList("v1.2.4")
+				tag.SHA = "list-new"
+				return tag
+			}(),
+			expLatestImage: func() *api.ImageTag {
+				tag := manifestList("v1.2.4@list-new")
+				tag.SHA = "list-new"
+				return tag
+			}(),
+			expIsLatest: false,
+		},
 	}
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			checker := New(search.New().With(test.searchResp, nil))
-			latestImage, isLatest, err := checker.isLatestSemver(context.TODO(), test.imageURL, test.currentSHA, test.currentImage, nil)
+			checker := New(search.New().With(test.searchResp, nil).WithTag(test.tagResp, nil))
+			latestImage, isLatest, platform, err := checker.isLatestSemver(context.TODO(), test.imageURL, test.currentTag, test.currentSHA, test.currentImage, &api.Options{Platform: test.platform})
 			if err != nil {
 				t.Fatal(err)
 			}
 
+			assert.Equal(t, test.expPlatform, platform)
+
 			if !reflect.DeepEqual(latestImage, test.expLatestImage) {
 				t.Errorf("got unexpected latest image, exp=%v got=%v",
 					test.expLatestImage, latestImage)
@@ -542,7 +965,10 @@ func TestIsLatestSemver(t *testing.T) {
 func TestIsLatestSHA(t *testing.T) {
 	tests := map[string]struct {
 		imageURL, currentSHA string
+		platform             *api.Platform
 		searchResp           *api.ImageTag
+		tagResp              *api.ImageTag
+		tagErr               error
 		expResult            *Result
 	}{
 		"if SHA not equal, then should be not equal": {
@@ -626,12 +1052,118 @@ func TestIsLatestSHA(t *testing.T) {
 				ImageURL:       "docker.io",
 			},
 		},
+		"if child SHA equal, then should be equal with its platform": {
+			imageURL:   "docker.io",
+			currentSHA: "123",
+			searchResp: &api.ImageTag{
+				SHA: "abc",
+				Tag: "foo",
+				Children: []*api.ImageTag{
+					{SHA: "456", OS: "linux", Architecture: "amd64"},
+					{SHA: "123", OS: "linux", Architecture: "arm64"},
+				},
+			},
+			expResult: &Result{
+				CurrentVersion: "123",
+				LatestVersion:  "foo@123",
+				IsLatest:       true,
+				ImageURL:       "docker.io",
+				Platform:       "linux/arm64",
+			},
+		},
+		"if child SHA not equal, then should compare to the child of the platform": {
+			imageURL:   "docker.io",
+			currentSHA: "123",
+			platform:   &api.Platform{OS: "linux", Architecture: "arm64"},
+			searchResp: &api.ImageTag{
+				SHA: "abc",
+				Tag: "foo",
+				Children: []*api.ImageTag{
+					{SHA: "456", OS: "linux", Architecture: "amd64"},
+					{SHA: "789", OS: "linux", Architecture: "arm64"},
+				},
+			},
+			expResult: &Result{
+				CurrentVersion: "123",
+				LatestVersion:  "foo@789",
+				IsLatest:       false,
+				ImageURL:       "docker.io",
+				Platform:       "linux/arm64",
+			},
+		},
+		"if child SHA not equal, then should compare to the child of the running tag's platform": {
+			imageURL:   "docker.io",
+			currentSHA: "123",
+			platform:   &api.Platform{OS: "linux", Architecture: "amd64"},
+			searchResp: &api.ImageTag{
+				SHA: "abc",
+				Tag: "foo",
+				Children: []*api.ImageTag{
+					{SHA: "456", OS: "linux", Architecture: "amd64"},
+					{SHA: "789", OS: "linux", Architecture: "arm64"},
+				},
+			},
+			tagResp: &api.ImageTag{
+				Tag: "latest",
+				Children: []*api.ImageTag{
+					{SHA: "012", OS: "linux", Architecture: "amd64"},
+					{SHA: "123", OS: "linux", Architecture: "arm64"},
+				},
+			},
+			expResult: &Result{
+				CurrentVersion: "123",
+				LatestVersion:  "foo@789",
+				IsLatest:       false,
+				ImageURL:       "docker.io",
+				Platform:       "linux/arm64",
+			},
+		},
+		"if the running tag fails to be looked up, then should compare to the child of the platform": {
+			imageURL:   "docker.io",
+			currentSHA: "123",
+			platform:   &api.Platform{OS: "linux", Architecture: "arm64"},
+			searchResp: &api.ImageTag{
+				SHA: "abc",
+				Tag: "foo",
+				Children: []*api.ImageTag{
+					{SHA: "456", OS: "linux", Architecture: "amd64"},
+					{SHA: "789", OS: "linux", Architecture: "arm64"},
+				},
+			},
+			tagErr: errors.New("not found"),
+			expResult: &Result{
+				CurrentVersion: "123",
+				LatestVersion:  "foo@789",
+				IsLatest:       false,
+				ImageURL:       "docker.io",
+				Platform:       "linux/arm64",
+			},
+		},
+		"if child SHA not equal, and no child of the platform, then should compare to the parent SHA": {
+			imageURL:   "docker.io",
+			currentSHA: "123",
+			platform:   &api.Platform{OS: "linux", Architecture: "s390x"},
+			searchResp: &api.ImageTag{
+				SHA: "abc",
+				Tag: "foo",
+				Children: []*api.ImageTag{
+					{SHA: "456", OS: "linux", Architecture: "amd64"},
+					{SHA: "789", OS: "linux", Architecture: "arm64"},
+				},
+			},
+			expResult: &Result{
+				CurrentVersion: "123",
+				LatestVersion:  "foo@abc",
+				IsLatest:       false,
+				ImageURL:       "docker.io",
+			},
+		},
 	}
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			checker := New(search.New().With(test.searchResp, nil))
-			result, err := checker.isLatestSHA(context.TODO(), test.imageURL, test.currentSHA, nil)
+			checker := New(search.New().With(test.searchResp, nil).WithTag(test.tagResp, test.tagErr))
+			result, err := checker.isLatestSHA(context.TODO(), test.imageURL, "", test.currentSHA, &api.Options{Platform: test.platform})
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -644,6 +1176,94 @@ func TestIsLatestSHA(t *testing.T) {
 	}
 }
 
+func TestOverrideImageURL(t *testing.T) {
+	mirrors := []Mirror{
+		{Prefix: "harbor.corp/dockerhub-proxy", Upstream: "docker.io"},
+		{Prefix: "harbor.corp/quay-proxy/", Upstream: "quay.io/"},
+		{Regex: regexp.MustCompile(`^artifactory\.corp/(ghcr|gcr)-remote`), Upstream: "${1}.io"},
+		{Prefix: "harbor.corp/ghcr-proxy", Upstream: "ghcr.io", Transparent: true},
+		{Prefix: "mirror.corp/dockerhub", Upstream: "docker.io", Transparent: true},
+	}
+
+	tests := map[string]struct {
+		imageURL     string
+		opts         *api.Options
+		expURL       string
+		expMirrorURL string
+	}{
+		"image not pulled through a mirror is unchanged": {
+			imageURL: "harbor.corp/team/app",
+			opts:     new(api.Options),
+			expURL:   "harbor.corp/team/app",
+		},
+		"image pulled through a mirror is rewritten upstream": {
+			imageURL:     "harbor.corp/dockerhub-proxy/library/nginx",
+			opts:         new(api.Options),
+			expURL:       "docker.io/library/nginx",
+			expMirrorURL: "harbor.corp/dockerhub-proxy/library/nginx",
+		},
+		"trailing slashes of mirrors are ignored": {
+			imageURL:     "harbor.corp/quay-proxy/jetstack/version-checker",
+			opts:         new(api.Options),
+			expURL:       "quay.io/jetstack/version-checker",
+			expMirrorURL: "harbor.corp/quay-proxy/jetstack/version-checker",
+		},
+		"prefix only matches whole path segments": {
+			imageURL: "harbor.corp/dockerhub-proxy-old/library/nginx",
+			opts:     new(api.Options),
+			expURL:   "harbor.corp/dockerhub-proxy-old/library/nginx",
+		},
+		"regex mirror is rewritten with its submatches": {
+			imageURL:     "artifactory.corp/ghcr-remote/jetstack/version-checker",
+			opts:         new(api.Options),
+			expURL:       "ghcr.io/jetstack/version-checker",
+			expMirrorURL: "artifactory.corp/ghcr-remote/jetstack/version-checker",
+		},
+		"regex mirror not matching is unchanged": {
+			imageURL: "artifactory.corp/quay-remote/jetstack/version-checker",
+			opts:     new(api.Options),
+			expURL:   "artifactory.corp/quay-remote/jetstack/version-checker",
+		},
+		"override URL is preferred": {
+			imageURL: "harbor.corp/dockerhub-proxy/library/nginx",
+			opts:     &api.Options{OverrideURL: stringp("ghcr.io/nginx/nginx")},
+			expURL:   "ghcr.io/nginx/nginx",
+		},
+		"image of a transparent mirror's upstream is looked up as named": {
+			imageURL:     "ghcr.io/jetstack/version-checker",
+			opts:         new(api.Options),
+			expURL:       "ghcr.io/jetstack/version-checker",
+			expMirrorURL: "harbor.corp/ghcr-proxy/jetstack/version-checker",
+		},
+		"short Docker Hub image matches a transparent mirror of docker.io": {
+			imageURL:     "nginx",
+			opts:         new(api.Options),
+			expURL:       "nginx",
+			expMirrorURL: "mirror.corp/dockerhub/library/nginx",
+		},
+		"Docker Hub image of an organisation matches a transparent mirror of docker.io": {
+			imageURL:     "jetstack/version-checker",
+			opts:         new(api.Options),
+			expURL:       "jetstack/version-checker",
+			expMirrorURL: "mirror.corp/dockerhub/jetstack/version-checker",
+		},
+		"image of another registry doesn't match a transparent mirror": {
+			imageURL: "quay.io/jetstack/version-checker",
+			opts:     new(api.Options),
+			expURL:   "quay.io/jetstack/version-checker",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			checker := New(search.New()).WithMirrors(mirrors)
+			url, mirrorURL := checker.overrideImageURL(logrus.NewEntry(logrus.New()), test.imageURL, test.opts)
+			assert.Equal(t, test.expURL, url)
+			assert.Equal(t, test.expMirrorURL, mirrorURL)
+		})
+	}
+}
+
 func TestURLAndTagFromImage(t *testing.T) {
 	tests := map[string]struct {
 		image             string
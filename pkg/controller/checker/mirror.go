@@ -0,0 +1,91 @@
+package checker
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Mirror rewrites the images pulled through a mirror, such as a registry's
+// pull-through cache, to the upstream image whose versions are looked up.
+// Either Prefix or Regex matches the images of the mirror.
+type Mirror struct {
+	// Prefix is the image URL prefix of the mirror, such as
+	// harbor.corp/dockerhub-proxy.
+	Prefix string
+
+	// Regex matches the image URLs of the mirror, such as
+	// ^harbor\.corp/(quay|ghcr)-proxy.
+	Regex *regexp.Regexp
+
+	// Upstream replaces the prefix, or the match of the regex, such as
+	// docker.io. Upstreams of regexes may refer to their submatches, such as
+	// ${1}.io.
+	Upstream string
+
+	// Transparent mirrors are configured in the container runtime, such as
+	// containerd's registry mirrors, so their images are named after the
+	// Upstream. They are looked up as named, and reported as pulled through
+	// the Prefix.
+	Transparent bool
+}
+
+// mirrorURL returns the image URL of the transparent mirror that the image
+// URL is pulled through, and whether it is. Images are matched by their full
+// name, as the container runtime names them, so nginx is matched as
+// docker.io/library/nginx.
+func (m Mirror) mirrorURL(imageURL string) (string, bool) {
+	imageURL = fullImageURL(imageURL)
+	prefix := strings.TrimSuffix(m.Prefix, "/")
+	upstream := strings.TrimSuffix(m.Upstream, "/")
+
+	switch {
+	case imageURL == upstream:
+		return prefix, true
+	case strings.HasPrefix(imageURL, upstream+"/"):
+		return prefix + strings.TrimPrefix(imageURL, upstream), true
+	default:
+		return imageURL, false
+	}
+}
+
+// fullImageURL returns the image URL with its registry host, as container
+// runtimes name Docker Hub images.
+func fullImageURL(imageURL string) string {
+	host, _, ok := strings.Cut(imageURL, "/")
+	switch {
+	case !ok:
+		return "docker.io/library/" + imageURL
+	case host == "index.docker.io":
+		return "docker.io" + strings.TrimPrefix(imageURL, host)
+	case !strings.ContainsAny(host, ".:") && host != "localhost":
+		return "docker.io/" + imageURL
+	default:
+		return imageURL
+	}
+}
+
+// rewrite returns the upstream image URL of the image URL, and whether it is
+// pulled through the mirror. The prefix only matches whole path segments,
+// while the regex replaces its first match.
+func (m Mirror) rewrite(imageURL string) (string, bool) {
+	if m.Regex != nil {
+		match := m.Regex.FindStringSubmatchIndex(imageURL)
+		if match == nil {
+			return imageURL, false
+		}
+		upstream := m.Regex.ExpandString(nil, m.Upstream, imageURL, match)
+		return imageURL[:match[0]] + string(upstream) + imageURL[match[1]:], true
+	}
+
+	prefix := strings.TrimSuffix(m.Prefix, "/")
+	upstream := strings.TrimSuffix(m.Upstream, "/")
+
+	switch {
+	case imageURL == prefix:
+		return upstream, true
+	case strings.HasPrefix(imageURL, prefix+"/"):
+		return upstream + strings.TrimPrefix(imageURL, prefix), true
+	default:
+		return imageURL, false
+	}
+}
@@ -13,6 +13,9 @@ var _ search.Searcher = &FakeSearch{}
 type FakeSearch struct {
 	latestImageF     func() (*api.ImageTag, error)
 	resolveSHAToTagF func() (string, error)
+	resolveTagToSHAF func() (string, error)
+	tagF             func() (*api.ImageTag, error)
+	distanceF        func() (*api.VersionDistance, error)
 }
 
 func New() *FakeSearch {
@@ -23,6 +26,15 @@ func New() *FakeSearch {
 		resolveSHAToTagF: func() (string, error) {
 			return "", nil
 		},
+		resolveTagToSHAF: func() (string, error) {
+			return "", nil
+		},
+		tagF: func() (*api.ImageTag, error) {
+			return nil, nil
+		},
+		distanceF: func() (*api.VersionDistance, error) {
+			return new(api.VersionDistance), nil
+		},
 	}
 }
 
@@ -33,6 +45,27 @@ func (f *FakeSearch) With(image *api.ImageTag, err error) *FakeSearch {
 	return f
 }
 
+func (f *FakeSearch) WithResolvedSHA(sha string, err error) *FakeSearch {
+	f.resolveTagToSHAF = func() (string, error) {
+		return sha, err
+	}
+	return f
+}
+
+func (f *FakeSearch) WithTag(tag *api.ImageTag, err error) *FakeSearch {
+	f.tagF = func() (*api.ImageTag, error) {
+		return tag, err
+	}
+	return f
+}
+
+func (f *FakeSearch) WithDistance(distance *api.VersionDistance, err error) *FakeSearch {
+	f.distanceF = func() (*api.VersionDistance, error) {
+		return distance, err
+	}
+	return f
+}
+
 func (f *FakeSearch) LatestImage(context.Context, string, *api.Options) (*api.ImageTag, error) {
 	return f.latestImageF()
 }
@@ -40,5 +73,18 @@ func (f *FakeSearch) LatestImage(context.Context, string, *api.Options) (*api.Im
 func (f *FakeSearch) ResolveSHAToTag(ctx context.Context, imageURL string, imageSHA string) (string, error) {
 	return f.resolveSHAToTagF()
 }
+
+func (f *FakeSearch) ResolveTagToSHA(ctx context.Context, imageURL string, tag string, platform *api.Platform) (string, error) {
+	return f.resolveTagToSHAF()
+}
+
+func (f *FakeSearch) Tag(ctx context.Context, imageURL string, tag string) (*api.ImageTag, error) {
+	return f.tagF()
+}
+
+func (f *FakeSearch) Distance(context.Context, string, string, *api.ImageTag, *api.Options) (*api.VersionDistance, error) {
+	return f.distanceF()
+}
+
 func (f *FakeSearch) Run(time.Duration) {
 }
@@ -0,0 +1,211 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/metrics"
+)
+
+// MirrorPair is an image mirrored into a registry, and the upstream image it
+// is mirrored from.
+type MirrorPair struct {
+	Mirror   string
+	Upstream string
+}
+
+// ParseMirrorPairs parses mirror pairs of the form "<mirror>=<upstream>",
+// e.g. "harbor.corp/library/nginx=docker.io/library/nginx".
+func ParseMirrorPairs(values []string) ([]MirrorPair, error) {
+	var pairs []MirrorPair
+
+	for _, value := range values {
+		mirror, upstream, ok := strings.Cut(value, "=")
+		if !ok || len(mirror) == 0 || len(upstream) == 0 {
+			return nil, fmt.Errorf("invalid mirror check %q, must be of the form <mirror>=<upstream>", value)
+		}
+		pairs = append(pairs, MirrorPair{Mirror: mirror, Upstream: upstream})
+	}
+
+	return pairs, nil
+}
+
+// TagLister lists the tags of an image, such as client.Client.
+type TagLister interface {
+	Tags(ctx context.Context, imageURL string) ([]api.ImageTag, error)
+}
+
+// MirrorScheduler periodically compares the tags of mirrored images to those
+// of their upstream, reporting the upstream tags which are missing from the
+// mirror, or have a different digest in it.
+type MirrorScheduler struct {
+	log      *logrus.Entry
+	tags     TagLister
+	metrics  *metrics.Metrics
+	interval time.Duration
+	pairs    []MirrorPair
+}
+
+func NewMirrorReconciler(
+	log *logrus.Entry,
+	tags TagLister,
+	metrics *metrics.Metrics,
+	interval time.Duration,
+	pairs []MirrorPair,
+) *MirrorScheduler {
+	// If no pairs are given, return nil to indicate disabled
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	return &MirrorScheduler{
+		log:      log.WithField("controller", "mirror"),
+		tags:     tags,
+		metrics:  metrics,
+		interval: interval,
+		pairs:    pairs,
+	}
+}
+
+// Start checks the mirrors on startup, and then every interval until the
+// context is cancelled.
+func (s *MirrorScheduler) Start(ctx context.Context) error {
+	s.log.WithField("interval", s.interval).WithField("mirrors", len(s.pairs)).
+		Info("MirrorScheduler started")
+
+	s.reconcile(ctx)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.log.Info("MirrorScheduler stopping")
+			return nil
+		case <-ticker.C:
+			s.reconcile(ctx)
+		}
+	}
+}
+
+// reconcile checks every mirror. A mirror which fails to be checked keeps
+// the result of its last check.
+func (s *MirrorScheduler) reconcile(ctx context.Context) {
+	for _, pair := range s.pairs {
+		log := s.log.WithFields(logrus.Fields{"mirror": pair.Mirror, "upstream": pair.Upstream})
+
+		missing, mismatched, err := s.check(ctx, pair)
+		if err != nil {
+			log.WithError(err).Error("Failed to check mirror")
+			continue
+		}
+
+		s.metrics.SetMirrorMissingTags(pair.Mirror, pair.Upstream, len(missing), len(mismatched))
+
+		if len(missing) > 0 || len(mismatched) > 0 {
+			log.Debugf("mirror is missing tags %v, and has different digests of tags %v", missing, mismatched)
+		}
+		log.WithFields(logrus.Fields{
+			"missingTags":    len(missing),
+			"mismatchedTags": len(mismatched),
+		}).Info("Mirror check complete")
+	}
+}
+
+// check returns the upstream tags of the pair which are missing from the
+// mirror, and those whose digest in the mirror is different, sorted. Mirrors
+// usually only sync tags from some point on, so upstream tags older than the
+// oldest tag the mirror has are not missing from it.
+func (s *MirrorScheduler) check(ctx context.Context, pair MirrorPair) ([]string, []string, error) {
+	upstreamTags, err := s.tags.Tags(ctx, pair.Upstream)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list upstream tags: %w", err)
+	}
+
+	mirrorTags, err := s.tags.Tags(ctx, pair.Mirror)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list mirror tags: %w", err)
+	}
+
+	upstreams, mirrored := tagsByName(upstreamTags), tagsByName(mirrorTags)
+	oldest := oldestMirrored(upstreams, mirrored)
+
+	var missing, mismatched []string
+	for name, upstream := range upstreams {
+		mirror, ok := mirrored[name]
+		switch {
+		case !ok && !upstream.Timestamp.IsZero() && upstream.Timestamp.Before(oldest):
+			continue
+		case !ok:
+			missing = append(missing, name)
+		case digestsDiffer(upstream, mirror):
+			mismatched = append(mismatched, name)
+		}
+	}
+
+	slices.Sort(missing)
+	slices.Sort(mismatched)
+
+	return missing, mismatched, nil
+}
+
+// tagsByName returns the tags by their name, skipping those without one.
+func tagsByName(tags []api.ImageTag) map[string]*api.ImageTag {
+	byName := make(map[string]*api.ImageTag, len(tags))
+	for i := range tags {
+		if _, ok := byName[tags[i].Tag]; len(tags[i].Tag) > 0 && !ok {
+			byName[tags[i].Tag] = &tags[i]
+		}
+	}
+	return byName
+}
+
+// oldestMirrored returns the upstream timestamp of the oldest upstream tag the
+// mirror has. Upstream timestamps are used, as mirrors may timestamp tags by
+// when they were synced. It is zero if none are known.
+func oldestMirrored(upstreams, mirrored map[string]*api.ImageTag) time.Time {
+	var oldest time.Time
+	for name, upstream := range upstreams {
+		if _, ok := mirrored[name]; !ok || upstream.Timestamp.IsZero() {
+			continue
+		}
+		if oldest.IsZero() || upstream.Timestamp.Before(oldest) {
+			oldest = upstream.Timestamp
+		}
+	}
+	return oldest
+}
+
+// digestsDiffer returns whether the digests of two tags are known to be
+// different. Manifest lists without a digest of their own are compared by
+// the digests of their children.
+func digestsDiffer(a, b *api.ImageTag) bool {
+	if len(a.SHA) > 0 && len(b.SHA) > 0 {
+		return a.SHA != b.SHA
+	}
+
+	aChildren, bChildren := childDigests(a), childDigests(b)
+	if len(aChildren) == 0 || len(bChildren) == 0 {
+		return false
+	}
+
+	return !maps.Equal(aChildren, bChildren)
+}
+
+func childDigests(tag *api.ImageTag) map[string]struct{} {
+	digests := make(map[string]struct{}, len(tag.Children))
+	for _, child := range tag.Children {
+		if len(child.SHA) > 0 {
+			digests[child.SHA] = struct{}{}
+		}
+	}
+	return digests
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/metrics"
+)
+
+type fakeTagLister map[string][]api.ImageTag
+
+func (f fakeTagLister) Tags(_ context.Context, imageURL string) ([]api.ImageTag, error) {
+	tags, ok := f[imageURL]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return tags, nil
+}
+
+func TestParseMirrorPairs(t *testing.T) {
+	pairs, err := ParseMirrorPairs([]string{"harbor.corp/library/nginx=docker.io/library/nginx"})
+	require.NoError(t, err)
+	assert.Equal(t, []MirrorPair{{Mirror: "harbor.corp/library/nginx", Upstream: "docker.io/library/nginx"}}, pairs)
+
+	_, err = ParseMirrorPairs([]string{"harbor.corp/library/nginx"})
+	assert.EqualError(t, err, `invalid mirror check "harbor.corp/library/nginx", must be of the form <mirror>=<upstream>`)
+}
+
+func TestMirrorCheck(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2024, time.January, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tags := fakeTagLister{
+		"docker.io/library/nginx": {
+			{Tag: "1.27.0", SHA: "sha:1"},
+			{Tag: "1.27.1", SHA: "sha:2"},
+			{Tag: "1.27.2", SHA: "sha:3"},
+			{Tag: "1.28.0", Children: []*api.ImageTag{{SHA: "sha:4"}, {SHA: "sha:5"}}},
+			{Tag: "1.28.1", Children: []*api.ImageTag{{SHA: "sha:6"}}},
+			{Tag: "", SHA: "sha:7"},
+		},
+		"harbor.corp/library/nginx": {
+			{Tag: "1.27.0", SHA: "sha:1"},
+			{Tag: "1.27.1", SHA: "sha:other"},
+			{Tag: "1.28.0", Children: []*api.ImageTag{{SHA: "sha:5"}, {SHA: "sha:4"}}},
+			{Tag: "1.28.1", Children: []*api.ImageTag{{SHA: "sha:other"}}},
+			{Tag: "mirror-only", SHA: "sha:8"},
+		},
+		"docker.io/library/redis": {
+			{Tag: "7.0.0", SHA: "sha:9", Timestamp: day(1)},
+			{Tag: "7.2.0", SHA: "sha:10", Timestamp: day(2)},
+			{Tag: "7.2.1", SHA: "sha:11", Timestamp: day(3)},
+			{Tag: "7.4.0", SHA: "sha:12", Timestamp: day(4)},
+			{Tag: "untimed", SHA: "sha:13"},
+		},
+		"harbor.corp/library/redis": {
+			// Synced later than upstream
+			{Tag: "7.2.0", SHA: "sha:10", Timestamp: day(10)},
+			{Tag: "mirror-only", SHA: "sha:14", Timestamp: day(1)},
+		},
+		"harbor.corp/library/empty": {},
+	}
+
+	tests := map[string]struct {
+		pair          MirrorPair
+		expMissing    []string
+		expMismatched []string
+		expErr        string
+	}{
+		"missing and mismatched tags": {
+			pair:          MirrorPair{Mirror: "harbor.corp/library/nginx", Upstream: "docker.io/library/nginx"},
+			expMissing:    []string{"1.27.2"},
+			expMismatched: []string{"1.27.1", "1.28.1"},
+		},
+		"in sync with itself": {
+			pair: MirrorPair{Mirror: "docker.io/library/nginx", Upstream: "docker.io/library/nginx"},
+		},
+		"upstream tags older than the mirror's oldest are not missing": {
+			pair:       MirrorPair{Mirror: "harbor.corp/library/redis", Upstream: "docker.io/library/redis"},
+			expMissing: []string{"7.2.1", "7.4.0", "untimed"},
+		},
+		"every upstream tag of an empty mirror is missing": {
+			pair:       MirrorPair{Mirror: "harbor.corp/library/empty", Upstream: "docker.io/library/redis"},
+			expMissing: []string{"7.0.0", "7.2.0", "7.2.1", "7.4.0", "untimed"},
+		},
+		"failed mirror lookup": {
+			pair:   MirrorPair{Mirror: "harbor.corp/library/missing", Upstream: "docker.io/library/nginx"},
+			expErr: "failed to list mirror tags: not found",
+		},
+		"failed upstream lookup": {
+			pair:   MirrorPair{Mirror: "harbor.corp/library/nginx", Upstream: "docker.io/library/missing"},
+			expErr: "failed to list upstream tags: not found",
+		},
+	}
+
+	log := logrus.NewEntry(logrus.New())
+	log.Logger.SetOutput(io.Discard)
+	m := metrics.New(log, prometheus.NewRegistry(), fake.NewFakeClient())
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := NewMirrorReconciler(log, tags, m, time.Hour, []MirrorPair{test.pair})
+
+			missing, mismatched, err := s.check(context.TODO(), test.pair)
+			if len(test.expErr) > 0 {
+				assert.EqualError(t, err, test.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expMissing, missing)
+			assert.Equal(t, test.expMismatched, mismatched)
+		})
+	}
+}
+
+func TestNewMirrorReconciler(t *testing.T) {
+	log := logrus.NewEntry(logrus.New())
+	assert.Nil(t, NewMirrorReconciler(log, fakeTagLister{}, nil, time.Hour, nil),
+		"Should return nil when no mirrors are checked")
+}
@@ -0,0 +1,47 @@
+package nodeplatform
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+// Resolver resolves the platform of Nodes from their well-known labels.
+type Resolver struct {
+	client k8sclient.Reader
+}
+
+// New constructs a new Resolver. Only the metadata of Nodes is got, so a
+// cached client only caches that of each Node.
+func New(client k8sclient.Reader) *Resolver {
+	return &Resolver{
+		client: client,
+	}
+}
+
+// Platform returns the platform of the Node, from its kubernetes.io/os and
+// kubernetes.io/arch labels. Nil if the Node has no architecture label.
+func (r *Resolver) Platform(ctx context.Context, nodeName string) (*api.Platform, error) {
+	node := new(metav1.PartialObjectMetadata)
+	node.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Node"))
+	if err := r.client.Get(ctx, types.NamespacedName{Name: nodeName}, node); err != nil {
+		return nil, fmt.Errorf("failed to get node %q: %w", nodeName, err)
+	}
+
+	arch := node.Labels[corev1.LabelArchStable]
+	if len(arch) == 0 {
+		return nil, nil
+	}
+
+	return &api.Platform{
+		OS:           api.OS(node.Labels[corev1.LabelOSStable]),
+		Architecture: api.Architecture(arch),
+	}, nil
+}
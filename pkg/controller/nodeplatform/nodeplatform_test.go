@@ -0,0 +1,56 @@
+package nodeplatform
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+func TestPlatform(t *testing.T) {
+	client := fake.NewClientBuilder().WithObjects(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "graviton", Labels: map[string]string{
+			corev1.LabelOSStable:   "linux",
+			corev1.LabelArchStable: "arm64",
+		}}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "unlabelled"}},
+	).Build()
+
+	tests := map[string]struct {
+		nodeName    string
+		expPlatform *api.Platform
+		expErr      string
+	}{
+		"platform from labels": {
+			nodeName:    "graviton",
+			expPlatform: &api.Platform{OS: "linux", Architecture: "arm64"},
+		},
+		"node without labels": {
+			nodeName: "unlabelled",
+		},
+		"missing node": {
+			nodeName: "missing",
+			expErr:   `failed to get node "missing": nodes "missing" not found`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			platform, err := New(client).Platform(context.TODO(), test.nodeName)
+			if len(test.expErr) > 0 {
+				assert.EqualError(t, err, test.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expPlatform, platform)
+		})
+	}
+}
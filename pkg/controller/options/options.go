@@ -4,10 +4,14 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client"
+	"github.com/jetstack/version-checker/pkg/version/constraint"
+	"github.com/jetstack/version-checker/pkg/version/scheme"
 )
 
 // Builder is a struct for building container search options.
@@ -38,11 +42,16 @@ func (b *Builder) Options(name string) (*api.Options, error) {
 		b.handleSHAOption,
 		b.handleSHAToTagOption,
 		b.handleMetadataOption,
+		b.handleStrictSemVerOption,
+		b.handleVersionSchemeOption,
 		b.handleRegexOption,
 		b.handlePinMajorOption,
 		b.handlePinMinorOption,
 		b.handlePinPatchOption,
+		b.handleConstraintOption,
 		b.handleOverrideURLOption,
+		b.handleRegistryClientOption,
+		b.handlePlatformOption,
 	}
 
 	// Execute each handler
@@ -88,6 +97,36 @@ func (b *Builder) handleMetadataOption(name string, opts *api.Options, setNonSha
 	return nil
 }
 
+func (b *Builder) handleStrictSemVerOption(name string, opts *api.Options, setNonSha *bool, errs *[]string) error {
+	if strictSemVer, ok := b.ans[b.index(name, api.StrictSemVerAnnotationKey)]; ok && strictSemVer == "true" {
+		*setNonSha = true
+		opts.StrictSemVer = true
+	}
+	return nil
+}
+
+func (b *Builder) handleVersionSchemeOption(name string, opts *api.Options, setNonSha *bool, errs *[]string) error {
+	versionScheme, ok := b.ans[b.index(name, api.VersionSchemeAnnotationKey)]
+	if !ok {
+		return nil
+	}
+
+	*setNonSha = true
+	if _, ok := scheme.Get(versionScheme); !ok {
+		*errs = append(*errs, fmt.Sprintf("unknown version scheme %q at annotation %q, must be one of %s",
+			versionScheme, b.index(name, api.VersionSchemeAnnotationKey), strings.Join(scheme.Names(), ", ")))
+		return nil
+	}
+
+	opts.VersionScheme = versionScheme
+	if opts.StrictSemVer && versionScheme != scheme.SemVerName {
+		*errs = append(*errs, fmt.Sprintf("cannot define %q with version scheme %q",
+			b.index(name, api.StrictSemVerAnnotationKey), versionScheme))
+	}
+
+	return nil
+}
+
 func (b *Builder) handleRegexOption(name string, opts *api.Options, setNonSha *bool, errs *[]string) error {
 	if matchRegex, ok := b.ans[b.index(name, api.MatchRegexAnnotationKey)]; ok {
 		*setNonSha = true
@@ -150,6 +189,21 @@ func (b *Builder) handlePinPatchOption(name string, opts *api.Options, setNonSha
 	return nil
 }
 
+func (b *Builder) handleConstraintOption(name string, opts *api.Options, setNonSha *bool, errs *[]string) error {
+	if expr, ok := b.ans[b.index(name, api.ConstraintAnnotationKey)]; ok {
+		*setNonSha = true
+		opts.Constraint = &expr
+
+		c, err := constraint.Parse(expr)
+		if err != nil {
+			*errs = append(*errs, fmt.Sprintf("failed to parse %s: %s", b.index(name, api.ConstraintAnnotationKey), err))
+		} else {
+			opts.ConstraintMatcher = c
+		}
+	}
+	return nil
+}
+
 func (b *Builder) handleOverrideURLOption(name string, opts *api.Options, setNonSha *bool, errs *[]string) error {
 	if overrideURL, ok := b.ans[b.index(name, api.OverrideURLAnnotationKey)]; ok {
 		opts.OverrideURL = &overrideURL
@@ -157,6 +211,31 @@ func (b *Builder) handleOverrideURLOption(name string, opts *api.Options, setNon
 	return nil
 }
 
+func (b *Builder) handleRegistryClientOption(name string, opts *api.Options, setNonSha *bool, errs *[]string) error {
+	if registryClient, ok := b.ans[b.index(name, api.RegistryClientAnnotationKey)]; ok {
+		if !slices.Contains(client.ClientNames, registryClient) {
+			*errs = append(*errs, fmt.Sprintf("annotation %q must name a registry client, one of %s, got %q",
+				b.index(name, api.RegistryClientAnnotationKey), strings.Join(client.ClientNames, ", "), registryClient))
+			return nil
+		}
+		opts.RegistryClient = registryClient
+	}
+	return nil
+}
+
+func (b *Builder) handlePlatformOption(name string, opts *api.Options, setNonSha *bool, errs *[]string) error {
+	if platform, ok := b.ans[b.index(name, api.PlatformAnnotationKey)]; ok {
+		platformOS, arch, ok := strings.Cut(platform, "/")
+		if !ok || len(platformOS) == 0 || len(arch) == 0 || strings.Contains(arch, "/") {
+			*errs = append(*errs, fmt.Sprintf("annotation %q must be of the form <os>/<arch>, e.g. linux/arm64, got %q",
+				b.index(name, api.PlatformAnnotationKey), platform))
+			return nil
+		}
+		opts.Platform = &api.Platform{OS: api.OS(platformOS), Architecture: api.Architecture(arch)}
+	}
+	return nil
+}
+
 // IsEnabled will return whether the container has the enabled annotation set.
 // Will fall back to default, if not set true/false.
 func (b *Builder) IsEnabled(defaultEnabled bool, name string) bool {
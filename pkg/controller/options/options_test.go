@@ -114,6 +114,79 @@ func TestBuild(t *testing.T) {
 			},
 			expErr: "",
 		},
+		"output options for strict semver": {
+			containerName: "test-name",
+			annotations: map[string]string{
+				api.StrictSemVerAnnotationKey + "/test-name": "true",
+			},
+			expOptions: &api.Options{
+				StrictSemVer: true,
+			},
+			expErr: "",
+		},
+		"cannot use strict semver with sha": {
+			containerName: "test-name",
+			annotations: map[string]string{
+				api.UseSHAAnnotationKey + "/test-name":       "true",
+				api.StrictSemVerAnnotationKey + "/test-name": "true",
+			},
+			expOptions: nil,
+			expErr:     `cannot define "use-sha.version-checker.io/test-name" with any semver options`,
+		},
+		"output options for version scheme": {
+			containerName: "test-name",
+			annotations: map[string]string{
+				api.VersionSchemeAnnotationKey + "/test-name": "calver",
+			},
+			expOptions: &api.Options{
+				VersionScheme: "calver",
+			},
+			expErr: "",
+		},
+		"unknown version scheme errors": {
+			containerName: "test-name",
+			annotations: map[string]string{
+				api.VersionSchemeAnnotationKey + "/test-name": "foo",
+			},
+			expOptions: nil,
+			expErr:     `unknown version scheme "foo" at annotation "version-scheme.version-checker.io/test-name", must be one of calver, date, lexical, numeric-build, semver`,
+		},
+		"cannot use strict semver with other version schemes": {
+			containerName: "test-name",
+			annotations: map[string]string{
+				api.VersionSchemeAnnotationKey + "/test-name": "calver",
+				api.StrictSemVerAnnotationKey + "/test-name":  "true",
+			},
+			expOptions: nil,
+			expErr:     `cannot define "strict-semver.version-checker.io/test-name" with version scheme "calver"`,
+		},
+		"output options for constraint": {
+			containerName: "test-name",
+			annotations: map[string]string{
+				api.ConstraintAnnotationKey + "/test-name": ">=1.20 <2.0",
+			},
+			expOptions: &api.Options{
+				Constraint: stringp(">=1.20 <2.0"),
+			},
+			expErr: "",
+		},
+		"invalid constraint errors": {
+			containerName: "test-name",
+			annotations: map[string]string{
+				api.ConstraintAnnotationKey + "/test-name": "=>1.20",
+			},
+			expOptions: nil,
+			expErr:     `failed to parse constraint.version-checker.io/test-name: invalid constraint "=>1.20": unknown operator "=>"`,
+		},
+		"cannot use constraint with sha": {
+			containerName: "test-name",
+			annotations: map[string]string{
+				api.UseSHAAnnotationKey + "/test-name":     "true",
+				api.ConstraintAnnotationKey + "/test-name": "^1",
+			},
+			expOptions: nil,
+			expErr:     `cannot define "use-sha.version-checker.io/test-name" with any semver options`,
+		},
 		"output options for resolve sha": {
 			containerName: "test-name",
 			annotations: map[string]string{
@@ -124,6 +197,52 @@ func TestBuild(t *testing.T) {
 			},
 			expErr: "",
 		},
+		"output options for registry client": {
+			containerName: "test-name",
+			annotations: map[string]string{
+				api.RegistryClientAnnotationKey + "/test-name": "oci",
+			},
+			expOptions: &api.Options{
+				RegistryClient: "oci",
+			},
+			expErr: "",
+		},
+		"empty registry client": {
+			containerName: "test-name",
+			annotations: map[string]string{
+				api.RegistryClientAnnotationKey + "/test-name": "",
+			},
+			expOptions: nil,
+			expErr: `annotation "registry-client.version-checker.io/test-name" must name a registry client, ` +
+				`one of acr, docker, ecr, fallback, gcr, ghcr, oci, quay, selfhosted, got ""`,
+		},
+		"unknown registry client": {
+			containerName: "test-name",
+			annotations: map[string]string{
+				api.RegistryClientAnnotationKey + "/test-name": "harbor",
+			},
+			expOptions: nil,
+			expErr: `annotation "registry-client.version-checker.io/test-name" must name a registry client, ` +
+				`one of acr, docker, ecr, fallback, gcr, ghcr, oci, quay, selfhosted, got "harbor"`,
+		},
+		"output options for platform": {
+			containerName: "test-name",
+			annotations: map[string]string{
+				api.PlatformAnnotationKey + "/test-name": "linux/arm64",
+			},
+			expOptions: &api.Options{
+				Platform: &api.Platform{OS: "linux", Architecture: "arm64"},
+			},
+			expErr: "",
+		},
+		"invalid platform": {
+			containerName: "test-name",
+			annotations: map[string]string{
+				api.PlatformAnnotationKey + "/test-name": "arm64",
+			},
+			expOptions: nil,
+			expErr:     `annotation "platform.version-checker.io/test-name" must be of the form <os>/<arch>, e.g. linux/arm64, got "arm64"`,
+		},
 		"bool options that don't have 'true' and nothing": {
 			containerName: "test-name",
 			annotations: map[string]string{
@@ -147,6 +266,13 @@ func TestBuild(t *testing.T) {
 				require.NoError(t, err)
 			}
 
+			// Constraint matchers hold functions, so are checked by expression.
+			if options != nil && options.Constraint != nil {
+				require.NotNil(t, options.ConstraintMatcher)
+				assert.Equal(t, *options.Constraint, options.ConstraintMatcher.String())
+				options.ConstraintMatcher = nil
+			}
+
 			assert.Exactly(t, test.expOptions, options)
 		})
 	}
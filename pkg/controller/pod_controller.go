@@ -15,8 +15,12 @@ import (
 
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
+	"github.com/jetstack/version-checker/pkg/cache"
 	"github.com/jetstack/version-checker/pkg/client"
 	"github.com/jetstack/version-checker/pkg/controller/checker"
+	"github.com/jetstack/version-checker/pkg/controller/nodeplatform"
+	"github.com/jetstack/version-checker/pkg/controller/policy"
+	"github.com/jetstack/version-checker/pkg/controller/pullsecrets"
 	"github.com/jetstack/version-checker/pkg/controller/search"
 	"github.com/jetstack/version-checker/pkg/metrics"
 	"github.com/jetstack/version-checker/pkg/version"
@@ -36,29 +40,59 @@ type PodReconciler struct {
 	RequeueDuration time.Duration // Configurable reschedule duration
 
 	defaultTestAll bool
+	reports        *reportWriter
+	policies       *policy.Matcher
 }
 
 func NewPodReconciler(
 	cacheTimeout time.Duration,
+	cacheOpts cache.Options,
 	metrics *metrics.Metrics,
 	imageClient *client.Client,
 	kubeClient k8sclient.Client,
 	log *logrus.Entry,
 	requeueDuration time.Duration,
 	defaultTestAll bool,
+	imageVersionReports bool,
+	versionCheckPolicies bool,
+	imagePullSecrets bool,
+	matchNodePlatform bool,
+	mirrors []checker.Mirror,
 ) *PodReconciler {
 	log = log.WithField("controller", "pod")
-	versionGetter := version.New(log, imageClient, cacheTimeout)
+	versionGetter := version.New(log, imageClient, cacheTimeout, cacheOpts)
 	search := search.New(log, cacheTimeout, versionGetter)
 
-	return &PodReconciler{
+	var pullSecrets checker.PullSecrets
+	if imagePullSecrets {
+		pullSecrets = pullsecrets.New(log, kubeClient)
+	}
+
+	var nodePlatforms checker.NodePlatforms
+	if matchNodePlatform {
+		nodePlatforms = nodeplatform.New(kubeClient)
+	}
+
+	versionChecker := checker.NewWithPullSecrets(search, pullSecrets).
+		WithNodePlatforms(nodePlatforms).
+		WithMirrors(mirrors)
+
+	r := &PodReconciler{
 		Log:             log,
 		Client:          kubeClient,
 		Metrics:         metrics,
-		VersionChecker:  checker.New(search),
+		VersionChecker:  versionChecker,
 		RequeueDuration: requeueDuration,
 		defaultTestAll:  defaultTestAll,
 	}
+	if imageVersionReports {
+		r.reports = newReportWriter(kubeClient)
+	}
+	if versionCheckPolicies {
+		r.policies = policy.New(log, kubeClient)
+	}
+
+	return r
 }
 
 // Reconcile is triggered whenever a watched object changes.
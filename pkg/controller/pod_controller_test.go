@@ -22,6 +22,7 @@ import (
 
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
+	"github.com/jetstack/version-checker/pkg/cache"
 	"github.com/jetstack/version-checker/pkg/client"
 	"github.com/jetstack/version-checker/pkg/metrics"
 )
@@ -41,7 +42,7 @@ func TestNewController(t *testing.T) {
 	)
 	imageClient := &client.Client{}
 
-	controller := NewPodReconciler(5*time.Minute, metrics, imageClient, kubeClient, testLogger, time.Hour, true)
+	controller := NewPodReconciler(5*time.Minute, cache.Options{}, metrics, imageClient, kubeClient, testLogger, time.Hour, true, false, false, false, false, nil)
 
 	assert.NotNil(t, controller)
 	assert.Equal(t, controller.defaultTestAll, true)
@@ -86,7 +87,7 @@ func TestReconcile(t *testing.T) {
 				kubeClient,
 			)
 
-			controller := NewPodReconciler(5*time.Minute, metrics, imageClient, kubeClient, testLogger, 5*time.Minute, true)
+			controller := NewPodReconciler(5*time.Minute, cache.Options{}, metrics, imageClient, kubeClient, testLogger, 5*time.Minute, true, false, false, false, false, nil)
 
 			ctx := context.Background()
 
@@ -123,7 +124,7 @@ func TestSetupWithManager(t *testing.T) {
 		kubeClient,
 	)
 	imageClient := &client.Client{}
-	controller := NewPodReconciler(5*time.Minute, metrics, imageClient, kubeClient, testLogger, time.Hour, true)
+	controller := NewPodReconciler(5*time.Minute, cache.Options{}, metrics, imageClient, kubeClient, testLogger, time.Hour, true, false, false, false, false, nil)
 
 	mgr, err := manager.New(&rest.Config{}, manager.Options{LeaderElectionConfig: nil})
 	require.NoError(t, err)
@@ -10,6 +10,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 
 	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/controller/checker"
 	"github.com/jetstack/version-checker/pkg/controller/options"
 	versionerrors "github.com/jetstack/version-checker/pkg/version/errors"
 )
@@ -18,7 +19,17 @@ import (
 func (c *PodReconciler) sync(ctx context.Context, pod *corev1.Pod) error {
 	log := c.Log.WithFields(logrus.Fields{"name": pod.Name, "namespace": pod.Namespace})
 
-	builder := options.New(pod.Annotations)
+	annotations := pod.Annotations
+	if c.policies != nil {
+		var err error
+		annotations, err = c.policies.Annotations(ctx, pod.Namespace, pod.Labels, pod.Annotations, &pod.Spec)
+		if err != nil {
+			return fmt.Errorf("failed to match version check policies for pod %s/%s: %s",
+				pod.Namespace, pod.Name, err)
+		}
+	}
+
+	builder := options.New(annotations)
 
 	var errs []string
 	for _, container := range pod.Spec.InitContainers {
@@ -51,6 +62,7 @@ func (c *PodReconciler) syncContainer(ctx context.Context,
 	// If not enabled, exit early
 	if !builder.IsEnabled(c.defaultTestAll, container.Name) {
 		c.Metrics.RemoveImage(pod.Namespace, pod.Name, container.Name, containerType)
+		c.removeReport(ctx, log, pod, container, containerType)
 		return nil
 	}
 
@@ -94,6 +106,7 @@ func (c *PodReconciler) checkContainer(ctx context.Context, log *logrus.Entry,
 	if err != nil {
 		// Report the error using ErrorsReporting
 		c.Metrics.ReportError(pod.Namespace, pod.Name, container.Name, container.Image)
+		c.updateReport(ctx, log, pod, container, containerType, nil, err)
 		return err
 	}
 
@@ -109,12 +122,73 @@ func (c *PodReconciler) checkContainer(ctx context.Context, log *logrus.Entry,
 		log.Debugf("image is not latest %s: %s -> %s",
 			result.ImageURL, result.CurrentVersion, result.LatestVersion)
 	}
+	if result.TagDrift != nil && result.TagDrift.Drifted {
+		log.Debugf("tag %s of image %s points to a different digest than running %s",
+			result.TagDrift.Tag, result.ImageURL, result.CurrentVersion)
+	}
 
 	c.Metrics.AddImage(pod.Namespace, pod.Name,
 		container.Name, containerType,
-		result.ImageURL, result.IsLatest,
+		result.ImageURL, result.MirrorURL, result.Platform, result.IsLatest,
 		result.CurrentVersion, result.LatestVersion,
 	)
+	c.Metrics.SetImageDistance(pod.Namespace, pod.Name,
+		container.Name, containerType,
+		result.ImageURL, result.Distance,
+	)
+	c.Metrics.SetTagDigestDrift(pod.Namespace, pod.Name,
+		container.Name, containerType,
+		result.ImageURL, result.TagDrift,
+	)
+	c.updateReport(ctx, log, pod, container, containerType, result, nil)
 
 	return nil
 }
+
+// updateReport records the check result, or error, of the container on the
+// ImageVersionReport of the Pod's workload, if reports are enabled. Failing
+// to write the report doesn't fail the check.
+func (c *PodReconciler) updateReport(ctx context.Context, log *logrus.Entry,
+	pod *corev1.Pod,
+	container *corev1.Container,
+	containerType string,
+	result *checker.Result,
+	checkErr error,
+) {
+	if c.reports == nil {
+		return
+	}
+
+	owner, err := resolveWorkloadOwner(ctx, c.Client, pod)
+	if err != nil {
+		log.WithError(err).Error("failed to resolve workload for image version report")
+		return
+	}
+
+	status := c.reports.newContainerStatus(container.Name, containerType, container.Image, result, checkErr)
+	if err := c.reports.update(ctx, pod.Namespace, owner, status); err != nil {
+		log.WithError(err).Error("failed to update image version report")
+	}
+}
+
+// removeReport removes the container from the ImageVersionReport of the Pod's
+// workload, if reports are enabled.
+func (c *PodReconciler) removeReport(ctx context.Context, log *logrus.Entry,
+	pod *corev1.Pod,
+	container *corev1.Container,
+	containerType string,
+) {
+	if c.reports == nil {
+		return
+	}
+
+	owner, err := resolveWorkloadOwner(ctx, c.Client, pod)
+	if err != nil {
+		log.WithError(err).Error("failed to resolve workload for image version report")
+		return
+	}
+
+	if err := c.reports.remove(ctx, pod.Namespace, owner, container.Name, containerType); err != nil {
+		log.WithError(err).Error("failed to update image version report")
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/cache"
 	"github.com/jetstack/version-checker/pkg/client"
 	"github.com/jetstack/version-checker/pkg/controller/checker"
 	fakesearch "github.com/jetstack/version-checker/pkg/controller/internal/fake/search"
@@ -38,7 +39,7 @@ func TestController_Sync(t *testing.T) {
 		fake.NewFakeClient(),
 	)
 	imageClient := &client.Client{}
-	searcher := search.New(log, 5*time.Minute, version.New(log, imageClient, 5*time.Minute))
+	searcher := search.New(log, 5*time.Minute, version.New(log, imageClient, 5*time.Minute, cache.Options{}))
 	checker := checker.New(searcher)
 
 	controller := &PodReconciler{
@@ -73,7 +74,7 @@ func TestController_SyncContainer(t *testing.T) {
 	log := logrus.NewEntry(logrus.New())
 	metrics := metrics.New(log, prometheus.NewRegistry(), fake.NewFakeClient())
 	imageClient := &client.Client{}
-	searcher := search.New(log, 5*time.Minute, version.New(log, imageClient, 5*time.Minute))
+	searcher := search.New(log, 5*time.Minute, version.New(log, imageClient, 5*time.Minute, cache.Options{}))
 	checker := checker.New(searcher)
 
 	controller := &PodReconciler{
@@ -105,7 +106,7 @@ func TestController_CheckContainer(t *testing.T) {
 	log := logrus.NewEntry(logrus.New())
 	metrics := metrics.New(log, prometheus.NewRegistry(), fake.NewFakeClient())
 	imageClient := &client.Client{}
-	searcher := search.New(log, 5*time.Minute, version.New(log, imageClient, 5*time.Minute))
+	searcher := search.New(log, 5*time.Minute, version.New(log, imageClient, 5*time.Minute, cache.Options{}))
 	checker := checker.New(searcher)
 
 	controller := &PodReconciler{
@@ -135,7 +136,7 @@ func TestController_SyncContainer_NoVersionFound(t *testing.T) {
 	log := logrus.NewEntry(logrus.New())
 	metrics := metrics.New(log, prometheus.NewRegistry(), fake.NewFakeClient())
 	imageClient := &client.Client{}
-	searcher := search.New(log, 5*time.Minute, version.New(log, imageClient, 5*time.Minute))
+	searcher := search.New(log, 5*time.Minute, version.New(log, imageClient, 5*time.Minute, cache.Options{}))
 	checker := checker.New(searcher)
 
 	controller := &PodReconciler{
@@ -0,0 +1,293 @@
+package policy
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/apis/versionchecker/v1alpha1"
+)
+
+// Matcher applies VersionCheckPolicies and ClusterVersionCheckPolicies to
+// the containers of Pods.
+type Matcher struct {
+	client k8sclient.Reader
+	log    *logrus.Entry
+}
+
+// policy is a VersionCheckPolicy or ClusterVersionCheckPolicy, with its
+// selectors parsed.
+type policy struct {
+	name     string
+	images   []*regexp.Regexp
+	selector labels.Selector
+	options  *v1alpha1.VersionCheckOptions
+}
+
+// New constructs a new Matcher.
+func New(log *logrus.Entry, client k8sclient.Reader) *Matcher {
+	return &Matcher{
+		client: client,
+		log:    log.WithField("module", "policy"),
+	}
+}
+
+// Annotations returns the given Pod annotations with the options of each
+// container's matching policy added, using the same keys as the
+// *.version-checker.io annotations. Containers whose options are set by any
+// annotation are left to them, without a policy. VersionCheckPolicies in the Pod's namespace
+// are matched before ClusterVersionCheckPolicies, and policies of the same
+// kind are matched in order of name. Only the first matching policy applies.
+func (m *Matcher) Annotations(ctx context.Context, namespace string,
+	podLabels, annotations map[string]string,
+	spec *corev1.PodSpec,
+) (map[string]string, error) {
+	policies, err := m.policies(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if len(policies) == 0 {
+		return annotations, nil
+	}
+
+	result := make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		result[k] = v
+	}
+
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for _, container := range containers {
+			// Options of the Pod replace those of a policy as a whole, so
+			// that they are never mixed, e.g. use-sha with a policy's pins
+			if setsOptions(annotations, container.Name) {
+				continue
+			}
+
+			p := match(policies, podLabels, container.Image)
+			if p == nil {
+				continue
+			}
+
+			m.log.WithField("container", container.Name).Debugf("applying policy %s", p.name)
+			for key, value := range optionAnnotations(p.options) {
+				result[key+"/"+container.Name] = value
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// policies returns the policies that apply to the given namespace, in the
+// order that they are matched.
+func (m *Matcher) policies(ctx context.Context, namespace string) ([]*policy, error) {
+	var namespaced v1alpha1.VersionCheckPolicyList
+	if err := m.client.List(ctx, &namespaced, k8sclient.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	sort.Slice(namespaced.Items, func(i, j int) bool {
+		return namespaced.Items[i].Name < namespaced.Items[j].Name
+	})
+
+	var cluster v1alpha1.ClusterVersionCheckPolicyList
+	if err := m.client.List(ctx, &cluster); err != nil {
+		return nil, err
+	}
+	sort.Slice(cluster.Items, func(i, j int) bool {
+		return cluster.Items[i].Name < cluster.Items[j].Name
+	})
+
+	var policies []*policy
+	for i := range namespaced.Items {
+		item := &namespaced.Items[i]
+		if p := m.parse(item.Namespace+"/"+item.Name, &item.Spec); p != nil {
+			policies = append(policies, p)
+		}
+	}
+
+	var namespaceLabels labels.Set
+	for i := range cluster.Items {
+		item := &cluster.Items[i]
+
+		if item.Spec.NamespaceSelector != nil {
+			if namespaceLabels == nil {
+				var ns corev1.Namespace
+				if err := m.client.Get(ctx, types.NamespacedName{Name: namespace}, &ns); err != nil {
+					return nil, err
+				}
+				namespaceLabels = labels.Set(ns.Labels)
+			}
+
+			selector, err := metav1.LabelSelectorAsSelector(item.Spec.NamespaceSelector)
+			if err != nil {
+				m.log.WithError(err).Errorf("ignoring policy %s with invalid namespace selector", item.Name)
+				continue
+			}
+			if !selector.Matches(namespaceLabels) {
+				continue
+			}
+		}
+
+		if p := m.parse(item.Name, &item.Spec); p != nil {
+			policies = append(policies, p)
+		}
+	}
+
+	return policies, nil
+}
+
+// parse returns the parsed policy, or nil if the policy is invalid.
+func (m *Matcher) parse(name string, spec *v1alpha1.VersionCheckPolicySpec) *policy {
+	p := &policy{
+		name:     name,
+		selector: labels.Everything(),
+		options:  &spec.Options,
+	}
+
+	if spec.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(spec.Selector)
+		if err != nil {
+			m.log.WithError(err).Errorf("ignoring policy %s with invalid selector", name)
+			return nil
+		}
+		p.selector = selector
+	}
+
+	for _, image := range spec.Images {
+		p.images = append(p.images, globToRegexp(image))
+	}
+
+	return p
+}
+
+// match returns the first policy that matches the Pod labels and image, or
+// nil if none match.
+func match(policies []*policy, podLabels map[string]string, image string) *policy {
+	name := imageName(image)
+
+	for _, p := range policies {
+		if !p.selector.Matches(labels.Set(podLabels)) {
+			continue
+		}
+		if len(p.images) == 0 {
+			return p
+		}
+		for _, glob := range p.images {
+			if glob.MatchString(name) {
+				return p
+			}
+		}
+	}
+
+	return nil
+}
+
+// globToRegexp compiles a glob pattern, where "*" matches any characters and
+// "?" matches a single character.
+func globToRegexp(glob string) *regexp.Regexp {
+	parts := strings.Split(glob, "*")
+	for i := range parts {
+		parts[i] = strings.ReplaceAll(regexp.QuoteMeta(parts[i]), `\?`, ".")
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
+
+// imageName returns the registry and repository of an image, without tag or
+// digest. Docker Hub images are returned with their full name.
+func imageName(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+
+	parts := strings.SplitN(image, "/", 2)
+	switch {
+	case len(parts) == 1:
+		return "docker.io/library/" + image
+	case !strings.ContainsAny(parts[0], ".:") && parts[0] != "localhost":
+		return "docker.io/" + image
+	default:
+		return image
+	}
+}
+
+// optionAnnotationKeys are the annotation keys of the options a policy sets.
+var optionAnnotationKeys = []string{
+	api.MatchRegexAnnotationKey,
+	api.PinMajorAnnotationKey,
+	api.PinMinorAnnotationKey,
+	api.PinPatchAnnotationKey,
+	api.ConstraintAnnotationKey,
+	api.UseMetaDataAnnotationKey,
+	api.StrictSemVerAnnotationKey,
+	api.VersionSchemeAnnotationKey,
+	api.UseSHAAnnotationKey,
+	api.ResolveSHAToTagsKey,
+	api.OverrideURLAnnotationKey,
+}
+
+// setsOptions returns whether the annotations set any option of the
+// container.
+func setsOptions(annotations map[string]string, containerName string) bool {
+	for _, key := range optionAnnotationKeys {
+		if _, ok := annotations[key+"/"+containerName]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// optionAnnotations returns the options as *.version-checker.io annotation
+// keys and values, so that they are validated the same as annotations.
+func optionAnnotations(opts *v1alpha1.VersionCheckOptions) map[string]string {
+	ans := make(map[string]string)
+
+	if opts.MatchRegex != nil {
+		ans[api.MatchRegexAnnotationKey] = *opts.MatchRegex
+	}
+	if opts.PinMajor != nil {
+		ans[api.PinMajorAnnotationKey] = strconv.FormatInt(*opts.PinMajor, 10)
+	}
+	if opts.PinMinor != nil {
+		ans[api.PinMinorAnnotationKey] = strconv.FormatInt(*opts.PinMinor, 10)
+	}
+	if opts.PinPatch != nil {
+		ans[api.PinPatchAnnotationKey] = strconv.FormatInt(*opts.PinPatch, 10)
+	}
+	if opts.Constraint != nil {
+		ans[api.ConstraintAnnotationKey] = *opts.Constraint
+	}
+	if opts.UseMetadata {
+		ans[api.UseMetaDataAnnotationKey] = "true"
+	}
+	if opts.StrictSemVer {
+		ans[api.StrictSemVerAnnotationKey] = "true"
+	}
+	if len(opts.VersionScheme) > 0 {
+		ans[api.VersionSchemeAnnotationKey] = opts.VersionScheme
+	}
+	if opts.UseSHA {
+		ans[api.UseSHAAnnotationKey] = "true"
+	}
+	if opts.ResolveSHAToTags {
+		ans[api.ResolveSHAToTagsKey] = "true"
+	}
+	if opts.OverrideURL != nil {
+		ans[api.OverrideURLAnnotationKey] = *opts.OverrideURL
+	}
+
+	return ans
+}
@@ -0,0 +1,200 @@
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/jetstack/version-checker/pkg/apis/versionchecker/v1alpha1"
+	"github.com/jetstack/version-checker/pkg/controller/options"
+)
+
+func TestImageName(t *testing.T) {
+	tests := map[string]string{
+		"nginx":                          "docker.io/library/nginx",
+		"nginx:1.27":                     "docker.io/library/nginx",
+		"jetstack/version-checker:v0.1":  "docker.io/jetstack/version-checker",
+		"quay.io/jetstack/cert-manager":  "quay.io/jetstack/cert-manager",
+		"localhost/app:v1":               "localhost/app",
+		"localhost:5000/app:v1":          "localhost:5000/app",
+		"gcr.io/project/app@sha256:abcd": "gcr.io/project/app",
+		"gcr.io/project/app:v1@sha256:1": "gcr.io/project/app",
+	}
+
+	for image, expName := range tests {
+		t.Run(image, func(t *testing.T) {
+			assert.Equal(t, expName, imageName(image))
+		})
+	}
+}
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := map[string]struct {
+		glob     string
+		name     string
+		expMatch bool
+	}{
+		"exact match":                 {"quay.io/jetstack/app", "quay.io/jetstack/app", true},
+		"star matches across slashes": {"quay.io/*", "quay.io/jetstack/app", true},
+		"star matches registry":       {"*.azurecr.io/*", "myreg.azurecr.io/team/app", true},
+		"dots are literal":            {"quay.io/*", "quayxio/jetstack/app", false},
+		"question mark matches one":   {"quay.io/app?", "quay.io/app1", true},
+		"match is anchored":           {"quay.io/app", "quay.io/app-extra", false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expMatch, globToRegexp(test.glob).MatchString(test.name))
+		})
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	int64p := func(i int64) *int64 { return &i }
+	stringp := func(s string) *string { return &s }
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	objects := []client.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod", Labels: map[string]string{"env": "prod"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev", Labels: map[string]string{"env": "dev"}}},
+		&v1alpha1.VersionCheckPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "jetstack", Namespace: "prod"},
+			Spec: v1alpha1.VersionCheckPolicySpec{
+				Images:   []string{"quay.io/jetstack/*"},
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "platform"}},
+				Options:  v1alpha1.VersionCheckOptions{PinMajor: int64p(1), PinMinor: int64p(2)},
+			},
+		},
+		&v1alpha1.ClusterVersionCheckPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "a-prod-quay"},
+			Spec: v1alpha1.VersionCheckPolicySpec{
+				Images:            []string{"quay.io/*"},
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+				Options:           v1alpha1.VersionCheckOptions{UseMetadata: true},
+			},
+		},
+		&v1alpha1.ClusterVersionCheckPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "b-docker-hub"},
+			Spec: v1alpha1.VersionCheckPolicySpec{
+				Images:  []string{"docker.io/*"},
+				Options: v1alpha1.VersionCheckOptions{MatchRegex: stringp(`^v\d+`), OverrideURL: stringp("mirror.io/app")},
+			},
+		},
+	}
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+
+	m := New(logrus.NewEntry(logrus.New()), kubeClient)
+
+	tests := map[string]struct {
+		namespace      string
+		labels         map[string]string
+		annotations    map[string]string
+		image          string
+		expAnnotations map[string]string
+	}{
+		"namespaced policy is matched before cluster policies": {
+			namespace: "prod",
+			labels:    map[string]string{"team": "platform"},
+			image:     "quay.io/jetstack/app:v1.2.0",
+			expAnnotations: map[string]string{
+				"pin-major.version-checker.io/app": "1",
+				"pin-minor.version-checker.io/app": "2",
+			},
+		},
+		"namespaced policy selector must match pod labels": {
+			namespace: "prod",
+			image:     "quay.io/jetstack/app:v1.2.0",
+			expAnnotations: map[string]string{
+				"use-metadata.version-checker.io/app": "true",
+			},
+		},
+		"cluster policy namespace selector must match": {
+			namespace:      "dev",
+			image:          "quay.io/jetstack/app:v1.2.0",
+			expAnnotations: map[string]string{},
+		},
+		"cluster policy matches docker hub images": {
+			namespace: "dev",
+			image:     "nginx:1.27",
+			expAnnotations: map[string]string{
+				"match-regex.version-checker.io/app":  `^v\d+`,
+				"override-url.version-checker.io/app": "mirror.io/app",
+			},
+		},
+		"annotations replace the options of the policy": {
+			namespace: "dev",
+			annotations: map[string]string{
+				"override-url.version-checker.io/app": "other.io/app",
+			},
+			image: "nginx:1.27",
+			expAnnotations: map[string]string{
+				"override-url.version-checker.io/app": "other.io/app",
+			},
+		},
+		"use-sha annotation is not mixed with semver options of the policy": {
+			namespace: "prod",
+			labels:    map[string]string{"team": "platform"},
+			annotations: map[string]string{
+				"use-sha.version-checker.io/app": "true",
+			},
+			image: "quay.io/jetstack/app:v1.2.0",
+			expAnnotations: map[string]string{
+				"use-sha.version-checker.io/app": "true",
+			},
+		},
+		"annotations of other containers don't replace the policy": {
+			namespace: "dev",
+			annotations: map[string]string{
+				"use-sha.version-checker.io/sidecar": "true",
+			},
+			image: "nginx:1.27",
+			expAnnotations: map[string]string{
+				"use-sha.version-checker.io/sidecar":  "true",
+				"match-regex.version-checker.io/app":  `^v\d+`,
+				"override-url.version-checker.io/app": "mirror.io/app",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			spec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: test.image}}}
+
+			annotations, err := m.Annotations(context.Background(), test.namespace, test.labels, test.annotations, spec)
+			require.NoError(t, err)
+			assert.Equal(t, test.expAnnotations, annotations)
+
+			// The result must be valid options
+			_, err = options.New(annotations).Options("app")
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAnnotationsNoPolicies(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	annotations := map[string]string{"use-sha.version-checker.io/app": "true"}
+	spec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "nginx"}}}
+
+	result, err := New(logrus.NewEntry(logrus.New()), kubeClient).
+		Annotations(context.Background(), "default", nil, annotations, spec)
+	require.NoError(t, err)
+	assert.Equal(t, annotations, result)
+}
@@ -0,0 +1,202 @@
+package pullsecrets
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/jetstack/version-checker/pkg/client/dockerconfig"
+)
+
+// cacheTimeout is how long Secrets and ServiceAccounts are cached for, so
+// that they aren't got for every container checked.
+const cacheTimeout = 5 * time.Minute
+
+// Resolver resolves the keychain of a Pod's imagePullSecrets, along with
+// those of its ServiceAccount, in the same way as the kubelet.
+type Resolver struct {
+	client k8sclient.Reader
+	log    *logrus.Entry
+
+	mu              sync.Mutex
+	secrets         map[types.NamespacedName]cachedSecret
+	serviceAccounts map[types.NamespacedName]cachedServiceAccount
+}
+
+// cachedSecret is the keychain of an imagePullSecret, which is nil if the
+// Secret doesn't exist or isn't a Docker config.
+type cachedSecret struct {
+	keychain authn.Keychain
+	expires  time.Time
+}
+
+// cachedServiceAccount is the imagePullSecrets of a ServiceAccount.
+type cachedServiceAccount struct {
+	pullSecrets []corev1.LocalObjectReference
+	expires     time.Time
+}
+
+// New constructs a new Resolver. The client should read from the API server
+// directly, rather than a cache of every Secret in the cluster.
+func New(log *logrus.Entry, client k8sclient.Reader) *Resolver {
+	return &Resolver{
+		client:          client,
+		log:             log.WithField("module", "pullsecrets"),
+		secrets:         make(map[types.NamespacedName]cachedSecret),
+		serviceAccounts: make(map[types.NamespacedName]cachedServiceAccount),
+	}
+}
+
+// Keychain returns the keychain of the imagePullSecrets of the pod spec and
+// its ServiceAccount, in that order, or nil if there are none. As with the
+// kubelet, Secrets which don't exist are ignored. The keychain is returned
+// with its identity, the Secrets it was resolved from, so that lookups made
+// with it can be cached apart from those made with other credentials.
+func (r *Resolver) Keychain(ctx context.Context, namespace string, spec *corev1.PodSpec) (authn.Keychain, string, error) {
+	saPullSecrets, err := r.serviceAccountPullSecrets(ctx, namespace, spec.ServiceAccountName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var (
+		keychains []authn.Keychain
+		names     []string
+		seen      = make(map[string]bool)
+	)
+	for _, ref := range slices.Concat(spec.ImagePullSecrets, saPullSecrets) {
+		if len(ref.Name) == 0 || seen[ref.Name] {
+			continue
+		}
+		seen[ref.Name] = true
+
+		keychain, err := r.secret(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name})
+		if err != nil {
+			return nil, "", err
+		}
+		if keychain != nil {
+			keychains = append(keychains, keychain)
+			names = append(names, ref.Name)
+		}
+	}
+
+	id := namespace + "/" + strings.Join(names, ",")
+	switch len(keychains) {
+	case 0:
+		return nil, "", nil
+	case 1:
+		return keychains[0], id, nil
+	default:
+		return authn.NewMultiKeychain(keychains...), id, nil
+	}
+}
+
+// serviceAccountPullSecrets returns the imagePullSecrets of the
+// ServiceAccount, which is the default ServiceAccount if not given.
+func (r *Resolver) serviceAccountPullSecrets(ctx context.Context, namespace, name string) ([]corev1.LocalObjectReference, error) {
+	if len(name) == 0 {
+		name = "default"
+	}
+	key := types.NamespacedName{Namespace: namespace, Name: name}
+
+	r.mu.Lock()
+	cached, ok := r.serviceAccounts[key]
+	r.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.pullSecrets, nil
+	}
+
+	var pullSecrets []corev1.LocalObjectReference
+	sa := new(corev1.ServiceAccount)
+	err := r.client.Get(ctx, key, sa)
+	switch {
+	case apierrors.IsNotFound(err):
+		r.log.WithField("serviceaccount", key).Debug("service account not found, ignoring its imagePullSecrets")
+	case err != nil:
+		return nil, fmt.Errorf("failed to get service account %s: %w", key, err)
+	default:
+		pullSecrets = sa.ImagePullSecrets
+	}
+
+	r.mu.Lock()
+	r.serviceAccounts[key] = cachedServiceAccount{pullSecrets: pullSecrets, expires: time.Now().Add(cacheTimeout)}
+	r.mu.Unlock()
+
+	return pullSecrets, nil
+}
+
+// secret returns the keychain of the imagePullSecret, or nil if it doesn't
+// exist or isn't a Docker config.
+func (r *Resolver) secret(ctx context.Context, key types.NamespacedName) (authn.Keychain, error) {
+	r.mu.Lock()
+	cached, ok := r.secrets[key]
+	r.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.keychain, nil
+	}
+
+	log := r.log.WithField("secret", key)
+
+	var keychain authn.Keychain
+	secret := new(corev1.Secret)
+	err := r.client.Get(ctx, key, secret)
+	switch {
+	case apierrors.IsNotFound(err):
+		log.Debug("image pull secret not found, ignoring")
+	case err != nil:
+		return nil, fmt.Errorf("failed to get image pull secret %s: %w", key, err)
+	default:
+		keychain, err = parseSecret(secret)
+		if err != nil {
+			// An invalid secret is cached, as it won't become valid until
+			// it is updated.
+			log.WithError(err).Warn("ignoring invalid image pull secret")
+		}
+	}
+
+	r.mu.Lock()
+	r.secrets[key] = cachedSecret{keychain: keychain, expires: time.Now().Add(cacheTimeout)}
+	r.mu.Unlock()
+
+	return keychain, nil
+}
+
+// parseSecret returns the keychain of a kubernetes.io/dockerconfigjson or
+// legacy kubernetes.io/dockercfg Secret.
+func parseSecret(secret *corev1.Secret) (authn.Keychain, error) {
+	var data []byte
+	switch secret.Type {
+	case corev1.SecretTypeDockerConfigJson:
+		var ok bool
+		if data, ok = secret.Data[corev1.DockerConfigJsonKey]; !ok {
+			return nil, fmt.Errorf("missing %q key", corev1.DockerConfigJsonKey)
+		}
+
+	case corev1.SecretTypeDockercfg:
+		legacy, ok := secret.Data[corev1.DockerConfigKey]
+		if !ok {
+			return nil, fmt.Errorf("missing %q key", corev1.DockerConfigKey)
+		}
+		// The legacy format is the auths of a Docker config file
+		data = []byte(`{"auths":` + string(legacy) + `}`)
+
+	default:
+		return nil, fmt.Errorf("unsupported secret type %q", secret.Type)
+	}
+
+	keychain, err := dockerconfig.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return keychain, nil
+}
@@ -0,0 +1,164 @@
+package pullsecrets
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestKeychain(t *testing.T) {
+	objects := []client.Object{
+		dockerConfigSecret("pod-secret", `{"auths":{"registry.example.com":{"username":"pod-user","password":"pod-password"}}}`),
+		dockerConfigSecret("sa-secret", `{"auths":{
+			"registry.example.com":{"username":"sa-user","password":"sa-password"},
+			"quay.io":{"username":"quay-user","password":"quay-password"}
+		}}`),
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "legacy-secret", Namespace: "default"},
+			Type:       corev1.SecretTypeDockercfg,
+			Data: map[string][]byte{
+				corev1.DockerConfigKey: []byte(`{"legacy.example.com":{"username":"legacy-user","password":"legacy-password"}}`),
+			},
+		},
+		dockerConfigSecret("invalid-secret", `{`),
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "opaque-secret", Namespace: "default"},
+			Type:       corev1.SecretTypeOpaque,
+		},
+		&corev1.ServiceAccount{
+			ObjectMeta:       metav1.ObjectMeta{Name: "app", Namespace: "default"},
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "sa-secret"}},
+		},
+		&corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "default"},
+		},
+	}
+
+	tests := map[string]struct {
+		spec        corev1.PodSpec
+		host        string
+		expKeychain bool
+		expID       string
+		expAuth     *authn.AuthConfig
+	}{
+		"no pull secrets": {
+			spec: corev1.PodSpec{},
+			host: "registry.example.com",
+		},
+		"pod pull secret": {
+			spec:        corev1.PodSpec{ImagePullSecrets: []corev1.LocalObjectReference{{Name: "pod-secret"}}},
+			host:        "registry.example.com",
+			expKeychain: true,
+			expID:       "default/pod-secret",
+			expAuth:     &authn.AuthConfig{Username: "pod-user", Password: "pod-password"},
+		},
+		"service account pull secret": {
+			spec:        corev1.PodSpec{ServiceAccountName: "app"},
+			host:        "quay.io",
+			expKeychain: true,
+			expID:       "default/sa-secret",
+			expAuth:     &authn.AuthConfig{Username: "quay-user", Password: "quay-password"},
+		},
+		"pod pull secrets are preferred over the service account's": {
+			spec: corev1.PodSpec{
+				ServiceAccountName: "app",
+				ImagePullSecrets:   []corev1.LocalObjectReference{{Name: "pod-secret"}},
+			},
+			host:        "registry.example.com",
+			expKeychain: true,
+			expID:       "default/pod-secret,sa-secret",
+			expAuth:     &authn.AuthConfig{Username: "pod-user", Password: "pod-password"},
+		},
+		"legacy dockercfg secret": {
+			spec:        corev1.PodSpec{ImagePullSecrets: []corev1.LocalObjectReference{{Name: "legacy-secret"}}},
+			host:        "legacy.example.com",
+			expKeychain: true,
+			expID:       "default/legacy-secret",
+			expAuth:     &authn.AuthConfig{Username: "legacy-user", Password: "legacy-password"},
+		},
+		"missing, invalid and unsupported secrets are ignored": {
+			spec: corev1.PodSpec{ImagePullSecrets: []corev1.LocalObjectReference{
+				{Name: "missing-secret"}, {Name: "invalid-secret"}, {Name: "opaque-secret"}, {Name: "pod-secret"},
+			}},
+			host:        "registry.example.com",
+			expKeychain: true,
+			expID:       "default/pod-secret",
+			expAuth:     &authn.AuthConfig{Username: "pod-user", Password: "pod-password"},
+		},
+		"missing service account is ignored": {
+			spec: corev1.PodSpec{ServiceAccountName: "missing"},
+			host: "registry.example.com",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			kubeClient := fake.NewClientBuilder().WithObjects(objects...).Build()
+			resolver := New(logrus.NewEntry(logrus.New()), kubeClient)
+
+			keychain, id, err := resolver.Keychain(context.Background(), "default", &test.spec)
+			require.NoError(t, err)
+			assert.Equal(t, test.expID, id)
+			if !test.expKeychain {
+				assert.Nil(t, keychain)
+				return
+			}
+			require.NotNil(t, keychain)
+
+			reg, err := name.NewRegistry(test.host)
+			require.NoError(t, err)
+			auth, err := keychain.Resolve(reg)
+			require.NoError(t, err)
+			cfg, err := auth.Authorization()
+			require.NoError(t, err)
+			assert.Equal(t, test.expAuth, cfg)
+		})
+	}
+}
+
+func TestKeychainCache(t *testing.T) {
+	gets := make(map[string]int)
+	kubeClient := fake.NewClientBuilder().
+		WithObjects(
+			dockerConfigSecret("pod-secret", `{"auths":{}}`),
+			&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "default"}},
+		).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				gets[key.Name]++
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).
+		Build()
+
+	resolver := New(logrus.NewEntry(logrus.New()), kubeClient)
+	spec := &corev1.PodSpec{ImagePullSecrets: []corev1.LocalObjectReference{{Name: "pod-secret"}, {Name: "missing-secret"}}}
+
+	for i := 0; i < 3; i++ {
+		_, _, err := resolver.Keychain(context.Background(), "default", spec)
+		require.NoError(t, err)
+	}
+
+	// Each object is only got once, including those not found
+	assert.Equal(t, map[string]int{"default": 1, "pod-secret": 1, "missing-secret": 1}, gets)
+}
+
+func dockerConfigSecret(name, config string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(config)},
+	}
+}
@@ -0,0 +1,243 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/util/retry"
+
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/jetstack/version-checker/pkg/apis/versionchecker/v1alpha1"
+	"github.com/jetstack/version-checker/pkg/controller/checker"
+)
+
+// reportRefreshInterval is the minimum time between writes of an unchanged
+// container result, so that workloads with many Pods don't update their
+// report on every Pod sync.
+const reportRefreshInterval = time.Minute
+
+// reportNameHashLength is the length of the hash suffix of report names
+// which were truncated to fit the object name limit.
+const reportNameHashLength = 10
+
+// reportWriter records check results on ImageVersionReports, one per
+// namespace and workload.
+type reportWriter struct {
+	client k8sclient.Client
+	now    func() time.Time
+}
+
+func newReportWriter(client k8sclient.Client) *reportWriter {
+	return &reportWriter{
+		client: client,
+		now:    time.Now,
+	}
+}
+
+// reportName returns the name of the ImageVersionReport of a workload. Names
+// over the object name limit are truncated, and suffixed with a hash of the
+// full name to keep them unique.
+func reportName(kind, name string) string {
+	reportName := strings.ToLower(kind) + "-" + name
+	if len(reportName) <= validation.DNS1123SubdomainMaxLength {
+		return reportName
+	}
+
+	sum := sha256.Sum256([]byte(reportName))
+	prefix := reportName[:validation.DNS1123SubdomainMaxLength-reportNameHashLength-1]
+	// The truncated name must still end in an alphanumeric character.
+	prefix = strings.TrimRight(prefix, ".-")
+
+	return prefix + "-" + hex.EncodeToString(sum[:])[:reportNameHashLength]
+}
+
+// newContainerStatus builds the report status of a container from the check
+// result, or check error.
+func (w *reportWriter) newContainerStatus(containerName, containerType, image string,
+	result *checker.Result,
+	checkErr error,
+) v1alpha1.ContainerVersionStatus {
+	now := w.now()
+	status := v1alpha1.ContainerVersionStatus{
+		Name:        containerName,
+		Type:        containerType,
+		Image:       image,
+		LastChecked: metav1.NewTime(now),
+	}
+
+	if checkErr != nil {
+		status.LastError = checkErr.Error()
+		return status
+	}
+
+	status.CurrentVersion = result.CurrentVersion
+	status.LatestVersion = result.LatestVersion
+	status.IsLatest = result.IsLatest
+	status.Platform = result.Platform
+	if !result.IsLatest && !result.LatestTimestamp.IsZero() && now.After(result.LatestTimestamp) {
+		status.Lag = &metav1.Duration{Duration: now.Sub(result.LatestTimestamp).Truncate(time.Second)}
+	}
+
+	return status
+}
+
+// update sets the container status on the report of the given workload,
+// creating the report if it doesn't exist.
+func (w *reportWriter) update(ctx context.Context, namespace string,
+	owner metav1.OwnerReference,
+	status v1alpha1.ContainerVersionStatus,
+) error {
+	key := types.NamespacedName{Namespace: namespace, Name: reportName(owner.Kind, owner.Name)}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		report := new(v1alpha1.ImageVersionReport)
+		err := w.client.Get(ctx, key, report)
+		if apierrors.IsNotFound(err) {
+			report = newReport(key, owner)
+			if err := w.client.Create(ctx, report); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+
+		if !setContainerStatus(&report.Status, status) {
+			return nil
+		}
+
+		return w.client.Status().Update(ctx, report)
+	})
+}
+
+// remove deletes the container status from the report of the given workload,
+// if present.
+func (w *reportWriter) remove(ctx context.Context, namespace string,
+	owner metav1.OwnerReference,
+	containerName, containerType string,
+) error {
+	key := types.NamespacedName{Namespace: namespace, Name: reportName(owner.Kind, owner.Name)}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		report := new(v1alpha1.ImageVersionReport)
+		if err := w.client.Get(ctx, key, report); err != nil {
+			return k8sclient.IgnoreNotFound(err)
+		}
+
+		containers := report.Status.Containers[:0]
+		for _, c := range report.Status.Containers {
+			if c.Name != containerName || c.Type != containerType {
+				containers = append(containers, c)
+			}
+		}
+		if len(containers) == len(report.Status.Containers) {
+			return nil
+		}
+
+		report.Status.Containers = containers
+		summarizeReportStatus(&report.Status)
+
+		return w.client.Status().Update(ctx, report)
+	})
+}
+
+// newReport returns an empty report for the given workload, owned by the
+// workload so that it is garbage collected along with it.
+func newReport(key types.NamespacedName, owner metav1.OwnerReference) *v1alpha1.ImageVersionReport {
+	report := &v1alpha1.ImageVersionReport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+		},
+		Spec: v1alpha1.ImageVersionReportSpec{
+			Workload: v1alpha1.WorkloadReference{
+				APIVersion: owner.APIVersion,
+				Kind:       owner.Kind,
+				Name:       owner.Name,
+			},
+		},
+	}
+
+	if len(owner.UID) > 0 {
+		report.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: owner.APIVersion,
+			Kind:       owner.Kind,
+			Name:       owner.Name,
+			UID:        owner.UID,
+		}}
+	}
+
+	return report
+}
+
+// setContainerStatus adds or replaces the container status, returning false
+// if the report doesn't need to be written.
+func setContainerStatus(reportStatus *v1alpha1.ImageVersionReportStatus, status v1alpha1.ContainerVersionStatus) bool {
+	i := 0
+	for ; i < len(reportStatus.Containers); i++ {
+		if reportStatus.Containers[i].Name == status.Name &&
+			reportStatus.Containers[i].Type == status.Type {
+			break
+		}
+	}
+
+	if i == len(reportStatus.Containers) {
+		reportStatus.Containers = append(reportStatus.Containers, status)
+		summarizeReportStatus(reportStatus)
+		return true
+	}
+
+	existing := reportStatus.Containers[i]
+
+	// Keep the last successful result when the check failed.
+	if len(status.LastError) > 0 && existing.Image == status.Image {
+		status.CurrentVersion = existing.CurrentVersion
+		status.LatestVersion = existing.LatestVersion
+		status.IsLatest = existing.IsLatest
+		status.Lag = existing.Lag
+	}
+
+	if containerResultEqual(existing, status) &&
+		status.LastChecked.Sub(existing.LastChecked.Time) < reportRefreshInterval {
+		return false
+	}
+
+	reportStatus.Containers[i] = status
+	summarizeReportStatus(reportStatus)
+
+	return true
+}
+
+// containerResultEqual returns whether the two statuses hold the same result,
+// ignoring when they were checked.
+func containerResultEqual(a, b v1alpha1.ContainerVersionStatus) bool {
+	return a.Image == b.Image &&
+		a.CurrentVersion == b.CurrentVersion &&
+		a.LatestVersion == b.LatestVersion &&
+		a.IsLatest == b.IsLatest &&
+		a.LastError == b.LastError
+}
+
+// summarizeReportStatus updates the report wide status fields from the
+// container statuses.
+func summarizeReportStatus(reportStatus *v1alpha1.ImageVersionReportStatus) {
+	reportStatus.OutdatedContainers = 0
+	reportStatus.LastChecked = nil
+
+	for _, c := range reportStatus.Containers {
+		if !c.IsLatest && len(c.CurrentVersion) > 0 {
+			reportStatus.OutdatedContainers++
+		}
+		if reportStatus.LastChecked == nil || reportStatus.LastChecked.Before(&c.LastChecked) {
+			lastChecked := c.LastChecked
+			reportStatus.LastChecked = &lastChecked
+		}
+	}
+}
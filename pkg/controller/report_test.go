@@ -0,0 +1,316 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/apis/versionchecker/v1alpha1"
+	"github.com/jetstack/version-checker/pkg/controller/checker"
+	fakesearch "github.com/jetstack/version-checker/pkg/controller/internal/fake/search"
+	"github.com/jetstack/version-checker/pkg/metrics"
+)
+
+func newReportTestClient(t *testing.T) *fake.ClientBuilder {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&v1alpha1.ImageVersionReport{})
+}
+
+func TestReportName(t *testing.T) {
+	longName := strings.Repeat("a", validation.DNS1123SubdomainMaxLength)
+
+	tests := map[string]struct {
+		kind, name string
+		expName    string
+		expPrefix  string
+	}{
+		"short name is the kind and name": {
+			kind:    KindDeployment,
+			name:    "app",
+			expName: "deployment-app",
+		},
+		"name at the limit is not truncated": {
+			kind:    KindJob,
+			name:    longName[:validation.DNS1123SubdomainMaxLength-len("job-")],
+			expName: "job-" + longName[:validation.DNS1123SubdomainMaxLength-len("job-")],
+		},
+		"name over the limit is truncated with a hash": {
+			kind:      KindStatefulSet,
+			name:      longName,
+			expPrefix: "statefulset-aaa",
+		},
+		"truncated name doesn't end in a separator": {
+			kind:      KindStatefulSet,
+			name:      strings.Repeat("a", 228) + ".-" + longName,
+			expPrefix: "statefulset-aaa",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := reportName(test.kind, test.name)
+			assert.Empty(t, validation.IsDNS1123Subdomain(got))
+			if len(test.expName) > 0 {
+				assert.Equal(t, test.expName, got)
+				return
+			}
+
+			assert.True(t, strings.HasPrefix(got, test.expPrefix), got)
+			assert.LessOrEqual(t, len(got), validation.DNS1123SubdomainMaxLength)
+			assert.NotContains(t, got, ".-")
+			// The hash keeps names which only differ after the limit apart
+			assert.NotEqual(t, got, reportName(test.kind, test.name+"b"))
+			assert.Equal(t, got, reportName(test.kind, test.name))
+		})
+	}
+}
+
+func TestReportWriterContainerStatus(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	w := &reportWriter{now: func() time.Time { return now }}
+
+	tests := map[string]struct {
+		result    *checker.Result
+		checkErr  error
+		expStatus v1alpha1.ContainerVersionStatus
+	}{
+		"latest image has no lag": {
+			result: &checker.Result{
+				CurrentVersion: "v1.0.0", LatestVersion: "v1.0.0", IsLatest: true,
+				LatestTimestamp: now.Add(-time.Hour),
+			},
+			expStatus: v1alpha1.ContainerVersionStatus{
+				CurrentVersion: "v1.0.0", LatestVersion: "v1.0.0", IsLatest: true,
+			},
+		},
+		"outdated image lags behind the latest release": {
+			result: &checker.Result{
+				CurrentVersion: "v1.0.0", LatestVersion: "v1.1.0",
+				LatestTimestamp: now.Add(-time.Hour),
+			},
+			expStatus: v1alpha1.ContainerVersionStatus{
+				CurrentVersion: "v1.0.0", LatestVersion: "v1.1.0",
+				Lag: &metav1.Duration{Duration: time.Hour},
+			},
+		},
+		"re-pushed image reports the platform of its digest": {
+			result: &checker.Result{
+				CurrentVersion: "v1.0.0@sha:123", LatestVersion: "v1.0.0@sha:456",
+				Platform: "linux/arm64",
+			},
+			expStatus: v1alpha1.ContainerVersionStatus{
+				CurrentVersion: "v1.0.0@sha:123", LatestVersion: "v1.0.0@sha:456",
+				Platform: "linux/arm64",
+			},
+		},
+		"outdated image without timestamp has no lag": {
+			result: &checker.Result{CurrentVersion: "v1.0.0", LatestVersion: "v1.1.0"},
+			expStatus: v1alpha1.ContainerVersionStatus{
+				CurrentVersion: "v1.0.0", LatestVersion: "v1.1.0",
+			},
+		},
+		"check error is recorded": {
+			checkErr:  errors.New("registry unavailable"),
+			expStatus: v1alpha1.ContainerVersionStatus{LastError: "registry unavailable"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.expStatus.Name = "app"
+			test.expStatus.Type = "container"
+			test.expStatus.Image = "localhost:5000/app:v1.0.0"
+			test.expStatus.LastChecked = metav1.NewTime(now)
+
+			status := w.newContainerStatus("app", "container", "localhost:5000/app:v1.0.0", test.result, test.checkErr)
+			assert.Equal(t, test.expStatus, status)
+		})
+	}
+}
+
+func TestSetContainerStatus(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	checked := func(status v1alpha1.ContainerVersionStatus, at time.Time) v1alpha1.ContainerVersionStatus {
+		status.LastChecked = metav1.NewTime(at)
+		return status
+	}
+
+	latest := v1alpha1.ContainerVersionStatus{
+		Name: "app", Type: "container", Image: "app:v1",
+		CurrentVersion: "v1", LatestVersion: "v1", IsLatest: true,
+	}
+	outdated := v1alpha1.ContainerVersionStatus{
+		Name: "app", Type: "container", Image: "app:v1",
+		CurrentVersion: "v1", LatestVersion: "v2",
+	}
+	failed := v1alpha1.ContainerVersionStatus{
+		Name: "app", Type: "container", Image: "app:v1",
+		LastError: "boom",
+	}
+
+	tests := map[string]struct {
+		existing    []v1alpha1.ContainerVersionStatus
+		status      v1alpha1.ContainerVersionStatus
+		expUpdate   bool
+		expStatus   v1alpha1.ContainerVersionStatus
+		expOutdated int32
+	}{
+		"new container is added": {
+			status:      checked(outdated, now),
+			expUpdate:   true,
+			expStatus:   checked(outdated, now),
+			expOutdated: 1,
+		},
+		"unchanged result is not written again within the refresh interval": {
+			existing:  []v1alpha1.ContainerVersionStatus{checked(latest, now.Add(-time.Second))},
+			status:    checked(latest, now),
+			expUpdate: false,
+			expStatus: checked(latest, now.Add(-time.Second)),
+		},
+		"unchanged result is refreshed after the refresh interval": {
+			existing:  []v1alpha1.ContainerVersionStatus{checked(latest, now.Add(-time.Hour))},
+			status:    checked(latest, now),
+			expUpdate: true,
+			expStatus: checked(latest, now),
+		},
+		"changed result is written": {
+			existing:    []v1alpha1.ContainerVersionStatus{checked(latest, now.Add(-time.Second))},
+			status:      checked(outdated, now),
+			expUpdate:   true,
+			expStatus:   checked(outdated, now),
+			expOutdated: 1,
+		},
+		"failed check keeps the last versions": {
+			existing:  []v1alpha1.ContainerVersionStatus{checked(outdated, now.Add(-time.Hour))},
+			status:    checked(failed, now),
+			expUpdate: true,
+			expStatus: func() v1alpha1.ContainerVersionStatus {
+				status := checked(outdated, now)
+				status.LastError = "boom"
+				return status
+			}(),
+			expOutdated: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			reportStatus := v1alpha1.ImageVersionReportStatus{Containers: test.existing}
+			summarizeReportStatus(&reportStatus)
+
+			assert.Equal(t, test.expUpdate, setContainerStatus(&reportStatus, test.status))
+			require.Len(t, reportStatus.Containers, 1)
+			assert.Equal(t, test.expStatus, reportStatus.Containers[0])
+			assert.Equal(t, test.expOutdated, reportStatus.OutdatedContainers)
+			assert.Equal(t, test.expStatus.LastChecked, *reportStatus.LastChecked)
+		})
+	}
+}
+
+func TestReportWriterUpdateRemove(t *testing.T) {
+	ctx := context.Background()
+	kubeClient := newReportTestClient(t).Build()
+	w := newReportWriter(kubeClient)
+
+	owner := metav1.OwnerReference{
+		APIVersion: appsv1.SchemeGroupVersion.String(),
+		Kind:       KindDeployment,
+		Name:       "app",
+		UID:        "1234",
+	}
+	key := types.NamespacedName{Namespace: "default", Name: "deployment-app"}
+
+	for _, container := range []string{"app", "sidecar"} {
+		status := w.newContainerStatus(container, "container", container+":v1",
+			&checker.Result{CurrentVersion: "v1", LatestVersion: "v2"}, nil)
+		require.NoError(t, w.update(ctx, "default", owner, status))
+	}
+
+	var report v1alpha1.ImageVersionReport
+	require.NoError(t, kubeClient.Get(ctx, key, &report))
+	assert.Equal(t, v1alpha1.WorkloadReference{
+		APIVersion: "apps/v1", Kind: KindDeployment, Name: "app",
+	}, report.Spec.Workload)
+	assert.Equal(t, []metav1.OwnerReference{owner}, report.OwnerReferences)
+	assert.Len(t, report.Status.Containers, 2)
+	assert.Equal(t, int32(2), report.Status.OutdatedContainers)
+
+	require.NoError(t, w.remove(ctx, "default", owner, "sidecar", "container"))
+	require.NoError(t, kubeClient.Get(ctx, key, &report))
+	require.Len(t, report.Status.Containers, 1)
+	assert.Equal(t, "app", report.Status.Containers[0].Name)
+	assert.Equal(t, int32(1), report.Status.OutdatedContainers)
+
+	// Removing from a missing report is a no-op
+	missing := owner
+	missing.Name = "missing"
+	assert.NoError(t, w.remove(ctx, "default", missing, "app", "container"))
+}
+
+func TestPodReconcilerReport(t *testing.T) {
+	ctx := context.Background()
+
+	replicaSet := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Name: "app-abc", Namespace: "default", OwnerReferences: controllerRef(KindDeployment, "app"),
+	}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "app-abc-1", Namespace: "default", OwnerReferences: controllerRef(KindReplicaSet, "app-abc"),
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{
+			{Name: "app", Image: "localhost:5000/app:v0.1.0"},
+		}},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{Name: "app", ImageID: "localhost:5000/app@sha:123"},
+		}},
+	}
+	kubeClient := newReportTestClient(t).WithObjects(replicaSet, pod).Build()
+
+	search := fakesearch.New().With(&api.ImageTag{Tag: "v0.2.0", SHA: "sha:456"}, nil)
+	r := &PodReconciler{
+		Client:          kubeClient,
+		Log:             testLogger,
+		Metrics:         metrics.New(testLogger, prometheus.NewRegistry(), kubeClient),
+		VersionChecker:  checker.New(search),
+		RequeueDuration: time.Hour,
+		defaultTestAll:  true,
+		reports:         newReportWriter(kubeClient),
+	}
+
+	require.NoError(t, r.sync(ctx, pod))
+
+	var report v1alpha1.ImageVersionReport
+	require.NoError(t, kubeClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "deployment-app"}, &report))
+	require.Len(t, report.Status.Containers, 1)
+
+	status := report.Status.Containers[0]
+	assert.Equal(t, "app", status.Name)
+	assert.Equal(t, "v0.1.0", status.CurrentVersion)
+	assert.Equal(t, "v0.2.0", status.LatestVersion)
+	assert.False(t, status.IsLatest)
+	assert.Empty(t, status.LastError)
+	assert.Equal(t, int32(1), report.Status.OutdatedContainers)
+}
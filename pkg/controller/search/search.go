@@ -11,6 +11,7 @@ import (
 
 	"github.com/jetstack/version-checker/pkg/api"
 	"github.com/jetstack/version-checker/pkg/cache"
+	"github.com/jetstack/version-checker/pkg/client/util"
 	"github.com/jetstack/version-checker/pkg/version"
 )
 
@@ -18,6 +19,9 @@ import (
 type Searcher interface {
 	LatestImage(context.Context, string, *api.Options) (*api.ImageTag, error)
 	ResolveSHAToTag(ctx context.Context, imageURL string, imageSHA string) (string, error)
+	ResolveTagToSHA(ctx context.Context, imageURL string, tag string, platform *api.Platform) (string, error)
+	Tag(ctx context.Context, imageURL string, tag string) (*api.ImageTag, error)
+	Distance(ctx context.Context, imageURL, currentTag string, latest *api.ImageTag, opts *api.Options) (*api.VersionDistance, error)
 }
 
 // Ensure The search Struct implements a cacheHandler
@@ -56,7 +60,7 @@ func (s *Search) Fetch(ctx context.Context, imageURL string, opts *api.Options)
 // options. If not found in the cache, or is too old, then will do a fresh
 // lookup and commit to the cache.
 func (s *Search) LatestImage(ctx context.Context, imageURL string, opts *api.Options) (*api.ImageTag, error) {
-	hashIndex, err := calculateHashIndex(imageURL, opts)
+	hashIndex, err := calculateHashIndex(util.ContextCacheIndex(ctx, imageURL), opts)
 	if err != nil {
 		return nil, err
 	}
@@ -78,6 +82,35 @@ func (s *Search) ResolveSHAToTag(ctx context.Context, imageURL string, imageSHA
 	return tag, err
 }
 
+func (s *Search) ResolveTagToSHA(ctx context.Context, imageURL string, tag string, platform *api.Platform) (string, error) {
+	sha, err := s.versionGetter.ResolveTagToSHA(ctx, imageURL, tag, platform)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve tag to sha: %w", err)
+	}
+
+	return sha, err
+}
+
+// Tag returns the image tag of the given name, or nil if the image has no
+// such tag.
+func (s *Search) Tag(ctx context.Context, imageURL string, tag string) (*api.ImageTag, error) {
+	imageTag, err := s.versionGetter.Tag(ctx, imageURL, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+
+	return imageTag, nil
+}
+
+func (s *Search) Distance(ctx context.Context, imageURL, currentTag string, latest *api.ImageTag, opts *api.Options) (*api.VersionDistance, error) {
+	distance, err := s.versionGetter.Distance(ctx, imageURL, currentTag, latest, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate version distance: %w", err)
+	}
+
+	return distance, nil
+}
+
 // calculateHashIndex returns a hash index given an imageURL and options.
 func calculateHashIndex(imageURL string, opts *api.Options) (string, error) {
 	optsJSON, err := json.Marshal(opts)
@@ -0,0 +1,219 @@
+package controller
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Kinds of the workload controllers that Pods are grouped by.
+const (
+	KindDeployment  = "Deployment"
+	KindStatefulSet = "StatefulSet"
+	KindDaemonSet   = "DaemonSet"
+	KindCronJob     = "CronJob"
+	KindJob         = "Job"
+	KindReplicaSet  = "ReplicaSet"
+	KindPod         = "Pod"
+)
+
+// workloadRef identifies the top level controller that owns a Pod.
+type workloadRef struct {
+	Kind string
+	Name string
+}
+
+// workloadKind describes a workload type that is reconciled by the
+// WorkloadReconciler.
+type workloadKind struct {
+	kind        string
+	apiVersion  string
+	newObject   func() k8sclient.Object
+	podTemplate func(k8sclient.Object) *corev1.PodTemplateSpec
+}
+
+var workloadKinds = []workloadKind{
+	{
+		kind:       KindDeployment,
+		apiVersion: appsv1.SchemeGroupVersion.String(),
+		newObject:  func() k8sclient.Object { return &appsv1.Deployment{} },
+		podTemplate: func(obj k8sclient.Object) *corev1.PodTemplateSpec {
+			return &obj.(*appsv1.Deployment).Spec.Template
+		},
+	},
+	{
+		kind:       KindStatefulSet,
+		apiVersion: appsv1.SchemeGroupVersion.String(),
+		newObject:  func() k8sclient.Object { return &appsv1.StatefulSet{} },
+		podTemplate: func(obj k8sclient.Object) *corev1.PodTemplateSpec {
+			return &obj.(*appsv1.StatefulSet).Spec.Template
+		},
+	},
+	{
+		kind:       KindDaemonSet,
+		apiVersion: appsv1.SchemeGroupVersion.String(),
+		newObject:  func() k8sclient.Object { return &appsv1.DaemonSet{} },
+		podTemplate: func(obj k8sclient.Object) *corev1.PodTemplateSpec {
+			return &obj.(*appsv1.DaemonSet).Spec.Template
+		},
+	},
+	{
+		kind:       KindCronJob,
+		apiVersion: batchv1.SchemeGroupVersion.String(),
+		newObject:  func() k8sclient.Object { return &batchv1.CronJob{} },
+		podTemplate: func(obj k8sclient.Object) *corev1.PodTemplateSpec {
+			return &obj.(*batchv1.CronJob).Spec.JobTemplate.Spec.Template
+		},
+	},
+	{
+		kind:       KindJob,
+		apiVersion: batchv1.SchemeGroupVersion.String(),
+		newObject:  func() k8sclient.Object { return &batchv1.Job{} },
+		podTemplate: func(obj k8sclient.Object) *corev1.PodTemplateSpec {
+			return &obj.(*batchv1.Job).Spec.Template
+		},
+	},
+	{
+		// ReplicaSets without a Deployment, such as those of an Argo Rollout,
+		// are the closest supported workload of their Pods.
+		kind:       KindReplicaSet,
+		apiVersion: appsv1.SchemeGroupVersion.String(),
+		newObject:  func() k8sclient.Object { return &appsv1.ReplicaSet{} },
+		podTemplate: func(obj k8sclient.Object) *corev1.PodTemplateSpec {
+			return &obj.(*appsv1.ReplicaSet).Spec.Template
+		},
+	},
+	{
+		// Pods without a supported controller are their own workload, and
+		// their own template.
+		kind:       KindPod,
+		apiVersion: corev1.SchemeGroupVersion.String(),
+		newObject:  func() k8sclient.Object { return &corev1.Pod{} },
+		podTemplate: func(obj k8sclient.Object) *corev1.PodTemplateSpec {
+			pod := obj.(*corev1.Pod)
+			return &corev1.PodTemplateSpec{ObjectMeta: pod.ObjectMeta, Spec: pod.Spec}
+		},
+	},
+}
+
+// controllerIndex indexes Pods, ReplicaSets and Jobs by their controller, so
+// the Pods of a workload are listed without resolving the owners of every Pod
+// in its namespace.
+const controllerIndex = "metadata.controller"
+
+// controllerIndexed are the objects indexed by controllerIndex.
+var controllerIndexed = []k8sclient.Object{&corev1.Pod{}, &appsv1.ReplicaSet{}, &batchv1.Job{}}
+
+// controllerIndexValue returns the controllerIndex key of the object's
+// controller, if it has one.
+func controllerIndexValue(obj k8sclient.Object) []string {
+	owner := metav1.GetControllerOf(obj)
+	if owner == nil {
+		return nil
+	}
+	return []string{controllerKey(owner.APIVersion, owner.Kind, owner.Name)}
+}
+
+// controllerKey returns the controllerIndex key of a controller, which only
+// includes the group of its API version.
+func controllerKey(apiVersion, kind, name string) string {
+	gv, _ := schema.ParseGroupVersion(apiVersion)
+	return gv.Group + "/" + kind + "/" + name
+}
+
+// isWorkloadKind returns whether the controller is of a supported workload
+// kind, other than a Pod.
+func isWorkloadKind(owner *metav1.OwnerReference) bool {
+	gv, err := schema.ParseGroupVersion(owner.APIVersion)
+	if err != nil {
+		return false
+	}
+	for _, kind := range workloadKinds {
+		if kind.kind == KindPod || kind.kind != owner.Kind {
+			continue
+		}
+		if kindGV, _ := schema.ParseGroupVersion(kind.apiVersion); kindGV.Group == gv.Group {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveWorkload walks the controller owner chain of the given Pod and
+// returns the top level workload. ReplicaSets are resolved to their owning
+// Deployment, and Jobs to their owning CronJob. Pods without a controller,
+// or whose controller isn't a supported workload, such as a
+// ReplicationController or a custom resource, are their own workload.
+func resolveWorkload(ctx context.Context, reader k8sclient.Reader, pod k8sclient.Object) (workloadRef, error) {
+	owner, err := resolveWorkloadOwner(ctx, reader, pod)
+	if err != nil {
+		return workloadRef{}, err
+	}
+	return workloadRef{Kind: owner.Kind, Name: owner.Name}, nil
+}
+
+// resolveWorkloadOwner is the same as resolveWorkload, but returns a full
+// reference to the top level workload.
+func resolveWorkloadOwner(ctx context.Context, reader k8sclient.Reader, pod k8sclient.Object) (metav1.OwnerReference, error) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil || !isWorkloadKind(owner) {
+		return metav1.OwnerReference{
+			APIVersion: corev1.SchemeGroupVersion.String(),
+			Kind:       KindPod,
+			Name:       pod.GetName(),
+			UID:        pod.GetUID(),
+		}, nil
+	}
+
+	switch owner.Kind {
+	case KindReplicaSet:
+		return resolveParent(ctx, reader, appsv1.SchemeGroupVersion.WithKind(KindReplicaSet), pod.GetNamespace(), owner)
+	case KindJob:
+		return resolveParent(ctx, reader, batchv1.SchemeGroupVersion.WithKind(KindJob), pod.GetNamespace(), owner)
+	default:
+		return workloadOwner(owner), nil
+	}
+}
+
+// resolveParent returns the controller of the given intermediate owner, or
+// the owner itself if it has no supported controller, or no longer exists.
+func resolveParent(ctx context.Context, reader k8sclient.Reader,
+	gvk schema.GroupVersionKind,
+	namespace string,
+	owner *metav1.OwnerReference,
+) (metav1.OwnerReference, error) {
+	obj := &metav1.PartialObjectMetadata{}
+	obj.SetGroupVersionKind(gvk)
+	err := reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: owner.Name}, obj)
+	if apierrors.IsNotFound(err) {
+		return workloadOwner(owner), nil
+	}
+	if err != nil {
+		return metav1.OwnerReference{}, err
+	}
+
+	if parent := metav1.GetControllerOf(obj); parent != nil && isWorkloadKind(parent) {
+		return workloadOwner(parent), nil
+	}
+
+	return workloadOwner(owner), nil
+}
+
+// workloadOwner returns a copy of the controller reference, without the
+// controller fields.
+func workloadOwner(owner *metav1.OwnerReference) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: owner.APIVersion,
+		Kind:       owner.Kind,
+		Name:       owner.Name,
+		UID:        owner.UID,
+	}
+}
@@ -0,0 +1,312 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/jetstack/version-checker/pkg/cache"
+	"github.com/jetstack/version-checker/pkg/client"
+	"github.com/jetstack/version-checker/pkg/controller/checker"
+	"github.com/jetstack/version-checker/pkg/controller/nodeplatform"
+	"github.com/jetstack/version-checker/pkg/controller/policy"
+	"github.com/jetstack/version-checker/pkg/controller/pullsecrets"
+	"github.com/jetstack/version-checker/pkg/controller/search"
+	"github.com/jetstack/version-checker/pkg/metrics"
+	"github.com/jetstack/version-checker/pkg/version"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WorkloadReconciler checks the images of workloads (Deployments,
+// StatefulSets, DaemonSets, Jobs, CronJobs, ReplicaSets and Pods without a
+// supported controller),
+// reporting a single result per workload container rather than per Pod.
+type WorkloadReconciler struct {
+	k8sclient.Client
+	Log             *logrus.Entry
+	Metrics         *metrics.Metrics
+	VersionChecker  *checker.Checker
+	RequeueDuration time.Duration // Configurable reschedule duration
+
+	defaultTestAll bool
+	checkTemplates bool
+	reports        *reportWriter
+	policies       *policy.Matcher
+}
+
+func NewWorkloadReconciler(
+	cacheTimeout time.Duration,
+	cacheOpts cache.Options,
+	metrics *metrics.Metrics,
+	imageClient *client.Client,
+	kubeClient k8sclient.Client,
+	log *logrus.Entry,
+	requeueDuration time.Duration,
+	defaultTestAll bool,
+	checkTemplates bool,
+	imageVersionReports bool,
+	versionCheckPolicies bool,
+	imagePullSecrets bool,
+	matchNodePlatform bool,
+	mirrors []checker.Mirror,
+) *WorkloadReconciler {
+	log = log.WithField("controller", "workload")
+	versionGetter := version.New(log, imageClient, cacheTimeout, cacheOpts)
+	search := search.New(log, cacheTimeout, versionGetter)
+
+	var pullSecrets checker.PullSecrets
+	if imagePullSecrets {
+		pullSecrets = pullsecrets.New(log, kubeClient)
+	}
+
+	var nodePlatforms checker.NodePlatforms
+	if matchNodePlatform {
+		nodePlatforms = nodeplatform.New(kubeClient)
+	}
+
+	versionChecker := checker.NewWithPullSecrets(search, pullSecrets).
+		WithNodePlatforms(nodePlatforms).
+		WithMirrors(mirrors)
+
+	r := &WorkloadReconciler{
+		Log:             log,
+		Client:          kubeClient,
+		Metrics:         metrics,
+		VersionChecker:  versionChecker,
+		RequeueDuration: requeueDuration,
+		defaultTestAll:  defaultTestAll,
+		checkTemplates:  checkTemplates,
+	}
+	if imageVersionReports {
+		r.reports = newReportWriter(kubeClient)
+	}
+	if versionCheckPolicies {
+		r.policies = policy.New(log, kubeClient)
+	}
+
+	return r
+}
+
+// reconcileKind is triggered whenever a workload of the given kind, or one of
+// its Pods, changes.
+func (r *WorkloadReconciler) reconcileKind(ctx context.Context, kind workloadKind, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithFields(logrus.Fields{"kind": kind.kind, "workload": req.NamespacedName})
+	ref := workloadRef{Kind: kind.kind, Name: req.Name}
+
+	obj := kind.newObject()
+	err := r.Get(ctx, req.NamespacedName, obj)
+	if apierrors.IsNotFound(err) {
+		// Workload deleted, remove from metrics
+		log.Info("Workload not found, removing from metrics")
+		r.Metrics.RemoveWorkload(req.Namespace, ref.Kind, ref.Name)
+		return ctrl.Result{Requeue: false}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var pods []corev1.Pod
+	if pod, ok := obj.(*corev1.Pod); ok {
+		// Pods with a supported controller are reported against their
+		// workload
+		podRef, err := resolveWorkload(ctx, r.Client, pod)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if podRef != ref {
+			return ctrl.Result{}, nil
+		}
+		pods = []corev1.Pod{*pod}
+	} else {
+		// Jobs created by a CronJob, and ReplicaSets created by a
+		// Deployment, are reported against their controller
+		if owner := metav1.GetControllerOf(obj); owner != nil && isWorkloadKind(owner) {
+			return ctrl.Result{}, nil
+		}
+		pods, err = r.workloadPods(ctx, req.Namespace, kind, req.Name)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	var template *corev1.PodTemplateSpec
+	if r.checkTemplates {
+		template = kind.podTemplate(obj)
+	}
+
+	owner := metav1.OwnerReference{
+		APIVersion: kind.apiVersion,
+		Kind:       kind.kind,
+		Name:       obj.GetName(),
+		UID:        obj.GetUID(),
+	}
+
+	// Perform the version check
+	if err := r.sync(ctx, req.Namespace, owner, template, pods); err != nil {
+		log.Error(err, "Failed to process workload")
+		// Requeue after some time in case of failure
+		return ctrl.Result{RequeueAfter: (r.RequeueDuration / 2)}, nil
+	}
+
+	// Schedule next check
+	return ctrl.Result{RequeueAfter: r.RequeueDuration}, nil
+}
+
+// workloadPods returns the Pods in the namespace which are owned by the given
+// workload, through the ReplicaSets of Deployments and the Jobs of CronJobs.
+func (r *WorkloadReconciler) workloadPods(ctx context.Context, namespace string, kind workloadKind, name string) ([]corev1.Pod, error) {
+	key := controllerKey(kind.apiVersion, kind.kind, name)
+
+	var controllers []string
+	switch kind.kind {
+	case KindDeployment:
+		var replicaSets appsv1.ReplicaSetList
+		if err := r.List(ctx, &replicaSets, k8sclient.InNamespace(namespace), k8sclient.MatchingFields{controllerIndex: key}); err != nil {
+			return nil, err
+		}
+		for _, rs := range replicaSets.Items {
+			controllers = append(controllers, controllerKey(appsv1.SchemeGroupVersion.String(), KindReplicaSet, rs.Name))
+		}
+	case KindCronJob:
+		var jobs batchv1.JobList
+		if err := r.List(ctx, &jobs, k8sclient.InNamespace(namespace), k8sclient.MatchingFields{controllerIndex: key}); err != nil {
+			return nil, err
+		}
+		for _, job := range jobs.Items {
+			controllers = append(controllers, controllerKey(batchv1.SchemeGroupVersion.String(), KindJob, job.Name))
+		}
+	default:
+		controllers = []string{key}
+	}
+
+	var pods []corev1.Pod
+	for _, controller := range controllers {
+		var podList corev1.PodList
+		if err := r.List(ctx, &podList, k8sclient.InNamespace(namespace), k8sclient.MatchingFields{controllerIndex: controller}); err != nil {
+			return nil, err
+		}
+		pods = append(pods, podList.Items...)
+	}
+
+	return pods, nil
+}
+
+// SetupWithManager initializes a controller for each supported workload kind.
+// Pod events are handled by the controller of Pods, which resolves the
+// workload of each Pod once, and passes it to the controller of its kind.
+func (r *WorkloadReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	ctx := context.Background()
+	for _, obj := range controllerIndexed {
+		if err := mgr.GetFieldIndexer().IndexField(ctx, obj, controllerIndex, controllerIndexValue); err != nil {
+			return err
+		}
+	}
+
+	workloadEvents := make(map[string]chan event.GenericEvent)
+	for _, kind := range workloadKinds {
+		if kind.kind != KindPod {
+			workloadEvents[kind.kind] = make(chan event.GenericEvent, numWorkers)
+		}
+	}
+
+	LeaderElect := false
+	for _, kind := range workloadKinds {
+		b := ctrl.NewControllerManagedBy(mgr).
+			Named("workload-" + strings.ToLower(kind.kind))
+		if kind.kind == KindPod {
+			b = b.Watches(&corev1.Pod{}, r.podHandler(workloadEvents))
+		} else {
+			b = b.For(kind.newObject()).
+				WatchesRawSource(source.Channel(workloadEvents[kind.kind], &handler.EnqueueRequestForObject{}))
+		}
+
+		err := b.WithOptions(controller.Options{
+			MaxConcurrentReconciles: numWorkers,
+			NeedLeaderElection:      &LeaderElect,
+		}).
+			Complete(reconcile.Func(func(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+				return r.reconcileKind(ctx, kind, req)
+			}))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// podHandler returns an event handler which enqueues the workload of a Pod,
+// on the queue of the Pod controller if it is its own workload, or otherwise
+// as an event of the workload's kind.
+func (r *WorkloadReconciler) podHandler(workloadEvents map[string]chan event.GenericEvent) handler.EventHandler {
+	enqueue := func(ctx context.Context, pod k8sclient.Object, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+		r.enqueuePod(ctx, pod, q, workloadEvents)
+	}
+
+	return handler.Funcs{
+		CreateFunc: func(ctx context.Context, e event.CreateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(ctx, e.Object, q)
+		},
+		UpdateFunc: func(ctx context.Context, e event.UpdateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(ctx, e.ObjectNew, q)
+		},
+		DeleteFunc: func(ctx context.Context, e event.DeleteEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(ctx, e.Object, q)
+		},
+		GenericFunc: func(ctx context.Context, e event.GenericEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(ctx, e.Object, q)
+		},
+	}
+}
+
+// enqueuePod resolves the workload of the Pod, and enqueues it.
+func (r *WorkloadReconciler) enqueuePod(ctx context.Context,
+	pod k8sclient.Object,
+	q workqueue.TypedRateLimitingInterface[reconcile.Request],
+	workloadEvents map[string]chan event.GenericEvent,
+) {
+	ref, err := resolveWorkload(ctx, r.Client, pod)
+	if err != nil {
+		r.Log.WithError(err).Errorf("failed to resolve workload of pod %s/%s",
+			pod.GetNamespace(), pod.GetName())
+		return
+	}
+
+	if ref.Kind == KindPod {
+		q.Add(reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: pod.GetNamespace(), Name: ref.Name},
+		})
+		return
+	}
+
+	events, ok := workloadEvents[ref.Kind]
+	if !ok {
+		return
+	}
+
+	workload := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{
+		Namespace: pod.GetNamespace(),
+		Name:      ref.Name,
+	}}
+	select {
+	case events <- event.GenericEvent{Object: workload}:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,431 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/cache"
+	"github.com/jetstack/version-checker/pkg/client"
+	"github.com/jetstack/version-checker/pkg/controller/checker"
+	fakesearch "github.com/jetstack/version-checker/pkg/controller/internal/fake/search"
+	"github.com/jetstack/version-checker/pkg/metrics"
+)
+
+func controllerRef(kind, name string) []metav1.OwnerReference {
+	apiVersion := appsv1.SchemeGroupVersion.String()
+	if kind == KindJob || kind == KindCronJob {
+		apiVersion = batchv1.SchemeGroupVersion.String()
+	}
+	return controllerRefOf(apiVersion, kind, name)
+}
+
+func controllerRefOf(apiVersion, kind, name string) []metav1.OwnerReference {
+	isController := true
+	return []metav1.OwnerReference{{APIVersion: apiVersion, Kind: kind, Name: name, Controller: &isController}}
+}
+
+// newWorkloadClientBuilder returns a fake client builder with the indexes of
+// the WorkloadReconciler.
+func newWorkloadClientBuilder() *fake.ClientBuilder {
+	builder := fake.NewClientBuilder()
+	for _, obj := range controllerIndexed {
+		builder = builder.WithIndex(obj, controllerIndex, controllerIndexValue)
+	}
+	return builder
+}
+
+func TestResolveWorkload(t *testing.T) {
+	kubeClient := fake.NewClientBuilder().WithObjects(
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name: "app-abc", Namespace: "default", OwnerReferences: controllerRef(KindDeployment, "app"),
+		}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name: "orphan-abc", Namespace: "default",
+		}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name: "rollout-abc", Namespace: "default", OwnerReferences: controllerRefOf("argoproj.io/v1alpha1", "Rollout", "rollout"),
+		}},
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+			Name: "backup-123", Namespace: "default", OwnerReferences: controllerRef(KindCronJob, "backup"),
+		}},
+	).Build()
+
+	tests := map[string]struct {
+		owners []metav1.OwnerReference
+		expRef workloadRef
+	}{
+		"pod without owner is its own workload": {
+			owners: nil,
+			expRef: workloadRef{Kind: KindPod, Name: "test-pod"},
+		},
+		"replicaset should resolve to deployment": {
+			owners: controllerRef(KindReplicaSet, "app-abc"),
+			expRef: workloadRef{Kind: KindDeployment, Name: "app"},
+		},
+		"replicaset without owner should resolve to itself": {
+			owners: controllerRef(KindReplicaSet, "orphan-abc"),
+			expRef: workloadRef{Kind: KindReplicaSet, Name: "orphan-abc"},
+		},
+		"replicaset of an unsupported controller should resolve to itself": {
+			owners: controllerRef(KindReplicaSet, "rollout-abc"),
+			expRef: workloadRef{Kind: KindReplicaSet, Name: "rollout-abc"},
+		},
+		"pod of an unsupported controller is its own workload": {
+			owners: controllerRefOf("v1", "ReplicationController", "legacy"),
+			expRef: workloadRef{Kind: KindPod, Name: "test-pod"},
+		},
+		"pod of a custom resource is its own workload": {
+			owners: controllerRefOf("example.com/v1", KindDeployment, "custom"),
+			expRef: workloadRef{Kind: KindPod, Name: "test-pod"},
+		},
+		"missing replicaset should resolve to itself": {
+			owners: controllerRef(KindReplicaSet, "missing"),
+			expRef: workloadRef{Kind: KindReplicaSet, Name: "missing"},
+		},
+		"job should resolve to cronjob": {
+			owners: controllerRef(KindJob, "backup-123"),
+			expRef: workloadRef{Kind: KindCronJob, Name: "backup"},
+		},
+		"statefulset should resolve directly": {
+			owners: controllerRef(KindStatefulSet, "db"),
+			expRef: workloadRef{Kind: KindStatefulSet, Name: "db"},
+		},
+		"non controller owners are ignored": {
+			owners: []metav1.OwnerReference{{Kind: KindDaemonSet, Name: "agent"}},
+			expRef: workloadRef{Kind: KindPod, Name: "test-pod"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name: "test-pod", Namespace: "default", OwnerReferences: test.owners,
+			}}
+
+			ref, err := resolveWorkload(context.Background(), kubeClient, pod)
+			require.NoError(t, err)
+			assert.Equal(t, test.expRef, ref)
+		})
+	}
+}
+
+func TestWorkloadReconcile(t *testing.T) {
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"}}
+	replicaSet := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Name: "app-abc", Namespace: "default", OwnerReferences: controllerRef(KindDeployment, "app"),
+	}}
+
+	newPod := func(name string, created time.Time, imageID string) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				CreationTimestamp: metav1.NewTime(created),
+				OwnerReferences:   controllerRef(KindReplicaSet, "app-abc"),
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Image: "localhost:5000/app:v0.1.0"},
+			}},
+		}
+		if imageID != "" {
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "app", ImageID: imageID}}
+		}
+		return pod
+	}
+
+	now := time.Now()
+	kubeClient := newWorkloadClientBuilder().WithObjects(
+		deployment, replicaSet,
+		newPod("app-1", now.Add(-time.Hour), "localhost:5000/app@sha:123"),
+		newPod("app-2", now, ""),
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"}},
+	).Build()
+
+	reg := prometheus.NewRegistry()
+	m := metrics.New(testLogger, reg, kubeClient)
+
+	// The running tag has since been re-pushed
+	search := fakesearch.New().
+		With(&api.ImageTag{Tag: "v0.2.0", SHA: "sha:456"}, nil).
+		WithTag(&api.ImageTag{Tag: "v0.1.0", SHA: "sha:789"}, nil)
+	r := &WorkloadReconciler{
+		Client:          kubeClient,
+		Log:             testLogger,
+		Metrics:         m,
+		VersionChecker:  checker.New(search),
+		RequeueDuration: time.Hour,
+		defaultTestAll:  true,
+	}
+
+	kind := workloadKinds[0]
+	require.Equal(t, KindDeployment, kind.kind)
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "app"}}
+
+	pods, err := r.workloadPods(context.Background(), "default", kind, "app")
+	require.NoError(t, err)
+	assert.Len(t, pods, 2)
+
+	result, err := r.reconcileKind(context.Background(), kind, req)
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, result.RequeueAfter)
+
+	// Only a single series should exist for the workload container, using the
+	// pod which has a ready container status.
+	count, err := testutil.GatherAndCount(reg, metrics.MetricNamespace+"_is_latest_workload_version")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// The drift of the running tag is reported for the workload
+	expDrift := `
+# HELP version_checker_workload_tag_digest_drift Whether the registry digest of the tag the workload container runs is different to the digest it is running
+# TYPE version_checker_workload_tag_digest_drift gauge
+version_checker_workload_tag_digest_drift{container="app",container_type="container",image="localhost:5000/app",namespace="default",tag="v0.1.0",workload_kind="Deployment",workload_name="app"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expDrift), metrics.MetricNamespace+"_workload_tag_digest_drift"))
+
+	// Deleting the workload removes the metrics
+	require.NoError(t, kubeClient.Delete(context.Background(), deployment))
+	result, err = r.reconcileKind(context.Background(), kind, req)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), result.RequeueAfter)
+
+	count, err = testutil.GatherAndCount(reg, metrics.MetricNamespace+"_is_latest_workload_version")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	count, err = testutil.GatherAndCount(reg, metrics.MetricNamespace+"_workload_tag_digest_drift")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestWorkloadReconcilePod(t *testing.T) {
+	newPod := func(name string, owners []metav1.OwnerReference) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", OwnerReferences: owners},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Image: "localhost:5000/app:v0.1.0"},
+			}},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "app", ImageID: "localhost:5000/app@sha:123"},
+			}},
+		}
+	}
+	standalone := newPod("standalone", nil)
+	db := newPod("db-0", controllerRef(KindStatefulSet, "db"))
+	kubeClient := newWorkloadClientBuilder().WithObjects(standalone, db).Build()
+
+	reg := prometheus.NewRegistry()
+	r := &WorkloadReconciler{
+		Client:          kubeClient,
+		Log:             testLogger,
+		Metrics:         metrics.New(testLogger, reg, kubeClient),
+		VersionChecker:  checker.New(fakesearch.New().With(&api.ImageTag{Tag: "v0.2.0", SHA: "sha:456"}, nil)),
+		RequeueDuration: time.Hour,
+		defaultTestAll:  true,
+	}
+
+	var kind workloadKind
+	for _, k := range workloadKinds {
+		if k.kind == KindPod {
+			kind = k
+		}
+	}
+	require.Equal(t, KindPod, kind.kind)
+
+	// Pods are only enqueued as a Pod workload when they have no controller,
+	// and otherwise passed to the controller of their workload
+	queue := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	defer queue.ShutDown()
+	workloadEvents := map[string]chan event.GenericEvent{KindStatefulSet: make(chan event.GenericEvent, 1)}
+
+	r.enqueuePod(context.Background(), standalone, queue, workloadEvents)
+	r.enqueuePod(context.Background(), db, queue, workloadEvents)
+
+	assert.Equal(t, 1, queue.Len())
+	req, _ := queue.Get()
+	assert.Equal(t, "standalone", req.Name)
+	require.Len(t, workloadEvents[KindStatefulSet], 1)
+	e := <-workloadEvents[KindStatefulSet]
+	assert.Equal(t, "db", e.Object.GetName())
+
+	for _, name := range []string{"standalone", "db-0"} {
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: name}}
+		_, err := r.reconcileKind(context.Background(), kind, req)
+		require.NoError(t, err)
+	}
+
+	// Only the Pod without a controller is reported as a Pod workload
+	metricFamilies, err := reg.Gather()
+	require.NoError(t, err)
+	var names []string
+	for _, mf := range metricFamilies {
+		if mf.GetName() != metrics.MetricNamespace+"_is_latest_workload_version" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "workload_name" {
+					names = append(names, label.GetValue())
+				}
+			}
+		}
+	}
+	assert.Equal(t, []string{"standalone"}, names)
+}
+
+func TestWorkloadReconcileRollout(t *testing.T) {
+	// Argo Rollouts own ReplicaSets, which are reported as the closest
+	// supported workload of their Pods
+	replicaSet := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Name: "rollout-abc", Namespace: "default", OwnerReferences: controllerRefOf("argoproj.io/v1alpha1", "Rollout", "rollout"),
+	}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "rollout-abc-1", Namespace: "default", OwnerReferences: controllerRef(KindReplicaSet, "rollout-abc"),
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{
+			{Name: "app", Image: "localhost:5000/app:v0.1.0"},
+		}},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{Name: "app", ImageID: "localhost:5000/app@sha:123"},
+		}},
+	}
+	kubeClient := newWorkloadClientBuilder().WithObjects(replicaSet, pod).Build()
+
+	reg := prometheus.NewRegistry()
+	r := &WorkloadReconciler{
+		Client:          kubeClient,
+		Log:             testLogger,
+		Metrics:         metrics.New(testLogger, reg, kubeClient),
+		VersionChecker:  checker.New(fakesearch.New().With(&api.ImageTag{Tag: "v0.2.0", SHA: "sha:456"}, nil)),
+		RequeueDuration: time.Hour,
+		defaultTestAll:  true,
+	}
+
+	kinds := make(map[string]workloadKind)
+	for _, kind := range workloadKinds {
+		kinds[kind.kind] = kind
+	}
+
+	for kind, name := range map[string]string{KindReplicaSet: "rollout-abc", KindPod: "rollout-abc-1"} {
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: name}}
+		_, err := r.reconcileKind(context.Background(), kinds[kind], req)
+		require.NoError(t, err)
+	}
+
+	exp := `
+# HELP version_checker_is_latest_workload_version Where the workload container in use is using the latest upstream registry version
+# TYPE version_checker_is_latest_workload_version gauge
+version_checker_is_latest_workload_version{container="app",container_type="container",current_version="v0.1.0",image="localhost:5000/app",latest_version="v0.2.0",mirror_image="",namespace="default",platform="",workload_kind="ReplicaSet",workload_name="rollout-abc"} 0
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(exp), metrics.MetricNamespace+"_is_latest_workload_version"))
+}
+
+func TestWorkloadReconcileTemplate(t *testing.T) {
+	suspended := true
+	template := corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{
+		{Name: "backup", Image: "localhost:5000/backup:v0.1.0"},
+	}}}
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default"},
+		Spec: batchv1.CronJobSpec{
+			Suspend:     &suspended,
+			JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: template}},
+		},
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "backup-123", Namespace: "default", OwnerReferences: controllerRef(KindCronJob, "backup"),
+		},
+		Spec: batchv1.JobSpec{Template: template},
+	}
+	kubeClient := newWorkloadClientBuilder().WithObjects(cronJob, job).Build()
+
+	search := fakesearch.New().
+		With(&api.ImageTag{Tag: "v0.2.0", SHA: "sha:456"}, nil).
+		WithResolvedSHA("sha:123", nil)
+
+	kinds := make(map[string]workloadKind)
+	for _, kind := range workloadKinds {
+		kinds[kind.kind] = kind
+	}
+
+	for _, checkTemplates := range []bool{false, true} {
+		reg := prometheus.NewRegistry()
+		r := &WorkloadReconciler{
+			Client:          kubeClient,
+			Log:             testLogger,
+			Metrics:         metrics.New(testLogger, reg, kubeClient),
+			VersionChecker:  checker.New(search),
+			RequeueDuration: time.Hour,
+			defaultTestAll:  true,
+			checkTemplates:  checkTemplates,
+		}
+
+		for kind, name := range map[string]string{KindCronJob: "backup", KindJob: "backup-123"} {
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: name}}
+			_, err := r.reconcileKind(context.Background(), kinds[kind], req)
+			require.NoError(t, err)
+		}
+
+		// The Job is owned by the CronJob, so only the CronJob template is reported.
+		expCount := 0
+		if checkTemplates {
+			expCount = 1
+		}
+		count, err := testutil.GatherAndCount(reg, metrics.MetricNamespace+"_is_latest_workload_version")
+		require.NoError(t, err)
+		assert.Equal(t, expCount, count, "checkTemplates=%t", checkTemplates)
+	}
+}
+
+func TestWorkloadSetupWithManager(t *testing.T) {
+	kubeClient := fake.NewClientBuilder().Build()
+	metrics := metrics.New(
+		logrus.NewEntry(logrus.StandardLogger()),
+		prometheus.NewRegistry(),
+		kubeClient,
+	)
+	imageClient := &client.Client{}
+	controller := NewWorkloadReconciler(5*time.Minute, cache.Options{}, metrics, imageClient, kubeClient, testLogger, time.Hour, true, true, true, true, false, false, nil)
+
+	mgr, err := manager.New(&rest.Config{}, manager.Options{
+		LeaderElectionConfig: nil,
+		MapperProvider: func(*rest.Config, *http.Client) (meta.RESTMapper, error) {
+			return testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme), nil
+		},
+	})
+	require.NoError(t, err)
+
+	err = controller.SetupWithManager(mgr)
+	assert.NoError(t, err, "SetupWithManager should not return an error")
+}
@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/controller/checker"
+	"github.com/jetstack/version-checker/pkg/controller/options"
+	versionerrors "github.com/jetstack/version-checker/pkg/version/errors"
+)
+
+// sync will check the containers of the given workload, using the Pods it
+// owns to discover the running image digests. If a pod template is given,
+// containers without a running Pod are checked using the template image.
+func (r *WorkloadReconciler) sync(ctx context.Context, namespace string,
+	owner metav1.OwnerReference,
+	template *corev1.PodTemplateSpec,
+	pods []corev1.Pod,
+) error {
+	log := r.Log.WithFields(logrus.Fields{"namespace": namespace, "kind": owner.Kind, "name": owner.Name})
+
+	// Prefer the newest Pods, so that the result follows the latest rollout.
+	sort.SliceStable(pods, func(i, j int) bool {
+		return pods[j].CreationTimestamp.Before(&pods[i].CreationTimestamp)
+	})
+
+	var (
+		meta *metav1.ObjectMeta
+		spec *corev1.PodSpec
+	)
+	switch {
+	case len(pods) > 0:
+		meta, spec = &pods[0].ObjectMeta, &pods[0].Spec
+	case template != nil:
+		meta, spec = &template.ObjectMeta, &template.Spec
+	default:
+		log.Debug("no pods found for workload")
+		return nil
+	}
+
+	annotations := meta.Annotations
+	if r.policies != nil {
+		var err error
+		annotations, err = r.policies.Annotations(ctx, namespace, meta.Labels, meta.Annotations, spec)
+		if err != nil {
+			return fmt.Errorf("failed to match version check policies for %s %s/%s: %s",
+				owner.Kind, namespace, owner.Name, err)
+		}
+	}
+
+	builder := options.New(annotations)
+
+	var errs []string
+	for _, container := range spec.InitContainers {
+		if err := r.syncContainer(ctx, log, builder, namespace, owner, template, pods, &container, "init"); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	for _, container := range spec.Containers {
+		if err := r.syncContainer(ctx, log, builder, namespace, owner, template, pods, &container, "container"); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to sync %s %s/%s: %s",
+			owner.Kind, namespace, owner.Name, strings.Join(errs, ","))
+	}
+
+	return nil
+}
+
+// syncContainer will check the version of a given workload container.
+func (r *WorkloadReconciler) syncContainer(ctx context.Context,
+	log *logrus.Entry,
+	builder *options.Builder,
+	namespace string,
+	owner metav1.OwnerReference,
+	template *corev1.PodTemplateSpec,
+	pods []corev1.Pod,
+	container *corev1.Container,
+	containerType string,
+) error {
+	// If not enabled, exit early
+	if !builder.IsEnabled(r.defaultTestAll, container.Name) {
+		r.Metrics.RemoveWorkloadImage(namespace, owner.Kind, owner.Name, container.Name, containerType)
+		if r.reports != nil {
+			if err := r.reports.remove(ctx, namespace, owner, container.Name, containerType); err != nil {
+				log.WithError(err).Error("failed to update image version report")
+			}
+		}
+		return nil
+	}
+
+	opts, err := builder.Options(container.Name)
+	if err != nil {
+		return fmt.Errorf("failed to build options from annotations for %q: %s",
+			container.Name, err)
+	}
+
+	log = log.WithField("container", container.Name)
+	log.Debug("processing container image")
+
+	result, err := r.checkContainer(ctx, log, namespace, template, pods, container, opts)
+	if err != nil || result != nil {
+		r.updateReport(ctx, log, namespace, owner, container, containerType, result, err)
+	}
+	// Don't re-sync, if no version found meeting search criteria
+	if versionerrors.IsNoVersionFound(err) {
+		log.Error(err.Error())
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check container image %q: %s",
+			container.Name, err)
+	}
+
+	// If no result ready yet, exit early
+	if result == nil {
+		return nil
+	}
+
+	if result.IsLatest {
+		log.Debugf("image is latest %s:%s",
+			result.ImageURL, result.CurrentVersion)
+	} else {
+		log.Debugf("image is not latest %s: %s -> %s",
+			result.ImageURL, result.CurrentVersion, result.LatestVersion)
+	}
+
+	r.Metrics.AddWorkloadImage(namespace, owner.Kind, owner.Name,
+		container.Name, containerType,
+		result.ImageURL, result.MirrorURL, result.Platform, result.IsLatest,
+		result.CurrentVersion, result.LatestVersion,
+	)
+	r.Metrics.SetWorkloadImageDistance(namespace, owner.Kind, owner.Name,
+		container.Name, containerType,
+		result.ImageURL, result.Distance,
+	)
+	r.Metrics.SetWorkloadTagDigestDrift(namespace, owner.Kind, owner.Name,
+		container.Name, containerType,
+		result.ImageURL, result.TagDrift,
+	)
+
+	return nil
+}
+
+// updateReport records the check result, or error, of the container on the
+// workload's ImageVersionReport, if reports are enabled.
+func (r *WorkloadReconciler) updateReport(ctx context.Context, log *logrus.Entry,
+	namespace string,
+	owner metav1.OwnerReference,
+	container *corev1.Container,
+	containerType string,
+	result *checker.Result,
+	checkErr error,
+) {
+	if r.reports == nil {
+		return
+	}
+
+	status := r.reports.newContainerStatus(container.Name, containerType, container.Image, result, checkErr)
+	if err := r.reports.update(ctx, namespace, owner, status); err != nil {
+		log.WithError(err).Error("failed to update image version report")
+	}
+}
+
+// checkContainer returns the result of the first Pod, which runs the same image
+// for the container, that has a ready container status. If no Pod is ready and
+// a pod template is given, the template image is checked instead.
+func (r *WorkloadReconciler) checkContainer(ctx context.Context, log *logrus.Entry,
+	namespace string,
+	template *corev1.PodTemplateSpec,
+	pods []corev1.Pod,
+	container *corev1.Container,
+	opts *api.Options,
+) (*checker.Result, error) {
+	for i := range pods {
+		if !podRunsImage(&pods[i].Spec, container) {
+			continue
+		}
+
+		// Options may be modified by the checker, so copy for each attempt.
+		podOpts := *opts
+		result, err := r.VersionChecker.Container(ctx, log, &pods[i], container, &podOpts)
+		if err != nil || result != nil {
+			return result, err
+		}
+	}
+
+	if template == nil || !podRunsImage(&template.Spec, container) {
+		return nil, nil
+	}
+
+	log.Debug("no ready pods found, checking pod template image")
+	return r.VersionChecker.Template(ctx, log, namespace, &template.Spec, container, opts)
+}
+
+// podRunsImage returns whether the pod spec has a container of the same name
+// and image as the given container.
+func podRunsImage(spec *corev1.PodSpec, container *corev1.Container) bool {
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for _, c := range containers {
+			if c.Name == container.Name {
+				return c.Image == container.Image
+			}
+		}
+	}
+	return false
+}
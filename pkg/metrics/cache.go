@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"github.com/jetstack/version-checker/pkg/cache"
+)
+
+// Ensure that we are a cache Recorder
+var _ cache.Recorder = (*Metrics)(nil)
+
+// CacheHit records an item served from the named cache before expiry.
+func (m *Metrics) CacheHit(cache string) {
+	m.cacheHits.WithLabelValues(cache).Inc()
+}
+
+// CacheMiss records an item fetched because it was not in the named cache.
+func (m *Metrics) CacheMiss(cache string) {
+	m.cacheMisses.WithLabelValues(cache).Inc()
+}
+
+// CacheStale records an expired item served from the named cache while it
+// is refreshed.
+func (m *Metrics) CacheStale(cache string) {
+	m.cacheStale.WithLabelValues(cache).Inc()
+}
+
+// CacheRefreshFailure records a failed background refresh of the named
+// cache.
+func (m *Metrics) CacheRefreshFailure(cache string) {
+	m.cacheRefreshFailures.WithLabelValues(cache).Inc()
+}
+
+// CacheCoalesced records a fetch of the named cache that waited on a
+// concurrent fetch of the same item.
+func (m *Metrics) CacheCoalesced(cache string) {
+	m.cacheCoalesced.WithLabelValues(cache).Inc()
+}
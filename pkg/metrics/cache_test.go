@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCacheRecorder(t *testing.T) {
+	m := New(logrus.NewEntry(logrus.New()), prometheus.NewRegistry(), fakek8s)
+
+	m.CacheHit("image")
+	m.CacheHit("image")
+	m.CacheMiss("image")
+	m.CacheStale("image")
+	m.CacheRefreshFailure("image")
+	m.CacheCoalesced("image")
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.cacheHits.WithLabelValues("image")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.cacheMisses.WithLabelValues("image")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.cacheStale.WithLabelValues("image")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.cacheRefreshFailures.WithLabelValues("image")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.cacheCoalesced.WithLabelValues("image")))
+}
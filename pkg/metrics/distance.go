@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+// Levels of the versions_behind metric.
+const (
+	LevelMajor    = "major"
+	LevelMinor    = "minor"
+	LevelPatch    = "patch"
+	LevelReleases = "releases"
+)
+
+// SetImageDistance exposes how far the container image is behind the latest
+// version. If distance is nil, e.g. when comparing SHA digests, any existing
+// series for the container are removed.
+func (m *Metrics) SetImageDistance(namespace, pod, container, containerType, imageURL string, distance *api.VersionDistance) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	labels := buildContainerPartialLabels(namespace, pod, container, containerType)
+	m.containerVersionsBehind.DeletePartialMatch(labels)
+	m.containerReleaseAge.DeletePartialMatch(labels)
+
+	if distance == nil {
+		return
+	}
+
+	for level, value := range map[string]float64{
+		LevelMajor:    float64(distance.Major),
+		LevelMinor:    float64(distance.Minor),
+		LevelPatch:    float64(distance.Patch),
+		LevelReleases: float64(distance.Releases),
+	} {
+		m.containerVersionsBehind.With(
+			buildDistanceLabels(namespace, pod, container, containerType, imageURL, level),
+		).Set(value)
+	}
+
+	m.containerReleaseAge.With(
+		buildLastUpdatedLabels(namespace, pod, container, containerType, imageURL),
+	).Set(distance.Age.Seconds())
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+func TestSetImageDistance(t *testing.T) {
+	m := New(logrus.NewEntry(logrus.New()), prometheus.NewRegistry(), fakek8s)
+
+	m.SetImageDistance("namespace", "pod", "container", "container", "url", &api.VersionDistance{
+		Minor: 3, Releases: 7, Age: time.Hour,
+	})
+
+	assert.Equal(t, 4,
+		testutil.CollectAndCount(m.containerVersionsBehind.MetricVec, MetricNamespace+"_versions_behind"),
+	)
+
+	for level, exp := range map[string]float64{
+		LevelMajor: 0, LevelMinor: 3, LevelPatch: 0, LevelReleases: 7,
+	} {
+		mt, err := m.containerVersionsBehind.GetMetricWith(
+			buildDistanceLabels("namespace", "pod", "container", "container", "url", level),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, exp, testutil.ToFloat64(mt), level)
+	}
+
+	mt, err := m.containerReleaseAge.GetMetricWith(
+		buildLastUpdatedLabels("namespace", "pod", "container", "container", "url"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour.Seconds(), testutil.ToFloat64(mt))
+
+	// A nil distance removes the series
+	m.SetImageDistance("namespace", "pod", "container", "container", "url", nil)
+	assert.Equal(t, 0,
+		testutil.CollectAndCount(m.containerVersionsBehind.MetricVec, MetricNamespace+"_versions_behind"),
+	)
+	assert.Equal(t, 0,
+		testutil.CollectAndCount(m.containerReleaseAge.MetricVec, MetricNamespace+"_release_age_seconds"),
+	)
+
+	// Removing the pod removes the series
+	m.SetImageDistance("namespace", "pod", "container", "container", "url", &api.VersionDistance{Patch: 1})
+	m.RemovePod("namespace", "pod")
+	assert.Equal(t, 0,
+		testutil.CollectAndCount(m.containerVersionsBehind.MetricVec, MetricNamespace+"_versions_behind"),
+	)
+}
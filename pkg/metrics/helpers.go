@@ -9,13 +9,15 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-func buildFullLabels(namespace, pod, container, containerType, imageURL, currentVersion, latestVersion string) prometheus.Labels {
+func buildFullLabels(namespace, pod, container, containerType, imageURL, mirrorURL, platform, currentVersion, latestVersion string) prometheus.Labels {
 	return prometheus.Labels{
 		"namespace":       namespace,
 		"pod":             pod,
 		"container_type":  containerType,
 		"container":       container,
 		"image":           imageURL,
+		"mirror_image":    mirrorURL,
+		"platform":        platform,
 		"current_version": currentVersion,
 		"latest_version":  latestVersion,
 	}
@@ -31,6 +33,28 @@ func buildLastUpdatedLabels(namespace, pod, container, containerType, imageURL s
 	}
 }
 
+func buildTagDriftLabels(namespace, pod, container, containerType, imageURL, tag string) prometheus.Labels {
+	return prometheus.Labels{
+		"namespace":      namespace,
+		"pod":            pod,
+		"container_type": containerType,
+		"container":      container,
+		"image":          imageURL,
+		"tag":            tag,
+	}
+}
+
+func buildDistanceLabels(namespace, pod, container, containerType, imageURL, level string) prometheus.Labels {
+	return prometheus.Labels{
+		"namespace":      namespace,
+		"pod":            pod,
+		"container_type": containerType,
+		"container":      container,
+		"image":          imageURL,
+		"level":          level,
+	}
+}
+
 func buildPodPartialLabels(namespace, pod string) prometheus.Labels {
 	return prometheus.Labels{
 		"namespace": namespace,
@@ -47,6 +71,62 @@ func buildContainerPartialLabels(namespace, pod, container, containerType string
 	}
 }
 
+func buildWorkloadFullLabels(namespace, kind, name, container, containerType, imageURL, mirrorURL, platform, currentVersion, latestVersion string) prometheus.Labels {
+	return prometheus.Labels{
+		"namespace":       namespace,
+		"workload_kind":   kind,
+		"workload_name":   name,
+		"container_type":  containerType,
+		"container":       container,
+		"image":           imageURL,
+		"mirror_image":    mirrorURL,
+		"platform":        platform,
+		"current_version": currentVersion,
+		"latest_version":  latestVersion,
+	}
+}
+
+func buildWorkloadImageLabels(namespace, kind, name, container, containerType, imageURL string) prometheus.Labels {
+	return prometheus.Labels{
+		"namespace":      namespace,
+		"workload_kind":  kind,
+		"workload_name":  name,
+		"container_type": containerType,
+		"container":      container,
+		"image":          imageURL,
+	}
+}
+
+func buildWorkloadDistanceLabels(namespace, kind, name, container, containerType, imageURL, level string) prometheus.Labels {
+	labels := buildWorkloadImageLabels(namespace, kind, name, container, containerType, imageURL)
+	labels["level"] = level
+	return labels
+}
+
+func buildWorkloadTagDriftLabels(namespace, kind, name, container, containerType, imageURL, tag string) prometheus.Labels {
+	labels := buildWorkloadImageLabels(namespace, kind, name, container, containerType, imageURL)
+	labels["tag"] = tag
+	return labels
+}
+
+func buildWorkloadPartialLabels(namespace, kind, name string) prometheus.Labels {
+	return prometheus.Labels{
+		"namespace":     namespace,
+		"workload_kind": kind,
+		"workload_name": name,
+	}
+}
+
+func buildWorkloadContainerPartialLabels(namespace, kind, name, container, containerType string) prometheus.Labels {
+	return prometheus.Labels{
+		"namespace":      namespace,
+		"workload_kind":  kind,
+		"workload_name":  name,
+		"container":      container,
+		"container_type": containerType,
+	}
+}
+
 // This _should_ leverage the Controllers Cache
 func (m *Metrics) PodExists(ctx context.Context, ns, name string) bool {
 	pod := &corev1.Pod{}
@@ -28,6 +28,33 @@ type Metrics struct {
 	containerImageDuration *prometheus.GaugeVec
 	containerImageErrors   *prometheus.CounterVec
 
+	// Version distance metrics
+	containerVersionsBehind *prometheus.GaugeVec
+	containerReleaseAge     *prometheus.GaugeVec
+
+	// Tag digest drift metric
+	containerTagDigestDrift *prometheus.GaugeVec
+
+	// Workload image version metrics
+	workloadImageVersion   *prometheus.GaugeVec
+	workloadVersionsBehind *prometheus.GaugeVec
+	workloadReleaseAge     *prometheus.GaugeVec
+	workloadTagDigestDrift *prometheus.GaugeVec
+
+	// Cache metrics
+	cacheHits            *prometheus.CounterVec
+	cacheMisses          *prometheus.CounterVec
+	cacheStale           *prometheus.CounterVec
+	cacheRefreshFailures *prometheus.CounterVec
+	cacheCoalesced       *prometheus.CounterVec
+
+	// Registry rate limit metrics
+	registryRateLimitWait      *prometheus.HistogramVec
+	registryRateLimitRemaining *prometheus.GaugeVec
+
+	// Mirror metrics
+	mirrorMissingTags *prometheus.GaugeVec
+
 	// Kubernetes version metric
 	kubernetesVersion *prometheus.GaugeVec
 
@@ -53,7 +80,7 @@ func New(log *logrus.Entry, reg ctrmetrics.RegistererGatherer, cache k8sclient.R
 			Help:      "Where the container in use is using the latest upstream registry version",
 		},
 		[]string{
-			"namespace", "pod", "container", "container_type", "image", "current_version", "latest_version",
+			"namespace", "pod", "container", "container_type", "image", "mirror_image", "platform", "current_version", "latest_version",
 		},
 	)
 	containerImageChecked := promauto.With(reg).NewGaugeVec(
@@ -84,6 +111,141 @@ func New(log *logrus.Entry, reg ctrmetrics.RegistererGatherer, cache k8sclient.R
 			"namespace", "pod", "container", "image",
 		},
 	)
+	containerVersionsBehind := promauto.With(reg).NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricNamespace,
+			Name:      "versions_behind",
+			Help:      "How far the container image is behind the latest upstream registry version, by major, minor, patch and number of releases",
+		},
+		[]string{
+			"namespace", "pod", "container", "container_type", "image", "level",
+		},
+	)
+	containerReleaseAge := promauto.With(reg).NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricNamespace,
+			Name:      "release_age_seconds",
+			Help:      "How much older the container image is than the latest upstream registry version, based on image timestamps",
+		},
+		[]string{
+			"namespace", "pod", "container", "container_type", "image",
+		},
+	)
+	containerTagDigestDrift := promauto.With(reg).NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricNamespace,
+			Name:      "tag_digest_drift",
+			Help:      "Whether the registry digest of the tag the container runs is different to the digest it is running",
+		},
+		[]string{
+			"namespace", "pod", "container", "container_type", "image", "tag",
+		},
+	)
+	workloadImageVersion := promauto.With(reg).NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricNamespace,
+			Name:      "is_latest_workload_version",
+			Help:      "Where the workload container in use is using the latest upstream registry version",
+		},
+		[]string{
+			"namespace", "workload_kind", "workload_name", "container", "container_type", "image", "mirror_image", "platform", "current_version", "latest_version",
+		},
+	)
+	workloadVersionsBehind := promauto.With(reg).NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricNamespace,
+			Name:      "workload_versions_behind",
+			Help:      "How far the workload container image is behind the latest upstream registry version, by major, minor, patch and number of releases",
+		},
+		[]string{
+			"namespace", "workload_kind", "workload_name", "container", "container_type", "image", "level",
+		},
+	)
+	workloadReleaseAge := promauto.With(reg).NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricNamespace,
+			Name:      "workload_release_age_seconds",
+			Help:      "How much older the workload container image is than the latest upstream registry version, based on image timestamps",
+		},
+		[]string{
+			"namespace", "workload_kind", "workload_name", "container", "container_type", "image",
+		},
+	)
+	workloadTagDigestDrift := promauto.With(reg).NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricNamespace,
+			Name:      "workload_tag_digest_drift",
+			Help:      "Whether the registry digest of the tag the workload container runs is different to the digest it is running",
+		},
+		[]string{
+			"namespace", "workload_kind", "workload_name", "container", "container_type", "image", "tag",
+		},
+	)
+	cacheHits := promauto.With(reg).NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricNamespace,
+			Name:      "cache_hits_total",
+			Help:      "Total number of items served from the cache before expiry",
+		},
+		[]string{"cache"},
+	)
+	cacheMisses := promauto.With(reg).NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricNamespace,
+			Name:      "cache_misses_total",
+			Help:      "Total number of items fetched because they were not in the cache",
+		},
+		[]string{"cache"},
+	)
+	cacheStale := promauto.With(reg).NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricNamespace,
+			Name:      "cache_stale_total",
+			Help:      "Total number of expired items served from the cache while they were refreshed in the background",
+		},
+		[]string{"cache"},
+	)
+	cacheRefreshFailures := promauto.With(reg).NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricNamespace,
+			Name:      "cache_refresh_failures_total",
+			Help:      "Total number of failed background refreshes of cache items",
+		},
+		[]string{"cache"},
+	)
+	cacheCoalesced := promauto.With(reg).NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricNamespace,
+			Name:      "cache_coalesced_total",
+			Help:      "Total number of cache fetches that waited on a concurrent fetch of the same item, rather than fetching it again",
+		},
+		[]string{"cache"},
+	)
+	registryRateLimitWait := promauto.With(reg).NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: MetricNamespace,
+			Name:      "registry_rate_limit_wait_seconds",
+			Help:      "Time registry requests waited for the registry's rate limit and concurrency budget",
+			Buckets:   []float64{0.001, 0.01, 0.1, 0.5, 1, 5, 10, 30, 60, 300},
+		},
+		[]string{"registry"},
+	)
+	registryRateLimitRemaining := promauto.With(reg).NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricNamespace,
+			Name:      "registry_rate_limit_remaining",
+			Help:      "Remaining request quota that the registry last reported",
+		},
+		[]string{"registry"},
+	)
+	mirrorMissingTags := promauto.With(reg).NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricNamespace,
+			Name:      "mirror_missing_tags",
+			Help:      "Number of upstream image tags which are missing from the mirror, or have a different digest in it",
+		},
+		[]string{"mirror", "upstream", "reason"},
+	)
 	kubernetesVersion := promauto.With(reg).NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "version_checker",
@@ -104,12 +266,34 @@ func New(log *logrus.Entry, reg ctrmetrics.RegistererGatherer, cache k8sclient.R
 		containerImageDuration: containerImageDuration,
 		containerImageChecked:  containerImageChecked,
 		containerImageErrors:   containerImageErrors,
+		workloadImageVersion:   workloadImageVersion,
+		workloadVersionsBehind: workloadVersionsBehind,
+		workloadReleaseAge:     workloadReleaseAge,
+		workloadTagDigestDrift: workloadTagDigestDrift,
 		kubernetesVersion:      kubernetesVersion,
 		roundTripper:           NewRoundTripper(reg),
+
+		containerVersionsBehind: containerVersionsBehind,
+		containerReleaseAge:     containerReleaseAge,
+		containerTagDigestDrift: containerTagDigestDrift,
+
+		cacheHits:            cacheHits,
+		cacheMisses:          cacheMisses,
+		cacheStale:           cacheStale,
+		cacheRefreshFailures: cacheRefreshFailures,
+		cacheCoalesced:       cacheCoalesced,
+
+		registryRateLimitWait:      registryRateLimitWait,
+		registryRateLimitRemaining: registryRateLimitRemaining,
+
+		mirrorMissingTags: mirrorMissingTags,
 	}
 }
 
-func (m *Metrics) AddImage(namespace, pod, container, containerType, imageURL string, isLatest bool, currentVersion, latestVersion string) {
+// AddImage sets whether the container's image is the latest version. The
+// image URL is that whose versions were looked up, and the mirror URL that
+// of the mirror the image is pulled through, if any.
+func (m *Metrics) AddImage(namespace, pod, container, containerType, imageURL, mirrorURL, platform string, isLatest bool, currentVersion, latestVersion string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -127,7 +311,7 @@ func (m *Metrics) AddImage(namespace, pod, container, containerType, imageURL st
 	m.containerImageChecked.DeletePartialMatch(labels)
 
 	m.containerImageVersion.With(
-		buildFullLabels(namespace, pod, container, containerType, imageURL, currentVersion, latestVersion),
+		buildFullLabels(namespace, pod, container, containerType, imageURL, mirrorURL, platform, currentVersion, latestVersion),
 	).Set(isLatestF)
 
 	// Bump last updated timestamp
@@ -147,6 +331,9 @@ func (m *Metrics) RemoveImage(namespace, pod, container, containerType string) {
 	total += m.containerImageDuration.DeletePartialMatch(labels)
 	total += m.containerImageChecked.DeletePartialMatch(labels)
 	total += m.containerImageErrors.DeletePartialMatch(labels)
+	total += m.containerVersionsBehind.DeletePartialMatch(labels)
+	total += m.containerReleaseAge.DeletePartialMatch(labels)
+	total += m.containerTagDigestDrift.DeletePartialMatch(labels)
 
 	m.log.Infof("Removed %d metrics for image %s/%s/%s (%s)", total, namespace, pod, container, containerType)
 }
@@ -168,6 +355,15 @@ func (m *Metrics) RemovePod(namespace, pod string) {
 	total += m.containerImageErrors.DeletePartialMatch(
 		buildPodPartialLabels(namespace, pod),
 	)
+	total += m.containerVersionsBehind.DeletePartialMatch(
+		buildPodPartialLabels(namespace, pod),
+	)
+	total += m.containerReleaseAge.DeletePartialMatch(
+		buildPodPartialLabels(namespace, pod),
+	)
+	total += m.containerTagDigestDrift.DeletePartialMatch(
+		buildPodPartialLabels(namespace, pod),
+	)
 
 	m.log.Infof("Removed %d metrics for pod %s/%s", total, namespace, pod)
 }
@@ -30,14 +30,14 @@ func TestCache(t *testing.T) {
 	// Lets add some Images/Metrics...
 	for i, typ := range []string{"init", "container"} {
 		version := fmt.Sprintf("0.1.%d", i)
-		m.AddImage("namespace", "pod", "container", typ, "url", true, version, version)
+		m.AddImage("namespace", "pod", "container", typ, "url", "", "", true, version, version)
 	}
 
 	// Check and ensure that the metrics are available...
 	for i, typ := range []string{"init", "container"} {
 		version := fmt.Sprintf("0.1.%d", i)
 		mt, _ := m.containerImageVersion.GetMetricWith(
-			buildFullLabels("namespace", "pod", "container", typ, "url", version, version),
+			buildFullLabels("namespace", "pod", "container", typ, "url", "", "", version, version),
 		)
 		count := testutil.ToFloat64(mt)
 		assert.Equal(t, count, float64(1), "Expected to get a metric for containerImageVersion")
@@ -59,7 +59,7 @@ func TestCache(t *testing.T) {
 	for i, typ := range []string{"init", "container"} {
 		version := fmt.Sprintf("0.1.%d", i)
 		mt, _ := m.containerImageVersion.GetMetricWith(
-			buildFullLabels("namespace", "pod", "container", typ, "url", version, version),
+			buildFullLabels("namespace", "pod", "container", typ, "url", "", "", version, version),
 		)
 		count := testutil.ToFloat64(mt)
 		assert.Equal(t, count, float64(0), "Expected NOT to get a metric for containerImageVersion")
@@ -77,8 +77,8 @@ func TestAddImageReplacesExistingVersionMetrics(t *testing.T) {
 	reg := prometheus.NewRegistry()
 	m := New(logrus.NewEntry(logrus.New()), reg, fakek8s)
 
-	m.AddImage("namespace", "pod", "container", "container", "url", false, "1.0.0", "1.1.0")
-	m.AddImage("namespace", "pod", "container", "container", "url", true, "1.1.0", "1.1.0")
+	m.AddImage("namespace", "pod", "container", "container", "url", "", "", false, "1.0.0", "1.1.0")
+	m.AddImage("namespace", "pod", "container", "container", "url", "", "", true, "1.1.0", "1.1.0")
 
 	assert.Equal(t, 1,
 		testutil.CollectAndCount(m.containerImageVersion.MetricVec, MetricNamespace+"_is_latest_version"),
@@ -95,17 +95,63 @@ func TestAddImageReplacesExistingVersionMetrics(t *testing.T) {
 		"container":       "container",
 		"container_type":  "container",
 		"image":           "url",
+		"mirror_image":    "",
+		"platform":        "",
 		"current_version": "1.0.0",
 		"latest_version":  "1.1.0",
 	}))
 
 	currentMetric, err := m.containerImageVersion.GetMetricWith(
-		buildFullLabels("namespace", "pod", "container", "container", "url", "1.1.0", "1.1.0"),
+		buildFullLabels("namespace", "pod", "container", "container", "url", "", "", "1.1.0", "1.1.0"),
 	)
 	require.NoError(t, err)
 	assert.Equal(t, float64(1), testutil.ToFloat64(currentMetric))
 }
 
+func TestAddImageOfMirror(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := New(logrus.NewEntry(logrus.New()), reg, fakek8s)
+
+	m.AddImage("namespace", "pod", "container", "container",
+		"docker.io/library/nginx", "harbor.corp/dockerhub-proxy/library/nginx", "", true, "1.27.0", "1.27.0")
+
+	metricFamilies, err := reg.Gather()
+	require.NoError(t, err)
+	assert.True(t, hasMetricWithLabels(metricFamilies, MetricNamespace+"_is_latest_version", map[string]string{
+		"namespace":       "namespace",
+		"pod":             "pod",
+		"container":       "container",
+		"container_type":  "container",
+		"image":           "docker.io/library/nginx",
+		"mirror_image":    "harbor.corp/dockerhub-proxy/library/nginx",
+		"platform":        "",
+		"current_version": "1.27.0",
+		"latest_version":  "1.27.0",
+	}))
+}
+
+func TestAddImageOfPlatform(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := New(logrus.NewEntry(logrus.New()), reg, fakek8s)
+
+	m.AddImage("namespace", "pod", "container", "container",
+		"docker.io/library/nginx", "", "linux/arm64", false, "1.27.0@sha:123", "1.27.0@sha:456")
+
+	metricFamilies, err := reg.Gather()
+	require.NoError(t, err)
+	assert.True(t, hasMetricWithLabels(metricFamilies, MetricNamespace+"_is_latest_version", map[string]string{
+		"namespace":       "namespace",
+		"pod":             "pod",
+		"container":       "container",
+		"container_type":  "container",
+		"image":           "docker.io/library/nginx",
+		"mirror_image":    "",
+		"platform":        "linux/arm64",
+		"current_version": "1.27.0@sha:123",
+		"latest_version":  "1.27.0@sha:456",
+	}))
+}
+
 func hasMetricWithLabels(metricFamilies []*dto.MetricFamily, name string, labels map[string]string) bool {
 	for _, metricFamily := range metricFamilies {
 		if metricFamily.GetName() != name {
@@ -257,9 +303,9 @@ func TestPodAnnotationsChangeAfterRegistration(t *testing.T) {
 	metrics := New(log, reg, client)
 
 	// Register Metrics...
-	metrics.AddImage("default", "mypod", "my-init-container", "init", "alpine:latest", false, "1.0", "1.1")
-	metrics.AddImage("default", "mypod", "mycontainer", "container", "nginx:1.0", true, "1.0", "1.0")
-	metrics.AddImage("default", "mypod", "sidecar", "container", "alpine:1.0", false, "1.0", "1.1")
+	metrics.AddImage("default", "mypod", "my-init-container", "init", "alpine:latest", "", "", false, "1.0", "1.1")
+	metrics.AddImage("default", "mypod", "mycontainer", "container", "nginx:1.0", "", "", true, "1.0", "1.0")
+	metrics.AddImage("default", "mypod", "sidecar", "container", "alpine:1.0", "", "", false, "1.0", "1.1")
 
 	_, err := reg.Gather()
 	require.NoError(t, err, "Failed to gather metrics")
@@ -0,0 +1,21 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// SetMirrorMissingTags sets the number of upstream tags which are missing
+// from the mirror, and which have a different digest in it.
+func (m *Metrics) SetMirrorMissingTags(mirror, upstream string, missing, mismatched int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.mirrorMissingTags.With(buildMirrorLabels(mirror, upstream, "missing")).Set(float64(missing))
+	m.mirrorMissingTags.With(buildMirrorLabels(mirror, upstream, "digest_mismatch")).Set(float64(mismatched))
+}
+
+func buildMirrorLabels(mirror, upstream, reason string) prometheus.Labels {
+	return prometheus.Labels{
+		"mirror":   mirror,
+		"upstream": upstream,
+		"reason":   reason,
+	}
+}
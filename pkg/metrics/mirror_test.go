@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestSetMirrorMissingTags(t *testing.T) {
+	m := New(logrus.NewEntry(logrus.New()), prometheus.NewRegistry(), fakek8s)
+
+	m.SetMirrorMissingTags("harbor.corp/library/nginx", "docker.io/library/nginx", 3, 1)
+	m.SetMirrorMissingTags("harbor.corp/library/nginx", "docker.io/library/nginx", 2, 0)
+
+	assert.Equal(t, 2,
+		testutil.CollectAndCount(m.mirrorMissingTags.MetricVec, MetricNamespace+"_mirror_missing_tags"),
+	)
+
+	for reason, exp := range map[string]float64{"missing": 2, "digest_mismatch": 0} {
+		mt, err := m.mirrorMissingTags.GetMetricWith(
+			buildMirrorLabels("harbor.corp/library/nginx", "docker.io/library/nginx", reason),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, exp, testutil.ToFloat64(mt), reason)
+	}
+}
@@ -0,0 +1,25 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+// Ensure that we are a rate limit and quota observer
+var (
+	_ util.RateLimitObserver = (*Metrics)(nil)
+	_ util.QuotaObserver     = (*Metrics)(nil)
+)
+
+// RegistryRateLimitWait records how long a request to the registry host
+// waited for its rate limit and concurrency budget.
+func (m *Metrics) RegistryRateLimitWait(host string, wait time.Duration) {
+	m.registryRateLimitWait.WithLabelValues(host).Observe(wait.Seconds())
+}
+
+// RegistryRateLimitRemaining records the remaining request quota that the
+// registry host reported.
+func (m *Metrics) RegistryRateLimitRemaining(host string, remaining int) {
+	m.registryRateLimitRemaining.WithLabelValues(host).Set(float64(remaining))
+}
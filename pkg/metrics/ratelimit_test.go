@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestRegistryRateLimitWait(t *testing.T) {
+	m := New(logrus.NewEntry(logrus.New()), prometheus.NewRegistry(), fakek8s)
+
+	m.RegistryRateLimitWait("quay.io", 0)
+	m.RegistryRateLimitWait("quay.io", 2*time.Second)
+	m.RegistryRateLimitWait("myregistry.azurecr.io", time.Second)
+
+	assert.Equal(t, 2, testutil.CollectAndCount(m.registryRateLimitWait))
+
+	var metric dto.Metric
+	assert.NoError(t, m.registryRateLimitWait.WithLabelValues("quay.io").(prometheus.Histogram).Write(&metric))
+	assert.Equal(t, uint64(2), metric.GetHistogram().GetSampleCount())
+	assert.Equal(t, float64(2), metric.GetHistogram().GetSampleSum())
+}
+
+func TestRegistryRateLimitRemaining(t *testing.T) {
+	m := New(logrus.NewEntry(logrus.New()), prometheus.NewRegistry(), fakek8s)
+
+	m.RegistryRateLimitRemaining("registry.hub.docker.com", 100)
+	m.RegistryRateLimitRemaining("registry.hub.docker.com", 76)
+	m.RegistryRateLimitRemaining("quay.io", 0)
+
+	assert.Equal(t, float64(76), testutil.ToFloat64(m.registryRateLimitRemaining.WithLabelValues("registry.hub.docker.com")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.registryRateLimitRemaining.WithLabelValues("quay.io")))
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+// SetTagDigestDrift exposes whether the registry digest of the tag the
+// container runs is different to the running digest, as 1, or 0 if it is the
+// same. If drift is nil, e.g. for images pinned by digest, any existing
+// series for the container are removed.
+func (m *Metrics) SetTagDigestDrift(namespace, pod, container, containerType, imageURL string, drift *api.TagDrift) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.containerTagDigestDrift.DeletePartialMatch(
+		buildContainerPartialLabels(namespace, pod, container, containerType),
+	)
+
+	if drift == nil {
+		return
+	}
+
+	driftedF := 0.0
+	if drift.Drifted {
+		driftedF = 1.0
+	}
+
+	m.containerTagDigestDrift.With(
+		buildTagDriftLabels(namespace, pod, container, containerType, imageURL, drift.Tag),
+	).Set(driftedF)
+}
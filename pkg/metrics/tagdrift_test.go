@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+func TestSetTagDigestDrift(t *testing.T) {
+	m := New(logrus.NewEntry(logrus.New()), prometheus.NewRegistry(), fakek8s)
+
+	m.SetTagDigestDrift("namespace", "pod", "container", "container", "url", &api.TagDrift{Tag: "1.25"})
+	m.SetTagDigestDrift("namespace", "pod", "container", "container", "url", &api.TagDrift{Tag: "stable", Drifted: true})
+
+	// Only the series of the tag last checked is kept
+	assert.Equal(t, 1,
+		testutil.CollectAndCount(m.containerTagDigestDrift.MetricVec, MetricNamespace+"_tag_digest_drift"),
+	)
+	mt, err := m.containerTagDigestDrift.GetMetricWith(
+		buildTagDriftLabels("namespace", "pod", "container", "container", "url", "stable"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), testutil.ToFloat64(mt))
+
+	// A nil drift removes the series
+	m.SetTagDigestDrift("namespace", "pod", "container", "container", "url", nil)
+	assert.Equal(t, 0,
+		testutil.CollectAndCount(m.containerTagDigestDrift.MetricVec, MetricNamespace+"_tag_digest_drift"),
+	)
+
+	// Removing the pod removes the series
+	m.SetTagDigestDrift("namespace", "pod", "container", "container", "url", &api.TagDrift{Tag: "stable"})
+	m.RemovePod("namespace", "pod")
+	assert.Equal(t, 0,
+		testutil.CollectAndCount(m.containerTagDigestDrift.MetricVec, MetricNamespace+"_tag_digest_drift"),
+	)
+}
@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+func (m *Metrics) AddWorkloadImage(namespace, kind, name, container, containerType, imageURL, mirrorURL, platform string, isLatest bool, currentVersion, latestVersion string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	isLatestF := 0.0
+	if isLatest {
+		isLatestF = 1.0
+	}
+
+	// Remove any existing series for this container, as the version labels
+	// may have changed since the last check.
+	m.workloadImageVersion.DeletePartialMatch(
+		buildWorkloadContainerPartialLabels(namespace, kind, name, container, containerType),
+	)
+
+	m.workloadImageVersion.With(
+		buildWorkloadFullLabels(namespace, kind, name, container, containerType, imageURL, mirrorURL, platform, currentVersion, latestVersion),
+	).Set(isLatestF)
+}
+
+// SetWorkloadImageDistance exposes how far the workload container image is
+// behind the latest version, the same as SetImageDistance for Pods.
+func (m *Metrics) SetWorkloadImageDistance(namespace, kind, name, container, containerType, imageURL string, distance *api.VersionDistance) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	labels := buildWorkloadContainerPartialLabels(namespace, kind, name, container, containerType)
+	m.workloadVersionsBehind.DeletePartialMatch(labels)
+	m.workloadReleaseAge.DeletePartialMatch(labels)
+
+	if distance == nil {
+		return
+	}
+
+	for level, value := range map[string]float64{
+		LevelMajor:    float64(distance.Major),
+		LevelMinor:    float64(distance.Minor),
+		LevelPatch:    float64(distance.Patch),
+		LevelReleases: float64(distance.Releases),
+	} {
+		m.workloadVersionsBehind.With(
+			buildWorkloadDistanceLabels(namespace, kind, name, container, containerType, imageURL, level),
+		).Set(value)
+	}
+
+	m.workloadReleaseAge.With(
+		buildWorkloadImageLabels(namespace, kind, name, container, containerType, imageURL),
+	).Set(distance.Age.Seconds())
+}
+
+// SetWorkloadTagDigestDrift exposes whether the registry digest of the tag
+// the workload container runs has drifted, the same as SetTagDigestDrift for
+// Pods.
+func (m *Metrics) SetWorkloadTagDigestDrift(namespace, kind, name, container, containerType, imageURL string, drift *api.TagDrift) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.workloadTagDigestDrift.DeletePartialMatch(
+		buildWorkloadContainerPartialLabels(namespace, kind, name, container, containerType),
+	)
+
+	if drift == nil {
+		return
+	}
+
+	driftedF := 0.0
+	if drift.Drifted {
+		driftedF = 1.0
+	}
+
+	m.workloadTagDigestDrift.With(
+		buildWorkloadTagDriftLabels(namespace, kind, name, container, containerType, imageURL, drift.Tag),
+	).Set(driftedF)
+}
+
+func (m *Metrics) RemoveWorkloadImage(namespace, kind, name, container, containerType string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	labels := buildWorkloadContainerPartialLabels(namespace, kind, name, container, containerType)
+
+	total := m.workloadImageVersion.DeletePartialMatch(labels)
+	total += m.workloadVersionsBehind.DeletePartialMatch(labels)
+	total += m.workloadReleaseAge.DeletePartialMatch(labels)
+	total += m.workloadTagDigestDrift.DeletePartialMatch(labels)
+
+	m.log.Infof("Removed %d metrics for workload image %s/%s/%s/%s (%s)", total, namespace, kind, name, container, containerType)
+}
+
+func (m *Metrics) RemoveWorkload(namespace, kind, name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	labels := buildWorkloadPartialLabels(namespace, kind, name)
+
+	total := m.workloadImageVersion.DeletePartialMatch(labels)
+	total += m.workloadVersionsBehind.DeletePartialMatch(labels)
+	total += m.workloadReleaseAge.DeletePartialMatch(labels)
+	total += m.workloadTagDigestDrift.DeletePartialMatch(labels)
+
+	m.log.Infof("Removed %d metrics for workload %s/%s/%s", total, namespace, kind, name)
+}
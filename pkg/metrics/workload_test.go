@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+func TestAddWorkloadImage(t *testing.T) {
+	m := New(logrus.NewEntry(logrus.New()), prometheus.NewRegistry(), fakek8s)
+
+	m.AddWorkloadImage("namespace", "Deployment", "app", "container", "container", "url", "", "", false, "1.0.0", "1.1.0")
+	m.AddWorkloadImage("namespace", "Deployment", "app", "container", "container", "url", "", "", true, "1.1.0", "1.1.0")
+	m.AddWorkloadImage("namespace", "StatefulSet", "db", "container", "container", "url", "", "", true, "1.1.0", "1.1.0")
+
+	// Version changes should replace the existing series for the container
+	assert.Equal(t, 2,
+		testutil.CollectAndCount(m.workloadImageVersion.MetricVec, MetricNamespace+"_is_latest_workload_version"),
+	)
+
+	mt, err := m.workloadImageVersion.GetMetricWith(
+		buildWorkloadFullLabels("namespace", "Deployment", "app", "container", "container", "url", "", "", "1.1.0", "1.1.0"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), testutil.ToFloat64(mt))
+
+	m.RemoveWorkloadImage("namespace", "Deployment", "app", "container", "init")
+	assert.Equal(t, 2,
+		testutil.CollectAndCount(m.workloadImageVersion.MetricVec, MetricNamespace+"_is_latest_workload_version"),
+	)
+
+	m.RemoveWorkload("namespace", "Deployment", "app")
+	assert.Equal(t, 1,
+		testutil.CollectAndCount(m.workloadImageVersion.MetricVec, MetricNamespace+"_is_latest_workload_version"),
+	)
+
+	m.RemoveWorkloadImage("namespace", "StatefulSet", "db", "container", "container")
+	assert.Equal(t, 0,
+		testutil.CollectAndCount(m.workloadImageVersion.MetricVec, MetricNamespace+"_is_latest_workload_version"),
+	)
+}
+
+func TestSetWorkloadImageDistance(t *testing.T) {
+	m := New(logrus.NewEntry(logrus.New()), prometheus.NewRegistry(), fakek8s)
+
+	m.SetWorkloadImageDistance("namespace", "Deployment", "app", "container", "container", "url", &api.VersionDistance{
+		Major: 1, Releases: 4, Age: time.Hour,
+	})
+
+	assert.Equal(t, 4,
+		testutil.CollectAndCount(m.workloadVersionsBehind.MetricVec, MetricNamespace+"_workload_versions_behind"),
+	)
+	mt, err := m.workloadVersionsBehind.GetMetricWith(
+		buildWorkloadDistanceLabels("namespace", "Deployment", "app", "container", "container", "url", LevelReleases),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, float64(4), testutil.ToFloat64(mt))
+
+	mt, err = m.workloadReleaseAge.GetMetricWith(
+		buildWorkloadImageLabels("namespace", "Deployment", "app", "container", "container", "url"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour.Seconds(), testutil.ToFloat64(mt))
+
+	// A nil distance removes the series
+	m.SetWorkloadImageDistance("namespace", "Deployment", "app", "container", "container", "url", nil)
+	assert.Equal(t, 0,
+		testutil.CollectAndCount(m.workloadVersionsBehind.MetricVec, MetricNamespace+"_workload_versions_behind"),
+	)
+
+	// Removing the workload removes the series
+	m.SetWorkloadImageDistance("namespace", "Deployment", "app", "container", "container", "url", &api.VersionDistance{Patch: 1})
+	m.RemoveWorkload("namespace", "Deployment", "app")
+	assert.Equal(t, 0,
+		testutil.CollectAndCount(m.workloadVersionsBehind.MetricVec, MetricNamespace+"_workload_versions_behind"),
+	)
+	assert.Equal(t, 0,
+		testutil.CollectAndCount(m.workloadReleaseAge.MetricVec, MetricNamespace+"_workload_release_age_seconds"),
+	)
+}
+
+func TestSetWorkloadTagDigestDrift(t *testing.T) {
+	m := New(logrus.NewEntry(logrus.New()), prometheus.NewRegistry(), fakek8s)
+
+	m.SetWorkloadTagDigestDrift("namespace", "Deployment", "app", "container", "container", "url", &api.TagDrift{Tag: "1.25"})
+	m.SetWorkloadTagDigestDrift("namespace", "Deployment", "app", "container", "container", "url", &api.TagDrift{Tag: "stable", Drifted: true})
+
+	// Only the series of the tag last checked is kept
+	assert.Equal(t, 1,
+		testutil.CollectAndCount(m.workloadTagDigestDrift.MetricVec, MetricNamespace+"_workload_tag_digest_drift"),
+	)
+	mt, err := m.workloadTagDigestDrift.GetMetricWith(
+		buildWorkloadTagDriftLabels("namespace", "Deployment", "app", "container", "container", "url", "stable"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), testutil.ToFloat64(mt))
+
+	// A nil drift removes the series
+	m.SetWorkloadTagDigestDrift("namespace", "Deployment", "app", "container", "container", "url", nil)
+	assert.Equal(t, 0,
+		testutil.CollectAndCount(m.workloadTagDigestDrift.MetricVec, MetricNamespace+"_workload_tag_digest_drift"),
+	)
+
+	// Removing the workload image, or the workload, removes the series
+	m.SetWorkloadTagDigestDrift("namespace", "Deployment", "app", "container", "container", "url", &api.TagDrift{Tag: "stable"})
+	m.RemoveWorkloadImage("namespace", "Deployment", "app", "container", "container")
+	assert.Equal(t, 0,
+		testutil.CollectAndCount(m.workloadTagDigestDrift.MetricVec, MetricNamespace+"_workload_tag_digest_drift"),
+	)
+
+	m.SetWorkloadTagDigestDrift("namespace", "Deployment", "app", "container", "container", "url", &api.TagDrift{Tag: "stable"})
+	m.RemoveWorkload("namespace", "Deployment", "app")
+	assert.Equal(t, 0,
+		testutil.CollectAndCount(m.workloadTagDigestDrift.MetricVec, MetricNamespace+"_workload_tag_digest_drift"),
+	)
+}
@@ -0,0 +1,218 @@
+// Package constraint parses version constraint expressions, e.g.
+// ">=1.20 <2.0", "~1.4", "^3" or "!=1.5.2", and checks versions against
+// them.
+package constraint
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// operatorSpaceRegex matches whitespace between an operator and its version,
+// e.g. ">= 1.20".
+var operatorSpaceRegex = regexp.MustCompile(`(!=|>=|<=|=|>|<|~|\^)\s+`)
+
+// Version is a version with major, minor and patch components.
+type Version interface {
+	Major() int64
+	Minor() int64
+	Patch() int64
+}
+
+// Constraint is a parsed constraint expression. Terms separated by spaces or
+// commas must all match, and groups of terms separated by "||" are
+// alternatives.
+type Constraint struct {
+	original string
+	groups   [][]term
+}
+
+// term checks the major, minor and patch components of a version.
+type term func(v [3]int64) bool
+
+// Parse parses a constraint expression. Each term is an optional operator
+// followed by a version, where missing or wildcard ("x", "*") components
+// match any value:
+//
+//	1.4, =1.4   1.4.x
+//	!=1.5.2     anything but 1.5.2
+//	>1.4        1.5.0 and above
+//	>=1.20      1.20.0 and above
+//	<2.0        below 2.0.0
+//	<=1.4       1.4.x and below
+//	~1.4        1.4.x
+//	^3          3.x.x
+//	^0.4        0.4.x
+func Parse(expr string) (*Constraint, error) {
+	c := &Constraint{original: expr}
+
+	for _, group := range strings.Split(expr, "||") {
+		group = operatorSpaceRegex.ReplaceAllString(group, "$1")
+		fields := strings.FieldsFunc(group, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("invalid constraint %q: empty expression", expr)
+		}
+
+		terms := make([]term, 0, len(fields))
+		for _, field := range fields {
+			t, err := parseTerm(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid constraint %q: %w", expr, err)
+			}
+			terms = append(terms, t)
+		}
+		c.groups = append(c.groups, terms)
+	}
+
+	return c, nil
+}
+
+// Check returns whether the version matches the constraint.
+func (c *Constraint) Check(v Version) bool {
+	parts := [3]int64{v.Major(), v.Minor(), v.Patch()}
+
+	for _, terms := range c.groups {
+		matched := true
+		for _, t := range terms {
+			if !t(parts) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// String returns the original expression.
+func (c *Constraint) String() string {
+	return c.original
+}
+
+func parseTerm(field string) (term, error) {
+	op := field[:len(field)-len(strings.TrimLeft(field, "!=<>~^"))]
+	parts, n, err := parsePartial(field[len(op):])
+	if err != nil {
+		return nil, err
+	}
+
+	lower := parts
+	upper := increment(parts, n)
+
+	switch op {
+	case "", "=":
+		return between(n, lower, upper), nil
+	case "!=":
+		in := between(n, lower, upper)
+		return func(v [3]int64) bool { return !in(v) }, nil
+	case ">":
+		if n == 0 {
+			return func([3]int64) bool { return false }, nil
+		}
+		return func(v [3]int64) bool { return compare(v, upper) >= 0 }, nil
+	case ">=":
+		return func(v [3]int64) bool { return compare(v, lower) >= 0 }, nil
+	case "<":
+		if n == 0 {
+			return func([3]int64) bool { return false }, nil
+		}
+		return func(v [3]int64) bool { return compare(v, lower) < 0 }, nil
+	case "<=":
+		if n == 0 {
+			return func([3]int64) bool { return true }, nil
+		}
+		return func(v [3]int64) bool { return compare(v, upper) < 0 }, nil
+	case "~":
+		// Allow patch changes, or minor changes if only the major is given.
+		if n > 2 {
+			upper = increment(parts, 2)
+		}
+		return between(n, lower, upper), nil
+	case "^":
+		// Allow changes that don't modify the left-most non-zero component.
+		for i := 0; i < n; i++ {
+			if parts[i] != 0 {
+				upper = increment(parts, i+1)
+				break
+			}
+		}
+		return between(n, lower, upper), nil
+	default:
+		return nil, fmt.Errorf("unknown operator %q", op)
+	}
+}
+
+// parsePartial parses a version with up to three components, returning the
+// number of components given. Components after a wildcard are ignored.
+func parsePartial(s string) ([3]int64, int, error) {
+	var parts [3]int64
+
+	s = strings.TrimPrefix(s, "v")
+	if len(s) == 0 {
+		return parts, 0, fmt.Errorf("missing version")
+	}
+
+	components := strings.Split(s, ".")
+	if len(components) > 3 {
+		return parts, 0, fmt.Errorf("version %q has more than 3 components", s)
+	}
+
+	for i, component := range components {
+		if component == "x" || component == "X" || component == "*" {
+			return parts, i, nil
+		}
+
+		part, err := strconv.ParseInt(component, 10, 64)
+		if err != nil || part < 0 {
+			return parts, 0, fmt.Errorf("invalid version %q", s)
+		}
+		parts[i] = part
+	}
+
+	return parts, len(components), nil
+}
+
+// increment returns the smallest version greater than all versions matching
+// the first n components.
+func increment(parts [3]int64, n int) [3]int64 {
+	if n == 0 {
+		return parts
+	}
+
+	parts[n-1]++
+	for i := n; i < 3; i++ {
+		parts[i] = 0
+	}
+
+	return parts
+}
+
+// between returns a term matching versions from lower up to, but not
+// including, upper. All versions match if no components were given.
+func between(n int, lower, upper [3]int64) term {
+	if n == 0 {
+		return func([3]int64) bool { return true }
+	}
+	return func(v [3]int64) bool {
+		return compare(v, lower) >= 0 && compare(v, upper) < 0
+	}
+}
+
+func compare(a, b [3]int64) int {
+	for i := 0; i < 3; i++ {
+		switch {
+		case a[i] < b[i]:
+			return -1
+		case a[i] > b[i]:
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,138 @@
+package constraint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type version [3]int64
+
+func (v version) Major() int64 { return v[0] }
+func (v version) Minor() int64 { return v[1] }
+func (v version) Patch() int64 { return v[2] }
+
+func TestCheck(t *testing.T) {
+	tests := map[string]struct {
+		expr       string
+		matches    []version
+		notMatches []version
+	}{
+		"range": {
+			expr:       ">=1.20 <2.0",
+			matches:    []version{{1, 20, 0}, {1, 25, 3}},
+			notMatches: []version{{1, 19, 9}, {2, 0, 0}},
+		},
+		"range with spaces after operators and commas": {
+			expr:       ">= 1.20, < 2.0",
+			matches:    []version{{1, 20, 0}},
+			notMatches: []version{{2, 0, 0}},
+		},
+		"tilde allows patch changes": {
+			expr:       "~1.4",
+			matches:    []version{{1, 4, 0}, {1, 4, 9}},
+			notMatches: []version{{1, 3, 9}, {1, 5, 0}},
+		},
+		"tilde with patch": {
+			expr:       "~1.4.2",
+			matches:    []version{{1, 4, 2}, {1, 4, 9}},
+			notMatches: []version{{1, 4, 1}, {1, 5, 0}},
+		},
+		"tilde with only major allows minor changes": {
+			expr:       "~1",
+			matches:    []version{{1, 0, 0}, {1, 9, 0}},
+			notMatches: []version{{2, 0, 0}},
+		},
+		"caret allows minor changes": {
+			expr:       "^3",
+			matches:    []version{{3, 0, 0}, {3, 9, 9}},
+			notMatches: []version{{2, 9, 9}, {4, 0, 0}},
+		},
+		"caret with zero major": {
+			expr:       "^0.4",
+			matches:    []version{{0, 4, 0}, {0, 4, 9}},
+			notMatches: []version{{0, 5, 0}},
+		},
+		"caret with zero major and minor": {
+			expr:       "^0.0.3",
+			matches:    []version{{0, 0, 3}},
+			notMatches: []version{{0, 0, 4}},
+		},
+		"not equal": {
+			expr:       "!=1.5.2",
+			matches:    []version{{1, 5, 1}, {1, 5, 3}},
+			notMatches: []version{{1, 5, 2}},
+		},
+		"latest 1.x but never 1.9.0": {
+			expr:       "^1 !=1.9.0",
+			matches:    []version{{1, 8, 0}, {1, 9, 1}},
+			notMatches: []version{{1, 9, 0}, {2, 0, 0}},
+		},
+		"partial equal": {
+			expr:       "1.4",
+			matches:    []version{{1, 4, 0}, {1, 4, 9}},
+			notMatches: []version{{1, 5, 0}},
+		},
+		"wildcard": {
+			expr:       "1.x",
+			matches:    []version{{1, 0, 0}, {1, 9, 9}},
+			notMatches: []version{{2, 0, 0}},
+		},
+		"greater than partial": {
+			expr:       ">1.4",
+			matches:    []version{{1, 5, 0}},
+			notMatches: []version{{1, 4, 9}},
+		},
+		"less than or equal partial": {
+			expr:       "<=1.4",
+			matches:    []version{{1, 4, 9}},
+			notMatches: []version{{1, 5, 0}},
+		},
+		"v prefix": {
+			expr:       ">=v1.2.3",
+			matches:    []version{{1, 2, 3}},
+			notMatches: []version{{1, 2, 2}},
+		},
+		"or": {
+			expr:       "~1.4 || >=3",
+			matches:    []version{{1, 4, 1}, {3, 0, 0}},
+			notMatches: []version{{2, 0, 0}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, err := Parse(test.expr)
+			require.NoError(t, err)
+			assert.Equal(t, test.expr, c.String())
+
+			for _, v := range test.matches {
+				assert.True(t, c.Check(v), "expected %v to match", v)
+			}
+			for _, v := range test.notMatches {
+				assert.False(t, c.Check(v), "expected %v not to match", v)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"empty":                "",
+		"empty alternative":    ">=1 ||",
+		"unknown operator":     "=>1.2",
+		"missing version":      ">=",
+		"not a number":         ">=1.a",
+		"too many components":  "1.2.3.4",
+		"negative number":      "1.-2",
+		"operator only spacer": "~ ,",
+	}
+
+	for name, expr := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(expr)
+			assert.Error(t, err)
+		})
+	}
+}
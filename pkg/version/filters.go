@@ -4,7 +4,7 @@ import (
 	"strings"
 
 	"github.com/jetstack/version-checker/pkg/api"
-	"github.com/jetstack/version-checker/pkg/version/semver"
+	"github.com/jetstack/version-checker/pkg/version/scheme"
 )
 
 func isSBOMAttestationOrSig(tag string) bool {
@@ -14,11 +14,16 @@ func isSBOMAttestationOrSig(tag string) bool {
 }
 
 // Used when filtering Tags as a SemVer
-func shouldSkipTag(opts *api.Options, v *semver.SemVer) bool {
+func shouldSkipTag(opts *api.Options, v scheme.Version) bool {
 	if isSBOMAttestationOrSig(v.String()) {
 		return true
 	}
 
+	// Constraints apply to regex matches too
+	if opts.ConstraintMatcher != nil && !opts.ConstraintMatcher.Check(v) {
+		return true
+	}
+
 	// Handle Regex matching
 	if opts.RegexMatcher != nil {
 		return !opts.RegexMatcher.MatchString(v.String())
@@ -31,6 +36,28 @@ func shouldSkipTag(opts *api.Options, v *semver.SemVer) bool {
 		(opts.PinPatch != nil && *opts.PinPatch != v.Patch())
 }
 
+// supportsPlatform returns whether the tag, or a child of its manifest list,
+// is built for the platform. Tags whose platforms aren't known are assumed to
+// be.
+func supportsPlatform(opts *api.Options, tag *api.ImageTag) bool {
+	if opts == nil || opts.Platform == nil {
+		return true
+	}
+
+	var known bool
+	for _, t := range append([]*api.ImageTag{tag}, tag.Children...) {
+		if len(t.Architecture) == 0 {
+			continue
+		}
+		if opts.Platform.Matches(t) {
+			return true
+		}
+		known = true
+	}
+
+	return !known
+}
+
 // Used when filtering SHA Tags
 func shouldSkipSHA(opts *api.Options, sha string) bool {
 	// Filter out Sbom and Attestation/Signatures
@@ -46,9 +73,9 @@ func shouldSkipSHA(opts *api.Options, sha string) bool {
 	return false
 }
 
-// isBetterSemVer compares two semantic version numbers and
+// isBetterSemVer compares two versions, parsed by the same version scheme, and
 // associated image tags to determine if one is considered better than the other.
-func isBetterSemVer(_ *api.Options, latestV, v *semver.SemVer, latestImageTag, currentImageTag *api.ImageTag) bool {
+func isBetterSemVer(_ *api.Options, latestV, v scheme.Version, latestImageTag, currentImageTag *api.ImageTag) bool {
 	// No latest version set yet
 	if latestV == nil {
 		return true
@@ -2,24 +2,26 @@ package version
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/jetstack/version-checker/pkg/api"
-	"github.com/jetstack/version-checker/pkg/version/semver"
+	"github.com/jetstack/version-checker/pkg/version/scheme"
 )
 
 // latestSemver will return the latest ImageTag based on the given options
-// restriction, using semver. This should not be used if UseSHA has been
-// enabled.
+// restriction, using the version scheme of the options, semver by default.
+// This should not be used if UseSHA has been enabled.
 func latestSemver(opts *api.Options, tags []api.ImageTag) (*api.ImageTag, error) {
 	var (
 		latestImageTag *api.ImageTag
-		latestV        *semver.SemVer
+		latestV        scheme.Version
 	)
 
+	versionScheme := scheme.ForOptions(opts)
 	for i := range tags {
-		v := semver.Parse(tags[i].Tag)
+		v := versionScheme.Parse(tags[i].Tag)
 
-		if shouldSkipTag(opts, v) {
+		if shouldSkipTag(opts, v) || !supportsPlatform(opts, &tags[i]) {
 			continue
 		}
 
@@ -42,7 +44,7 @@ func latestSHA(opts *api.Options, tags []api.ImageTag) (*api.ImageTag, error) {
 
 	for i := range tags {
 		// Filter out SBOM and Attestation/Sig's...
-		if shouldSkipSHA(opts, tags[i].Tag) {
+		if shouldSkipSHA(opts, tags[i].Tag) || !supportsPlatform(opts, &tags[i]) {
 			continue
 		}
 
@@ -53,3 +55,50 @@ func latestSHA(opts *api.Options, tags []api.ImageTag) (*api.ImageTag, error) {
 
 	return latestTag, nil
 }
+
+// versionDistance returns how far the current tag is behind the latest tag,
+// counting the releases in between that match the options.
+func versionDistance(opts *api.Options, tags []api.ImageTag, currentTag string, latest *api.ImageTag) *api.VersionDistance {
+	distance := new(api.VersionDistance)
+
+	versionScheme := scheme.ForOptions(opts)
+	currentV, latestV := versionScheme.Parse(currentTag), versionScheme.Parse(latest.Tag)
+	if !currentV.LessThan(latestV) {
+		return distance
+	}
+
+	switch {
+	case latestV.Major() != currentV.Major():
+		distance.Major = max(latestV.Major()-currentV.Major(), 0)
+	case latestV.Minor() != currentV.Minor():
+		distance.Minor = max(latestV.Minor()-currentV.Minor(), 0)
+	default:
+		distance.Patch = max(latestV.Patch()-currentV.Patch(), 0)
+	}
+
+	var counted []scheme.Version
+	for i := range tags {
+		v := versionScheme.Parse(tags[i].Tag)
+		if shouldSkipTag(opts, v) || !supportsPlatform(opts, &tags[i]) ||
+			!currentV.LessThan(v) || latestV.LessThan(v) {
+			continue
+		}
+		if !slices.ContainsFunc(counted, v.Equal) {
+			counted = append(counted, v)
+			distance.Releases++
+		}
+	}
+
+	for i := range tags {
+		if tags[i].Tag != currentTag {
+			continue
+		}
+		if !tags[i].Timestamp.IsZero() && !latest.Timestamp.IsZero() &&
+			latest.Timestamp.After(tags[i].Timestamp) {
+			distance.Age = latest.Timestamp.Sub(tags[i].Timestamp)
+		}
+		break
+	}
+
+	return distance
+}
@@ -0,0 +1,60 @@
+package scheme
+
+import (
+	"regexp"
+	"strconv"
+)
+
+var buildRegex = regexp.MustCompile(`[0-9]+`)
+
+// NumericBuild orders tags by the first number in the tag, e.g. 1234,
+// build-1234 or 20241001-abc1234. Tags without a number have metadata.
+type NumericBuild struct{}
+
+type buildVersion struct {
+	original string
+	valid    bool
+	number   int64
+}
+
+// Parse parses the first number in the tag as the build number.
+func (NumericBuild) Parse(tag string) Version {
+	v := &buildVersion{original: tag}
+
+	number, err := strconv.ParseInt(buildRegex.FindString(tag), 10, 64)
+	if err == nil {
+		v.valid = true
+		v.number = number
+	}
+
+	return v
+}
+
+// compare returns -1, 0 or 1, and false if the other version is of a
+// different scheme.
+func (v *buildVersion) compare(other Version) (int, bool) {
+	o, ok := other.(*buildVersion)
+	if !ok {
+		return 0, false
+	}
+	if c, ok := compareInvalid(v.valid, o.valid, v.original, o.original); ok {
+		return c, true
+	}
+	return compareParts([]int64{v.number}, []int64{o.number}), true
+}
+
+func (v *buildVersion) LessThan(other Version) bool {
+	c, ok := v.compare(other)
+	return ok && c < 0
+}
+
+func (v *buildVersion) Equal(other Version) bool {
+	c, ok := v.compare(other)
+	return ok && c == 0
+}
+
+func (v *buildVersion) HasMetaData() bool { return !v.valid }
+func (v *buildVersion) Major() int64      { return v.number }
+func (v *buildVersion) Minor() int64      { return 0 }
+func (v *buildVersion) Patch() int64      { return 0 }
+func (v *buildVersion) String() string    { return v.original }
@@ -0,0 +1,100 @@
+package scheme
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// calverRegex matches YYYY.MM[.DD][.MICRO] or YY.MM[.DD][.MICRO], with
+	// '.', '-' or '_' separators, followed by an optional suffix. Each part
+	// ends at a separator or the end of the tag, so 2024.13.01 isn't month 1.
+	calverRegex = regexp.MustCompile(`^v?([0-9]{4}|[0-9]{2})[._-](1[0-2]|0?[1-9])(?:[._-]([0-9]{1,2})(?:[._-]([0-9]+))?)?((?:[._-].*)?)$`)
+
+	// calverCompactRegex matches YYYYMMDD, followed by an optional suffix.
+	calverCompactRegex = regexp.MustCompile(`^v?([0-9]{4})(0[1-9]|1[0-2])([0-3][0-9])()(.*)$`)
+)
+
+// CalVer orders tags using calendar versioning, e.g. 2024.10.01, 24.04 or
+// 2024.10.01.2. Anything after the date and optional micro version is
+// metadata, and a tag without metadata has higher precedence than the same
+// date with metadata.
+type CalVer struct{}
+
+type calverVersion struct {
+	original string
+	valid    bool
+	// parts holds the year, month, day and micro version.
+	parts  [4]int64
+	suffix string
+}
+
+// Parse parses the tag as a calendar version.
+func (CalVer) Parse(tag string) Version {
+	v := &calverVersion{original: tag}
+
+	match := calverRegex.FindStringSubmatch(tag)
+	if len(match) == 0 {
+		match = calverCompactRegex.FindStringSubmatch(tag)
+	}
+	if len(match) == 0 {
+		return v
+	}
+
+	v.valid = true
+	for i := 0; i < 4; i++ {
+		if len(match[i+1]) > 0 {
+			v.parts[i], _ = strconv.ParseInt(match[i+1], 10, 64)
+		}
+	}
+	// Short years are in this century.
+	if len(match[1]) == 2 {
+		v.parts[0] += 2000
+	}
+	v.suffix = match[5]
+
+	return v
+}
+
+// compare returns -1, 0 or 1, and false if the other version is of a
+// different scheme.
+func (v *calverVersion) compare(other Version) (int, bool) {
+	o, ok := other.(*calverVersion)
+	if !ok {
+		return 0, false
+	}
+	if c, ok := compareInvalid(v.valid, o.valid, v.original, o.original); ok {
+		return c, true
+	}
+	if c := compareParts(v.parts[:], o.parts[:]); c != 0 {
+		return c, true
+	}
+
+	switch {
+	case v.suffix == o.suffix:
+		return 0, true
+	case len(v.suffix) == 0:
+		return 1, true
+	case len(o.suffix) == 0:
+		return -1, true
+	default:
+		return strings.Compare(v.suffix, o.suffix), true
+	}
+}
+
+func (v *calverVersion) LessThan(other Version) bool {
+	c, ok := v.compare(other)
+	return ok && c < 0
+}
+
+func (v *calverVersion) Equal(other Version) bool {
+	c, ok := v.compare(other)
+	return ok && c == 0
+}
+
+func (v *calverVersion) HasMetaData() bool { return !v.valid || len(v.suffix) > 0 }
+func (v *calverVersion) Major() int64      { return v.parts[0] }
+func (v *calverVersion) Minor() int64      { return v.parts[1] }
+func (v *calverVersion) Patch() int64      { return v.parts[2] }
+func (v *calverVersion) String() string    { return v.original }
@@ -0,0 +1,84 @@
+package scheme
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// dateRegex matches a date, with an optional time, anywhere in a tag, e.g.
+// 2024-10-01, 20241001 or 2024-10-01T00-00-00Z.
+var dateRegex = regexp.MustCompile(`([0-9]{4})-?([0-9]{2})-?([0-9]{2})(?:[T_-]?([0-9]{2})[:-]?([0-9]{2})(?:[:-]?([0-9]{2}))?)?`)
+
+// Date orders tags by the date and time extracted from the tag, e.g.
+// RELEASE.2024-10-01T00-00-00Z or nightly-20241001. Tags without a date have
+// metadata.
+type Date struct{}
+
+type dateVersion struct {
+	original string
+	valid    bool
+	date     time.Time
+}
+
+// Parse extracts the first date in the tag.
+func (Date) Parse(tag string) Version {
+	v := &dateVersion{original: tag}
+
+	match := dateRegex.FindStringSubmatch(tag)
+	if len(match) == 0 {
+		return v
+	}
+
+	var parts [6]int
+	for i := range parts {
+		parts[i], _ = strconv.Atoi(match[i+1])
+	}
+	if parts[1] < 1 || parts[1] > 12 || parts[2] < 1 || parts[2] > 31 {
+		return v
+	}
+
+	v.valid = true
+	v.date = time.Date(parts[0], time.Month(parts[1]), parts[2], parts[3], parts[4], parts[5], 0, time.UTC)
+
+	return v
+}
+
+// compare returns -1, 0 or 1, and false if the other version is of a
+// different scheme.
+func (v *dateVersion) compare(other Version) (int, bool) {
+	o, ok := other.(*dateVersion)
+	if !ok {
+		return 0, false
+	}
+	if c, ok := compareInvalid(v.valid, o.valid, v.original, o.original); ok {
+		return c, true
+	}
+	return v.date.Compare(o.date), true
+}
+
+func (v *dateVersion) LessThan(other Version) bool {
+	c, ok := v.compare(other)
+	return ok && c < 0
+}
+
+func (v *dateVersion) Equal(other Version) bool {
+	c, ok := v.compare(other)
+	return ok && c == 0
+}
+
+func (v *dateVersion) HasMetaData() bool { return !v.valid }
+func (v *dateVersion) String() string    { return v.original }
+
+// Major, Minor and Patch return the year, month and day of the date, or 0
+// if the tag has no date.
+func (v *dateVersion) Major() int64 { return v.datePart(int64(v.date.Year())) }
+func (v *dateVersion) Minor() int64 { return v.datePart(int64(v.date.Month())) }
+func (v *dateVersion) Patch() int64 { return v.datePart(int64(v.date.Day())) }
+
+func (v *dateVersion) datePart(part int64) int64 {
+	if !v.valid {
+		return 0
+	}
+	return part
+}
@@ -0,0 +1,35 @@
+package scheme
+
+import (
+	"strings"
+)
+
+// compareInvalid orders invalid versions before valid versions, comparing
+// two invalid versions by their original tag. It returns false if both
+// versions are valid.
+func compareInvalid(aValid, bValid bool, a, b string) (int, bool) {
+	switch {
+	case aValid && bValid:
+		return 0, false
+	case !aValid && !bValid:
+		return strings.Compare(a, b), true
+	case !aValid:
+		return -1, true
+	default:
+		return 1, true
+	}
+}
+
+// compareParts compares version numbers, where a smaller index has a higher
+// weight.
+func compareParts(a, b []int64) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		switch {
+		case a[i] < b[i]:
+			return -1
+		case a[i] > b[i]:
+			return 1
+		}
+	}
+	return 0
+}
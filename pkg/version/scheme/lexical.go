@@ -0,0 +1,31 @@
+package scheme
+
+import (
+	"strings"
+)
+
+// Lexical orders tags by plain string comparison. No tag has metadata.
+type Lexical struct{}
+
+type lexicalVersion string
+
+// Parse returns the tag as is.
+func (Lexical) Parse(tag string) Version {
+	return lexicalVersion(tag)
+}
+
+func (v lexicalVersion) LessThan(other Version) bool {
+	o, ok := other.(lexicalVersion)
+	return ok && strings.Compare(string(v), string(o)) < 0
+}
+
+func (v lexicalVersion) Equal(other Version) bool {
+	o, ok := other.(lexicalVersion)
+	return ok && v == o
+}
+
+func (v lexicalVersion) HasMetaData() bool { return false }
+func (v lexicalVersion) Major() int64      { return 0 }
+func (v lexicalVersion) Minor() int64      { return 0 }
+func (v lexicalVersion) Patch() int64      { return 0 }
+func (v lexicalVersion) String() string    { return string(v) }
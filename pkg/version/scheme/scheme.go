@@ -0,0 +1,114 @@
+// Package scheme provides the versioning schemes used to order image tags.
+// Each scheme parses tags into Versions that can be compared against other
+// Versions of the same scheme.
+package scheme
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+const (
+	// SemVerName is the name of the default semver scheme.
+	SemVerName = "semver"
+	// CalVerName is the name of the calendar versioning scheme.
+	CalVerName = "calver"
+	// NumericBuildName is the name of the build number scheme.
+	NumericBuildName = "numeric-build"
+	// DateName is the name of the date extracted scheme.
+	DateName = "date"
+	// LexicalName is the name of the lexical scheme.
+	LexicalName = "lexical"
+)
+
+// Scheme parses image tags into comparable Versions.
+type Scheme interface {
+	// Parse parses the tag. Tags which are not valid for the scheme are still
+	// returned, but have metadata and sort before all valid tags.
+	Parse(tag string) Version
+}
+
+// Version is an image tag parsed by a Scheme. Versions are only comparable
+// with Versions parsed by the same Scheme.
+type Version interface {
+	// String returns the original tag.
+	String() string
+
+	// LessThan returns true if this Version has a lower precedence than the
+	// other.
+	LessThan(other Version) bool
+
+	// Equal returns true if this Version has the same precedence as the
+	// other.
+	Equal(other Version) bool
+
+	// HasMetaData returns whether the tag has metadata, such as a
+	// pre-release, which is skipped unless metadata is allowed.
+	HasMetaData() bool
+
+	// Major, Minor and Patch return the components used for version pinning
+	// and distance. Schemes without such components return 0.
+	Major() int64
+	Minor() int64
+	Patch() int64
+}
+
+var (
+	mu      sync.RWMutex
+	schemes = map[string]Scheme{
+		SemVerName:       SemVer{},
+		CalVerName:       CalVer{},
+		NumericBuildName: NumericBuild{},
+		DateName:         Date{},
+		LexicalName:      Lexical{},
+	}
+)
+
+// Register adds a named scheme, which can then be selected with the
+// version-scheme option. Registering an existing name replaces it.
+func Register(name string, s Scheme) {
+	mu.Lock()
+	defer mu.Unlock()
+	schemes[name] = s
+}
+
+// Get returns the scheme with the given name, and whether it exists.
+func Get(name string) (Scheme, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	s, ok := schemes[name]
+	return s, ok
+}
+
+// Names returns the sorted names of all registered schemes.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(schemes))
+	for name := range schemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// ForOptions returns the scheme selected by the options, defaulting to
+// semver. The semver scheme uses SemVer 2.0 precedence if StrictSemVer is
+// set.
+func ForOptions(opts *api.Options) Scheme {
+	if opts == nil {
+		return SemVer{}
+	}
+
+	if len(opts.VersionScheme) > 0 && opts.VersionScheme != SemVerName {
+		if s, ok := Get(opts.VersionScheme); ok {
+			return s
+		}
+	}
+
+	return SemVer{Strict: opts.StrictSemVer}
+}
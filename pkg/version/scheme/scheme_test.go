@@ -0,0 +1,226 @@
+package scheme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+func TestForOptions(t *testing.T) {
+	tests := map[string]struct {
+		opts      *api.Options
+		expScheme Scheme
+	}{
+		"nil options is semver": {
+			opts:      nil,
+			expScheme: SemVer{},
+		},
+		"no scheme is semver": {
+			opts:      new(api.Options),
+			expScheme: SemVer{},
+		},
+		"strict semver": {
+			opts:      &api.Options{StrictSemVer: true},
+			expScheme: SemVer{Strict: true},
+		},
+		"calver": {
+			opts:      &api.Options{VersionScheme: CalVerName},
+			expScheme: CalVer{},
+		},
+		"unknown scheme falls back to semver": {
+			opts:      &api.Options{VersionScheme: "foo"},
+			expScheme: SemVer{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expScheme, ForOptions(test.opts))
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	_, ok := Get("custom")
+	assert.False(t, ok)
+
+	Register("custom", Lexical{})
+	t.Cleanup(func() {
+		mu.Lock()
+		delete(schemes, "custom")
+		mu.Unlock()
+	})
+
+	s, ok := Get("custom")
+	assert.True(t, ok)
+	assert.Equal(t, Lexical{}, s)
+	assert.Contains(t, Names(), "custom")
+	assert.Equal(t, Lexical{}, ForOptions(&api.Options{VersionScheme: "custom"}))
+}
+
+func TestLessThan(t *testing.T) {
+	tests := map[string]struct {
+		scheme        Scheme
+		first, second string
+		lessThan      bool
+	}{
+		"calver orders by date": {
+			CalVer{}, "2024.09.30", "2024.10.01",
+			true,
+		},
+		"calver compares numerically": {
+			CalVer{}, "2024.9.1", "2024.10.1",
+			true,
+		},
+		"calver short year": {
+			CalVer{}, "23.10", "24.04",
+			true,
+		},
+		"calver micro version": {
+			CalVer{}, "2024.10.01", "2024.10.01.1",
+			true,
+		},
+		"calver compact date": {
+			CalVer{}, "20240930", "2024.10.01",
+			true,
+		},
+		"calver suffix is lower than no suffix": {
+			CalVer{}, "2024.10.01-rc1", "2024.10.01",
+			true,
+		},
+		"calver invalid is lower": {
+			CalVer{}, "latest", "2024.10.01",
+			true,
+		},
+		"calver invalid month is lower": {
+			CalVer{}, "2024.13.01", "2024.02.01",
+			true,
+		},
+		"numeric build compares numerically": {
+			NumericBuild{}, "build-99", "build-100",
+			true,
+		},
+		"numeric build with commit suffix": {
+			NumericBuild{}, "20241001-fff0000", "20241002-abc1234",
+			true,
+		},
+		"numeric build greater is not less": {
+			NumericBuild{}, "1234", "999",
+			false,
+		},
+		"date extracted with time": {
+			Date{}, "RELEASE.2024-10-01T00-00-00Z", "RELEASE.2024-10-01T10-00-00Z",
+			true,
+		},
+		"date extracted ignores prefix": {
+			Date{}, "RELEASE.2024-10-02T00-00-00Z", "nightly-20241001",
+			false,
+		},
+		"date without a date is lower": {
+			Date{}, "latest", "nightly-20241001",
+			true,
+		},
+		"lexical order": {
+			Lexical{}, "release-a", "release-b",
+			true,
+		},
+		"lexical is not numeric": {
+			Lexical{}, "10", "9",
+			true,
+		},
+		"semver": {
+			SemVer{}, "v1.2.3", "v1.10.0",
+			true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			first, second := test.scheme.Parse(test.first), test.scheme.Parse(test.second)
+			assert.Equal(t, test.lessThan, first.LessThan(second))
+			if test.lessThan {
+				assert.False(t, second.LessThan(first))
+				assert.False(t, first.Equal(second))
+			}
+		})
+	}
+
+	// Versions of different schemes are never less than or equal.
+	assert.False(t, CalVer{}.Parse("2024.10.01").LessThan(SemVer{}.Parse("v1.0.0")))
+	assert.False(t, CalVer{}.Parse("2024.10.01").Equal(Date{}.Parse("2024.10.01")))
+}
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		scheme             Scheme
+		tag                string
+		expMetaData        bool
+		expMajor, expMinor int64
+		expPatch           int64
+	}{
+		"calver": {
+			scheme: CalVer{}, tag: "2024.10.01",
+			expMajor: 2024, expMinor: 10, expPatch: 1,
+		},
+		"calver with suffix has metadata": {
+			scheme: CalVer{}, tag: "2024.10.01-alpine",
+			expMetaData: true,
+			expMajor:    2024, expMinor: 10, expPatch: 1,
+		},
+		"calver invalid has metadata": {
+			scheme: CalVer{}, tag: "latest",
+			expMetaData: true,
+		},
+		"calver with invalid month has metadata": {
+			scheme: CalVer{}, tag: "2024.13.01",
+			expMetaData: true,
+		},
+		"calver with three digit month has metadata": {
+			scheme: CalVer{}, tag: "2024.100",
+			expMetaData: true,
+		},
+		"calver with three digit day has metadata": {
+			scheme: CalVer{}, tag: "2024.10.011",
+			expMetaData: true,
+			expMajor:    2024, expMinor: 10,
+		},
+		"calver short year with suffix": {
+			scheme: CalVer{}, tag: "24.04_1-rc1",
+			expMetaData: true,
+			expMajor:    2024, expMinor: 4, expPatch: 1,
+		},
+		"numeric build": {
+			scheme: NumericBuild{}, tag: "build-1234",
+			expMajor: 1234,
+		},
+		"numeric build without number has metadata": {
+			scheme: NumericBuild{}, tag: "latest",
+			expMetaData: true,
+		},
+		"date": {
+			scheme: Date{}, tag: "RELEASE.2024-10-01T00-00-00Z",
+			expMajor: 2024, expMinor: 10, expPatch: 1,
+		},
+		"date with invalid month has metadata": {
+			scheme: Date{}, tag: "2024-13-01",
+			expMetaData: true,
+		},
+		"lexical": {
+			scheme: Lexical{}, tag: "anything",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			v := test.scheme.Parse(test.tag)
+			assert.Equal(t, test.tag, v.String())
+			assert.Equal(t, test.expMetaData, v.HasMetaData())
+			assert.Equal(t, test.expMajor, v.Major())
+			assert.Equal(t, test.expMinor, v.Minor())
+			assert.Equal(t, test.expPatch, v.Patch())
+			assert.True(t, v.Equal(test.scheme.Parse(test.tag)))
+		})
+	}
+}
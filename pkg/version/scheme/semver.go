@@ -0,0 +1,39 @@
+package scheme
+
+import (
+	"github.com/jetstack/version-checker/pkg/version/semver"
+)
+
+// SemVer orders tags as semantic versions.
+type SemVer struct {
+	// Strict uses SemVer 2.0 precedence.
+	Strict bool
+}
+
+type semverVersion struct {
+	*semver.SemVer
+}
+
+// Parse parses the tag as a semver.
+func (s SemVer) Parse(tag string) Version {
+	if s.Strict {
+		return semverVersion{semver.ParseStrict(tag)}
+	}
+	return semverVersion{semver.Parse(tag)}
+}
+
+func (v semverVersion) LessThan(other Version) bool {
+	o, ok := other.(semverVersion)
+	if !ok {
+		return false
+	}
+	return v.SemVer.LessThan(o.SemVer)
+}
+
+func (v semverVersion) Equal(other Version) bool {
+	o, ok := other.(semverVersion)
+	if !ok {
+		return false
+	}
+	return v.SemVer.Equal(o.SemVer)
+}
@@ -8,6 +8,12 @@ import (
 
 var (
 	versionRegex = regexp.MustCompile(`^v?([0-9]+)(\.[0-9]+)?(\.[0-9]+)?(.*)$`)
+
+	// strictVersionRegex matches a SemVer 2.0 version, optionally prefixed with
+	// 'v' and with the minor and patch versions omitted.
+	strictVersionRegex = regexp.MustCompile(`^v?(0|[1-9][0-9]*)(?:\.(0|[1-9][0-9]*))?(?:\.(0|[1-9][0-9]*))?` +
+		`(?:-((?:0|[1-9][0-9]*|[0-9]*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9][0-9]*|[0-9]*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+		`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
 )
 
 // SemVer is a struct to contain a SemVer of an image tag.
@@ -21,6 +27,18 @@ type SemVer struct {
 	// version is the version number of a tag. 'Left', or smaller index, the
 	// higher weight.
 	version [3]int64
+
+	// strict is true when the tag was parsed with ParseStrict, and will be
+	// compared using SemVer 2.0 precedence.
+	strict bool
+	// valid is true when a strict tag is a valid SemVer 2.0 version.
+	valid bool
+	// prerelease holds the dot separated pre-release identifiers of a strict
+	// tag.
+	prerelease []string
+	// build holds the build metadata of a strict tag, which is ignored when
+	// comparing.
+	build string
 }
 
 func Parse(tag string) *SemVer {
@@ -45,11 +63,47 @@ func Parse(tag string) *SemVer {
 	return s
 }
 
+// ParseStrict parses the tag as a SemVer 2.0 version, separating the
+// pre-release identifiers from the build metadata. The 'v' prefix is ignored,
+// and missing minor or patch versions are treated as 0. Tags which are not
+// valid versions are kept, but sort before all valid versions.
+func ParseStrict(tag string) *SemVer {
+	s := &SemVer{
+		original: tag,
+		version:  [3]int64{},
+		strict:   true,
+	}
+
+	match := strictVersionRegex.FindStringSubmatch(tag)
+	if len(match) == 0 {
+		s.metadata = tag
+		return s
+	}
+
+	s.valid = true
+	for i := 0; i < 3; i++ {
+		if len(match[i+1]) > 0 {
+			s.version[i], _ = strconv.ParseInt(match[i+1], 10, 64)
+		}
+	}
+	if len(match[4]) > 0 {
+		s.prerelease = strings.Split(match[4], ".")
+		s.metadata = match[4]
+	}
+	s.build = match[5]
+
+	return s
+}
+
 // LessThan will return true if the given semver is equal, or larger that the
 // calling semver. If the calling SemVer has metadata, then ASCII comparison
 // will take place on the version.
 // e.g. v1.0.1-alpha.1 < v1.0.1-beta.0.
 func (s *SemVer) LessThan(other *SemVer) bool {
+	if s.strict && other.strict {
+		return s.compareStrict(other) < 0
+	}
+
 	if s.isInvalidComparison(other) {
 		return len(s.original) < len(other.original)
 	}
@@ -109,14 +163,97 @@ func (s *SemVer) comparePreReleaseMetadata(other *SemVer) bool {
 	return false
 }
 
-// Equal will return true if the given semver is equal.
+// compareStrict compares two strict tags using SemVer 2.0 precedence,
+// returning -1, 0 or 1. Invalid versions sort before valid versions, and are
+// compared by their original string.
+func (s *SemVer) compareStrict(other *SemVer) int {
+	switch {
+	case !s.valid && !other.valid:
+		return strings.Compare(s.original, other.original)
+	case !s.valid:
+		return -1
+	case !other.valid:
+		return 1
+	}
+
+	for i := 0; i < 3; i++ {
+		if s.version[i] != other.version[i] {
+			if s.version[i] < other.version[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	// A version without pre-release identifiers has higher precedence.
+	switch {
+	case len(s.prerelease) == 0 && len(other.prerelease) == 0:
+		return 0
+	case len(s.prerelease) == 0:
+		return 1
+	case len(other.prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(s.prerelease) && i < len(other.prerelease); i++ {
+		if c := compareIdentifiers(s.prerelease[i], other.prerelease[i]); c != 0 {
+			return c
+		}
+	}
+
+	// A larger set of pre-release identifiers has higher precedence, if all
+	// preceding identifiers are equal.
+	switch {
+	case len(s.prerelease) < len(other.prerelease):
+		return -1
+	case len(s.prerelease) > len(other.prerelease):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compareIdentifiers compares two pre-release identifiers. Numeric
+// identifiers are compared numerically, and have lower precedence than
+// alphanumeric identifiers, which are compared in ASCII order.
+func compareIdentifiers(a, b string) int {
+	aInt, aErr := strconv.ParseUint(a, 10, 64)
+	bInt, bErr := strconv.ParseUint(b, 10, 64)
+
+	switch {
+	case aErr == nil && bErr == nil:
+		if aInt == bInt {
+			return 0
+		}
+		if aInt < bInt {
+			return -1
+		}
+		return 1
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// Equal will return true if the given semver is equal. Strict tags are equal
+// if they have the same precedence, ignoring the 'v' prefix and build
+// metadata.
 func (s *SemVer) Equal(other *SemVer) bool {
+	if s.strict && other.strict {
+		return s.compareStrict(other) == 0
+	}
+
 	return s.original == other.original
 }
 
 // HasMetaData returns whether this SemVer has metadata. MetaData is defined
 // as a tag containing anything after the patch digit.
 // e.g. v1.0.1-gke.3, v1.0.1-alpha.0, v1.2.3.4.
+// For strict tags, only pre-release identifiers are metadata, so
+// v1.0.1+build.3 is a stable release.
 func (s *SemVer) HasMetaData() bool {
 	return len(s.metadata) > 0
 }
@@ -220,3 +220,173 @@ func TestLessThan(t *testing.T) {
 		})
 	}
 }
+
+func TestParseStrict(t *testing.T) {
+	tests := map[string]struct {
+		input         string
+		expVersion    [3]int64
+		expPreRelease []string
+		expBuild      string
+		expMetadata   bool
+	}{
+		"v prefix is ignored": {
+			input:      "v1.2.3",
+			expVersion: [3]int64{1, 2, 3},
+		},
+		"missing minor and patch are 0": {
+			input:      "1.2",
+			expVersion: [3]int64{1, 2, 0},
+		},
+		"pre-release identifiers are split": {
+			input:         "1.2.3-rc.1",
+			expVersion:    [3]int64{1, 2, 3},
+			expPreRelease: []string{"rc", "1"},
+			expMetadata:   true,
+		},
+		"build metadata is separated from pre-release": {
+			input:         "v1.2.3-alpha.1+build.5",
+			expVersion:    [3]int64{1, 2, 3},
+			expPreRelease: []string{"alpha", "1"},
+			expBuild:      "build.5",
+			expMetadata:   true,
+		},
+		"build metadata alone is not metadata": {
+			input:      "1.2.3+20240101",
+			expVersion: [3]int64{1, 2, 3},
+			expBuild:   "20240101",
+		},
+		"leading zeros are invalid": {
+			input:       "1.02.3",
+			expMetadata: true,
+		},
+		"extra version numbers are invalid": {
+			input:       "1.2.3.4",
+			expMetadata: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := ParseStrict(test.input)
+			if s.version != test.expVersion {
+				t.Errorf("unexpected version, exp=%v got=%v", test.expVersion, s.version)
+			}
+			if !reflect.DeepEqual(s.prerelease, test.expPreRelease) {
+				t.Errorf("unexpected pre-release, exp=%v got=%v", test.expPreRelease, s.prerelease)
+			}
+			if s.build != test.expBuild {
+				t.Errorf("unexpected build, exp=%q got=%q", test.expBuild, s.build)
+			}
+			if s.HasMetaData() != test.expMetadata {
+				t.Errorf("unexpected metadata, exp=%t got=%t", test.expMetadata, s.HasMetaData())
+			}
+		})
+	}
+}
+
+func TestLessThanStrict(t *testing.T) {
+	tests := map[string]struct {
+		first, second string
+		lessThan      bool
+	}{
+		"pre-release is less than release": {
+			"1.0.0-alpha", "1.0.0",
+			true,
+		},
+		"release is not less than pre-release": {
+			"1.0.0", "1.0.0-alpha",
+			false,
+		},
+		"older release is less than pre-release": {
+			"0.9.0", "1.0.0-alpha",
+			true,
+		},
+		"smaller identifier set is less": {
+			"1.0.0-alpha", "1.0.0-alpha.1",
+			true,
+		},
+		"numeric identifier is less than alphanumeric": {
+			"1.0.0-alpha.1", "1.0.0-alpha.beta",
+			true,
+		},
+		"alphanumeric identifiers compare in ASCII order": {
+			"1.0.0-alpha.beta", "1.0.0-beta",
+			true,
+		},
+		"numeric identifiers compare numerically": {
+			"1.0.0-beta.2", "1.0.0-beta.11",
+			true,
+		},
+		"rc is less than release": {
+			"1.0.0-rc.1", "1.0.0",
+			true,
+		},
+		"build metadata is ignored": {
+			"1.0.0+build.9", "1.0.0+build.10",
+			false,
+		},
+		"v prefix is ignored": {
+			"v1.0.0", "1.0.0",
+			false,
+		},
+		"invalid is less than valid": {
+			"latest", "0.0.1",
+			true,
+		},
+		"valid is not less than invalid": {
+			"0.0.1", "latest",
+			false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if ParseStrict(test.first).LessThan(ParseStrict(test.second)) != test.lessThan {
+				t.Errorf("unexpected less than, first=%s second=%s expLessThan=%t",
+					test.first, test.second, test.lessThan)
+			}
+		})
+	}
+}
+
+func TestEqualStrict(t *testing.T) {
+	tests := map[string]struct {
+		first, second string
+		equal         bool
+	}{
+		"v prefix is ignored": {
+			"v1.2.3", "1.2.3",
+			true,
+		},
+		"build metadata is ignored": {
+			"1.2.3+build.1", "v1.2.3+build.2",
+			true,
+		},
+		"missing patch is 0": {
+			"1.2", "1.2.0",
+			true,
+		},
+		"pre-release is not equal to release": {
+			"1.2.3-rc.1", "1.2.3",
+			false,
+		},
+		"same invalid tag is equal": {
+			"latest", "latest",
+			true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if ParseStrict(test.first).Equal(ParseStrict(test.second)) != test.equal {
+				t.Errorf("unexpected equal, first=%s second=%s expEqual=%t",
+					test.first, test.second, test.equal)
+			}
+		})
+	}
+
+	// Without strict parsing, the original tags are compared.
+	if Parse("v1.2.3").Equal(Parse("1.2.3")) {
+		t.Error("expected non-strict v1.2.3 and 1.2.3 to not be equal")
+	}
+}
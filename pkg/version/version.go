@@ -10,6 +10,7 @@ import (
 
 	"github.com/jetstack/version-checker/pkg/api"
 	"github.com/jetstack/version-checker/pkg/client"
+	"github.com/jetstack/version-checker/pkg/client/util"
 
 	"github.com/jetstack/version-checker/pkg/cache"
 	versionerrors "github.com/jetstack/version-checker/pkg/version/errors"
@@ -24,7 +25,11 @@ type Version struct {
 	imageCache *cache.Cache
 }
 
-func New(log *logrus.Entry, client client.ClientHandler, cacheTimeout time.Duration) *Version {
+// New constructs a new Version, caching image tags for cacheTimeout. The
+// image cache is configured with cacheOpts, e.g. to persist it so that it
+// survives restarts. Tags looked up with the keychain of a context, such as
+// that of a Pod's imagePullSecrets, are cached apart from other tags.
+func New(log *logrus.Entry, client client.ClientHandler, cacheTimeout time.Duration, cacheOpts cache.Options) *Version {
 	log = log.WithField("module", "version_getter")
 
 	v := &Version{
@@ -32,7 +37,8 @@ func New(log *logrus.Entry, client client.ClientHandler, cacheTimeout time.Durat
 		client: client,
 	}
 
-	v.imageCache = cache.New(log, cacheTimeout, v)
+	cacheOpts.Name = "image"
+	v.imageCache = cache.NewWithOptions(context.Background(), log, cacheTimeout, v, cacheOpts)
 
 	return v
 }
@@ -40,7 +46,7 @@ func New(log *logrus.Entry, client client.ClientHandler, cacheTimeout time.Durat
 // LatestTagFromImage will return the latest tag given an imageURL, according
 // to the given options.
 func (v *Version) LatestTagFromImage(ctx context.Context, imageURL string, opts *api.Options) (*api.ImageTag, error) {
-	tagsI, err := v.imageCache.Get(ctx, imageURL, imageURL, nil)
+	tagsI, err := v.imageCache.Get(ctx, util.ContextCacheIndex(ctx, imageURL), imageURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -78,7 +84,7 @@ func (v *Version) LatestTagFromImage(ctx context.Context, imageURL string, opts
 
 // ResolveSHAToTag Resolve a SHA to a tag if possible
 func (v *Version) ResolveSHAToTag(ctx context.Context, imageURL string, imageSHA string) (string, error) {
-	tagsI, err := v.imageCache.Get(ctx, imageURL, imageURL, nil)
+	tagsI, err := v.imageCache.Get(ctx, util.ContextCacheIndex(ctx, imageURL), imageURL, nil)
 	if err != nil {
 		return "", err
 	}
@@ -93,6 +99,61 @@ func (v *Version) ResolveSHAToTag(ctx context.Context, imageURL string, imageSHA
 	return "", nil
 }
 
+// ResolveTagToSHA Resolve a tag to its SHA if possible. If the tag is a
+// manifest list without a digest, the digest of the first child built for the
+// given platform is used, or of the first child if the platform is nil.
+// Children of unknown platform are built for any platform.
+func (v *Version) ResolveTagToSHA(ctx context.Context, imageURL string, tag string, platform *api.Platform) (string, error) {
+	imageTag, err := v.Tag(ctx, imageURL, tag)
+	if err != nil || imageTag == nil {
+		return "", err
+	}
+
+	if imageTag.SHA != "" {
+		return imageTag.SHA, nil
+	}
+	for _, child := range imageTag.Children {
+		if child.SHA == "" {
+			continue
+		}
+		if platform == nil || len(child.Architecture) == 0 || platform.Matches(child) {
+			return child.SHA, nil
+		}
+	}
+
+	return "", nil
+}
+
+// Tag returns the image tag of the given name, including the digests of its
+// children if it is a manifest list, or nil if the image has no such tag.
+func (v *Version) Tag(ctx context.Context, imageURL string, tag string) (*api.ImageTag, error) {
+	tagsI, err := v.imageCache.Get(ctx, util.ContextCacheIndex(ctx, imageURL), imageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	tags := tagsI.([]api.ImageTag)
+
+	for i := range tags {
+		if tags[i].Tag == tag {
+			return &tags[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Distance returns how far the current tag is behind the latest tag of the
+// image, according to the given options.
+func (v *Version) Distance(ctx context.Context, imageURL, currentTag string, latest *api.ImageTag, opts *api.Options) (*api.VersionDistance, error) {
+	tagsI, err := v.imageCache.Get(ctx, util.ContextCacheIndex(ctx, imageURL), imageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	tags := tagsI.([]api.ImageTag)
+
+	return versionDistance(opts, tags, currentTag, latest), nil
+}
+
 // Fetch returns the given image tags for a given image URL.
 func (v *Version) Fetch(ctx context.Context, imageURL string, _ *api.Options) (interface{}, error) {
 	// fetch tags from image URL
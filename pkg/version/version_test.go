@@ -10,6 +10,8 @@ import (
 	"github.com/jetstack/version-checker/pkg/api"
 	"github.com/jetstack/version-checker/pkg/cache"
 	"github.com/jetstack/version-checker/pkg/client"
+	"github.com/jetstack/version-checker/pkg/client/util"
+	"github.com/jetstack/version-checker/pkg/version/constraint"
 	"github.com/sirupsen/logrus"
 
 	"github.com/stretchr/testify/assert"
@@ -49,6 +51,45 @@ func TestLatestSemver(t *testing.T) {
 		{Tag: "1.1.1", Timestamp: parseTime("2023-06-04T00:00:00Z")},
 		{Tag: "2.0.0", Timestamp: parseTime("2023-06-05T00:00:00Z")},
 	}
+	// Pre-release identifiers and build metadata
+	strictTags := []api.ImageTag{
+		{Tag: "v1.9.0", Timestamp: parseTime("2023-06-01T00:00:00Z")},
+		{Tag: "1.10.0", Timestamp: parseTime("2023-06-02T00:00:00Z")},
+		{Tag: "v1.10.0+build.2", Timestamp: parseTime("2023-06-03T00:00:00Z")},
+		{Tag: "v2.0.0-rc.10", Timestamp: parseTime("2023-06-04T00:00:00Z")},
+		{Tag: "v2.0.0-rc.9", Timestamp: parseTime("2023-06-05T00:00:00Z")},
+		{Tag: "v2.0.0-rc", Timestamp: parseTime("2023-06-06T00:00:00Z")},
+	}
+	// Tags which semver mis-orders
+	calverTags := []api.ImageTag{
+		{Tag: "2024.9.30", Timestamp: parseTime("2023-06-01T00:00:00Z")},
+		{Tag: "2024.10.01", Timestamp: parseTime("2023-06-02T00:00:00Z")},
+		{Tag: "2024.10.01-rc1", Timestamp: parseTime("2023-06-03T00:00:00Z")},
+		{Tag: "latest", Timestamp: parseTime("2023-06-04T00:00:00Z")},
+	}
+	buildTags := []api.ImageTag{
+		{Tag: "20240930-fff0000", Timestamp: parseTime("2023-06-01T00:00:00Z")},
+		{Tag: "20241001-abc1234", Timestamp: parseTime("2023-06-02T00:00:00Z")},
+		{Tag: "latest", Timestamp: parseTime("2023-06-04T00:00:00Z")},
+	}
+	// Manifest lists, single platform images and images of unknown platform
+	platformTags := []api.ImageTag{
+		{Tag: "v1.0.0", Timestamp: parseTime("2023-06-01T00:00:00Z"), Children: []*api.ImageTag{
+			{SHA: "sha:amd64", OS: "linux", Architecture: "amd64"},
+			{SHA: "sha:arm64", OS: "linux", Architecture: "arm64"},
+		}},
+		{Tag: "v1.1.0", Timestamp: parseTime("2023-06-02T00:00:00Z"), OS: "linux", Architecture: "arm64"},
+		{Tag: "v1.2.0", Timestamp: parseTime("2023-06-03T00:00:00Z"), Children: []*api.ImageTag{
+			{SHA: "sha:amd64", OS: "linux", Architecture: "amd64"},
+			{SHA: "sha:att", OS: "unknown", Architecture: "unknown"},
+		}},
+		{Tag: "v1.3.0", Timestamp: parseTime("2023-06-04T00:00:00Z"), OS: "windows", Architecture: "arm64"},
+	}
+	dateTags := []api.ImageTag{
+		{Tag: "RELEASE.2024-09-30T10-00-00Z", Timestamp: parseTime("2023-06-01T00:00:00Z")},
+		{Tag: "RELEASE.2024-10-01T00-00-00Z", Timestamp: parseTime("2023-06-02T00:00:00Z")},
+		{Tag: "RELEASE.2024-02-01T00-00-00Z", Timestamp: parseTime("2023-06-03T00:00:00Z")},
+	}
 	// Include More Alpha/Beta/RC
 	alphaBetaTags := []api.ImageTag{
 		{Tag: "v1.0.0", Timestamp: parseTime("2023-06-01T00:00:00Z")},
@@ -209,6 +250,102 @@ func TestLatestSemver(t *testing.T) {
 			tags:     alphaBetaTags,
 			expected: "v1.1.1",
 		},
+		{
+			name: "Build metadata is metadata without strict SemVer",
+			opts: &api.Options{
+				UseMetaData: false,
+			},
+			tags:     strictTags,
+			expected: "1.10.0",
+		},
+		{
+			name: "Build metadata is a stable release with strict SemVer",
+			opts: &api.Options{
+				StrictSemVer: true,
+			},
+			tags:     strictTags,
+			expected: "v1.10.0+build.2",
+		},
+		{
+			name: "Strict SemVer pre-release precedence",
+			opts: &api.Options{
+				UseMetaData:  true,
+				StrictSemVer: true,
+			},
+			tags:     strictTags,
+			expected: "v2.0.0-rc.10",
+		},
+		{
+			name: "Constraint excludes a known broken version",
+			opts: &api.Options{
+				ConstraintMatcher: mustParseConstraint(t, "^1 !=1.1.1"),
+			},
+			tags:     tagsNoPrefix,
+			expected: "1.1.0",
+		},
+		{
+			name: "Constraint applies to regex matches",
+			opts: &api.Options{
+				RegexMatcher:      regexp.MustCompile(`^v(\d+)(\.\d+)?(\.\d+)?(.*)$`),
+				ConstraintMatcher: mustParseConstraint(t, "<2.0.0"),
+			},
+			tags:     badTags,
+			expected: "v1.1.1",
+		},
+		{
+			name: "CalVer scheme",
+			opts: &api.Options{
+				VersionScheme: "calver",
+			},
+			tags:     calverTags,
+			expected: "2024.10.01",
+		},
+		{
+			name: "Numeric build scheme",
+			opts: &api.Options{
+				VersionScheme: "numeric-build",
+			},
+			tags:     buildTags,
+			expected: "20241001-abc1234",
+		},
+		{
+			name: "Date scheme",
+			opts: &api.Options{
+				VersionScheme: "date",
+			},
+			tags:     dateTags,
+			expected: "RELEASE.2024-10-01T00-00-00Z",
+		},
+		{
+			name: "Tags not built for the platform are skipped",
+			opts: &api.Options{
+				Platform: &api.Platform{OS: "linux", Architecture: "arm64"},
+			},
+			tags:     platformTags,
+			expected: "v1.1.0",
+		},
+		{
+			name: "Platform architecture without OS",
+			opts: &api.Options{
+				Platform: &api.Platform{Architecture: "arm64"},
+			},
+			tags:     platformTags,
+			expected: "v1.3.0",
+		},
+		{
+			name: "Tags of unknown platform are not skipped",
+			opts: &api.Options{
+				Platform: &api.Platform{OS: "linux", Architecture: "arm64"},
+			},
+			tags:     tagsNoPrefix,
+			expected: "2.0.0",
+		},
+		{
+			name:     "No platform",
+			opts:     &api.Options{},
+			tags:     platformTags,
+			expected: "v1.3.0",
+		},
 	}
 
 	for _, tt := range tests {
@@ -295,6 +432,15 @@ func TestLatestSHA(t *testing.T) {
 			options:     &api.Options{RegexMatcher: regexp.MustCompile("^([0-9]+)$")},
 			expectedSHA: strPtr("sha2"),
 		},
+		{
+			name: "Multiple tags, with platform",
+			tags: []api.ImageTag{
+				{SHA: "sha1", Timestamp: time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC), OS: "linux", Architecture: "arm64"},
+				{SHA: "sha2", Timestamp: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC), OS: "linux", Architecture: "amd64"},
+			},
+			options:     &api.Options{Platform: &api.Platform{OS: "linux", Architecture: "arm64"}},
+			expectedSHA: strPtr("sha1"),
+		},
 		{
 			name:        "No tags",
 			tags:        []api.ImageTag{},
@@ -481,6 +627,190 @@ func TestLatestTagFromImage(t *testing.T) {
 	}
 }
 
+func TestResolveTagToSHA(t *testing.T) {
+	tags := []api.ImageTag{
+		{Tag: "v1.0.0", SHA: "sha1"},
+		{Tag: "v1.1.0", Children: []*api.ImageTag{{SHA: "sha2"}, {SHA: "sha3"}}},
+		{Tag: "v1.2.0", Children: []*api.ImageTag{
+			{SHA: "sha4", OS: "linux", Architecture: "amd64"},
+			{SHA: "sha5", OS: "linux", Architecture: "arm64"},
+		}},
+		{Tag: "v1.3.0", SHA: "sha6", Children: []*api.ImageTag{
+			{SHA: "sha7", OS: "linux", Architecture: "amd64"},
+			{SHA: "sha8", OS: "linux", Architecture: "arm64"},
+		}},
+	}
+	arm64 := &api.Platform{OS: "linux", Architecture: "arm64"}
+
+	tests := map[string]struct {
+		tag      string
+		platform *api.Platform
+		expSHA   string
+	}{
+		"tag with digest":                                         {tag: "v1.0.0", expSHA: "sha1"},
+		"manifest list uses first child digest":                   {tag: "v1.1.0", expSHA: "sha2"},
+		"manifest list uses the child digest of the platform":     {tag: "v1.2.0", platform: arm64, expSHA: "sha5"},
+		"manifest list of unknown platforms uses the first child": {tag: "v1.1.0", platform: arm64, expSHA: "sha2"},
+		"manifest list with a digest uses the list digest":        {tag: "v1.3.0", platform: arm64, expSHA: "sha6"},
+		"unknown tag": {tag: "v2.0.0", expSHA: ""},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockClient := &MockClient{}
+			mockClient.On("Tags", mock.Anything, "example.com/image").Return(tags, nil)
+
+			log := logrus.NewEntry(logrus.New())
+			v := &Version{
+				log:    log,
+				client: mockClient,
+			}
+			v.imageCache = cache.New(log, time.Minute, v)
+
+			sha, err := v.ResolveTagToSHA(context.Background(), "example.com/image", test.tag, test.platform)
+			require.NoError(t, err)
+			assert.Equal(t, test.expSHA, sha)
+		})
+	}
+}
+
+func TestTag(t *testing.T) {
+	tags := []api.ImageTag{
+		{Tag: "v1.0.0", SHA: "sha1"},
+		{Tag: "v1.1.0", SHA: "sha2", Children: []*api.ImageTag{{SHA: "sha3"}, {SHA: "sha4"}}},
+	}
+
+	tests := map[string]struct {
+		tag    string
+		expTag *api.ImageTag
+	}{
+		"tag with digest":             {tag: "v1.0.0", expTag: &tags[0]},
+		"manifest list with children": {tag: "v1.1.0", expTag: &tags[1]},
+		"unknown tag":                 {tag: "v2.0.0"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockClient := &MockClient{}
+			mockClient.On("Tags", mock.Anything, "example.com/image").Return(tags, nil)
+
+			log := logrus.NewEntry(logrus.New())
+			v := &Version{
+				log:    log,
+				client: mockClient,
+			}
+			v.imageCache = cache.New(log, time.Minute, v)
+
+			tag, err := v.Tag(context.Background(), "example.com/image", test.tag)
+			require.NoError(t, err)
+			assert.Equal(t, test.expTag, tag)
+		})
+	}
+}
+
+func TestTagsCachedByKeychain(t *testing.T) {
+	mockClient := &MockClient{}
+	mockClient.On("Tags", mock.Anything, "example.com/image").Return([]api.ImageTag{{Tag: "v1.0.0", SHA: "sha1"}}, nil)
+
+	log := logrus.NewEntry(logrus.New())
+	v := &Version{
+		log:    log,
+		client: mockClient,
+	}
+	v.imageCache = cache.New(log, time.Minute, v)
+
+	keychain := util.HostKeychain{"example.com": {Username: "pod-user"}}
+	contexts := []context.Context{
+		context.Background(),
+		util.ContextWithKeychain(context.Background(), keychain, "default/pod-secret"),
+		util.ContextWithKeychain(context.Background(), keychain, "default/other-secret"),
+	}
+
+	// Each set of credentials fetches the tags once
+	for i := 0; i < 2; i++ {
+		for _, ctx := range contexts {
+			_, err := v.Tag(ctx, "example.com/image", "v1.0.0")
+			require.NoError(t, err)
+		}
+	}
+	mockClient.AssertNumberOfCalls(t, "Tags", len(contexts))
+}
+
+func TestVersionDistance(t *testing.T) {
+	int64p := func(i int64) *int64 { return &i }
+
+	tags := []api.ImageTag{
+		{Tag: "v1.0.0", Timestamp: parseTime("2023-06-01T00:00:00Z")},
+		{Tag: "v1.0.1", Timestamp: parseTime("2023-06-02T00:00:00Z")},
+		{Tag: "v1.1.0", Timestamp: parseTime("2023-06-03T00:00:00Z")},
+		{Tag: "v1.1.1-alpha", Timestamp: parseTime("2023-06-04T00:00:00Z")},
+		{Tag: "v1.1.1", Timestamp: parseTime("2023-06-05T00:00:00Z")},
+		{Tag: "v1.2.0"},
+		{Tag: "v2.0.0", Timestamp: parseTime("2023-06-10T00:00:00Z")},
+	}
+
+	tests := map[string]struct {
+		opts        *api.Options
+		currentTag  string
+		latest      api.ImageTag
+		expDistance *api.VersionDistance
+	}{
+		"latest version has no distance": {
+			opts:        new(api.Options),
+			currentTag:  "v2.0.0",
+			latest:      tags[6],
+			expDistance: &api.VersionDistance{},
+		},
+		"major behind only reports major delta": {
+			opts:       new(api.Options),
+			currentTag: "v1.0.0",
+			latest:     tags[6],
+			expDistance: &api.VersionDistance{
+				Major: 1, Releases: 5, Age: 9 * 24 * time.Hour,
+			},
+		},
+		"minor behind": {
+			opts:       new(api.Options),
+			currentTag: "v1.0.1",
+			latest:     tags[5],
+			expDistance: &api.VersionDistance{
+				Minor: 2, Releases: 3,
+			},
+		},
+		"patch behind": {
+			opts:       new(api.Options),
+			currentTag: "v1.1.0",
+			latest:     tags[4],
+			expDistance: &api.VersionDistance{
+				Patch: 1, Releases: 1, Age: 2 * 24 * time.Hour,
+			},
+		},
+		"releases are filtered by options": {
+			opts:       &api.Options{PinMajor: int64p(1), PinMinor: int64p(1)},
+			currentTag: "v1.0.0",
+			latest:     tags[4],
+			expDistance: &api.VersionDistance{
+				Minor: 1, Releases: 2, Age: 4 * 24 * time.Hour,
+			},
+		},
+		"unknown current tag has no age": {
+			opts:       new(api.Options),
+			currentTag: "v1.0.2",
+			latest:     tags[4],
+			expDistance: &api.VersionDistance{
+				Minor: 1, Releases: 2,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			distance := versionDistance(test.opts, tags, test.currentTag, &test.latest)
+			assert.Equal(t, test.expDistance, distance)
+		})
+	}
+}
+
 func TestNew(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -507,7 +837,7 @@ func TestNew(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			version := New(tt.log, tt.client, tt.cacheTimeout)
+			version := New(tt.log, tt.client, tt.cacheTimeout, cache.Options{})
 
 			assert.NotNil(t, version)
 			assert.Equal(t, tt.log.WithField("module", "version_getter"), version.log)
@@ -533,3 +863,12 @@ func (m *MockClient) Tags(ctx context.Context, img string) ([]api.ImageTag, erro
 	args := m.Called(ctx, img)
 	return args.Get(0).([]api.ImageTag), args.Error(1)
 }
+
+func mustParseConstraint(t *testing.T, expr string) *constraint.Constraint {
+	t.Helper()
+	c, err := constraint.Parse(expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}